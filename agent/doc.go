@@ -0,0 +1,9 @@
+// Package agent implements the fontctl font session agent: jobs (i.e. render farm tasks) lease a set
+// of fonts, keep the lease alive with heartbeats and release it when done. Fonts are reference counted
+// across all leases, loaded when the first lease needs them and unloaded when the last lease that uses
+// them is released or expires. A crashed job therefore can't leave fonts loaded until the next reboot.
+//
+// The package is operating system independent. Fonts are loaded and unloaded through the Loader
+// interface, which the fontctl CLI implements with the winfont and linuxfont packages. The HTTP API only
+// serves local clients that know the token of the user running the agent, see NewHandler.
+package agent
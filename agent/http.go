@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// acquireRequest is the body of POST /v1/leases.
+type acquireRequest struct {
+	Owner      string   `json:"owner"`
+	Fonts      []string `json:"fonts"`
+	TTLSeconds float64  `json:"ttl_seconds"`
+}
+
+// leaseResponse is a Lease as returned by the API.
+type leaseResponse struct {
+	Lease
+	TTLSeconds float64 `json:"ttl_seconds"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func newLeaseResponse(l Lease) leaseResponse {
+	return leaseResponse{Lease: l, TTLSeconds: l.TTL.Seconds()}
+}
+
+// NewHandler returns the HTTP/JSON API of the agent:
+//
+//	POST   /v1/leases                 {"owner": "...", "fonts": ["C:\\fonts\\a.ttf"], "ttl_seconds": 60}
+//	POST   /v1/leases/{id}/heartbeat  extend the lease by its TTL
+//	DELETE /v1/leases/{id}            release the lease
+//	GET    /v1/leases                 list active leases
+//	GET    /v1/fonts                  list loaded fonts with their reference counts
+//
+// Requests must send the token as "Authorization: Bearer <token>" and have a loopback Host header, and
+// requests from web pages (with an Origin header) are refused, so a web page the user visits can't load
+// fonts, also not with DNS rebinding.
+func NewHandler(m *Manager, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/leases", func(w http.ResponseWriter, r *http.Request) {
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+			return
+		}
+		var req acquireRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		ttl := time.Duration(req.TTLSeconds * float64(time.Second))
+		// fonts must be unloaded again even if the client goes away while they are loading
+		lease, err := m.Acquire(context.WithoutCancel(r.Context()), req.Owner, req.Fonts, ttl)
+		if err != nil {
+			writeError(w, statusCode(err), err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, newLeaseResponse(lease))
+	})
+	mux.HandleFunc("POST /v1/leases/{id}/heartbeat", func(w http.ResponseWriter, r *http.Request) {
+		lease, err := m.Heartbeat(r.PathValue("id"))
+		if err != nil {
+			writeError(w, statusCode(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newLeaseResponse(lease))
+	})
+	mux.HandleFunc("DELETE /v1/leases/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := m.Release(context.WithoutCancel(r.Context()), r.PathValue("id")); err != nil {
+			writeError(w, statusCode(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /v1/leases", func(w http.ResponseWriter, r *http.Request) {
+		leases := m.Leases()
+		list := make([]leaseResponse, 0, len(leases))
+		for _, l := range leases {
+			list = append(list, newLeaseResponse(l))
+		}
+		writeJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("GET /v1/fonts", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, m.Fonts())
+	})
+	return guard(token, mux)
+}
+
+// guard only passes requests with the token from local clients to next.
+func guard(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host '%s' is not a loopback address", r.Host))
+			return
+		}
+		if r.Header.Get("Origin") != "" {
+			writeError(w, http.StatusForbidden, errors.New("requests from web pages are not allowed"))
+			return
+		}
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether the Host header of a request names this machine, "localhost" or a
+// loopback IP with an optional port.
+func isLoopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Serve runs the API (see NewHandler) on listener and expires leases every interval until ctx is done.
+// Then all leases are released, so no fonts stay loaded after the agent stops.
+func Serve(ctx context.Context, listener net.Listener, m *Manager, token string, interval time.Duration) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	srv := &http.Server{
+		Handler:           NewHandler(m, token),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Expire(context.WithoutCancel(ctx))
+			}
+		}
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(listener) }()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-serveErr:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	stop()
+	<-done
+	m.ReleaseAll(context.WithoutCancel(ctx))
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// statusCode maps Manager errors to HTTP status codes.
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrLeaseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	}
+	// a font that can't be loaded is the job's problem, not an agent failure
+	return http.StatusUnprocessableEntity
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
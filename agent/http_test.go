@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandlerGuard(t *testing.T) {
+	const token = "secret"
+	body, err := json.Marshal(acquireRequest{Owner: "job", Fonts: testFonts(t, "a.ttf")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		host        string
+		auth        string
+		origin      string
+		contentType string
+		want        int
+	}{
+		{"valid", "127.0.0.1:7878", "Bearer secret", "", "application/json", http.StatusCreated},
+		{"localhost", "localhost:7878", "Bearer secret", "", "application/json; charset=utf-8", http.StatusCreated},
+		{"IPv6 loopback", "[::1]:7878", "Bearer secret", "", "application/json", http.StatusCreated},
+		{"missing token", "127.0.0.1:7878", "", "", "application/json", http.StatusUnauthorized},
+		{"wrong token", "127.0.0.1:7878", "Bearer secreT", "", "application/json", http.StatusUnauthorized},
+		{"not a bearer token", "127.0.0.1:7878", "Basic secret", "", "application/json", http.StatusUnauthorized},
+		{"DNS rebinding", "evil.example.com:7878", "Bearer secret", "", "application/json", http.StatusForbidden},
+		{"LAN address", "192.168.1.10:7878", "Bearer secret", "", "application/json", http.StatusForbidden},
+		{"web page", "127.0.0.1:7878", "Bearer secret", "https://evil.example.com", "application/json", http.StatusForbidden},
+		{"form post", "127.0.0.1:7878", "Bearer secret", "", "text/plain", http.StatusUnsupportedMediaType},
+		{"no content type", "127.0.0.1:7878", "Bearer secret", "", "", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, loader, _ := newTestManager(t)
+			r := httptest.NewRequest(http.MethodPost, "/v1/leases", strings.NewReader(string(body)))
+			r.Host = tt.host
+			for header, value := range map[string]string{"Authorization": tt.auth, "Origin": tt.origin, "Content-Type": tt.contentType} {
+				if value != "" {
+					r.Header.Set(header, value)
+				}
+			}
+			w := httptest.NewRecorder()
+			NewHandler(m, token).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tt.want)
+			}
+			if tt.want != http.StatusCreated && len(loader.loaded) != 0 {
+				t.Errorf("refused request loaded %v", loader.loaded)
+			}
+		})
+	}
+}
+
+func TestHandlerWithoutToken(t *testing.T) {
+	m, _, _ := newTestManager(t)
+	r := httptest.NewRequest(http.MethodGet, "/v1/leases", nil)
+	r.Host = "127.0.0.1:7878"
+	r.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	NewHandler(m, "").ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d with an empty token, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fontctl", "agent-token")
+	token, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Errorf("token %q, want 32 random bytes in hex", token)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); os.PathSeparator == '/' && perm != 0600 {
+		t.Errorf("token file mode = %s, want it only readable by the user", perm)
+	}
+	again, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != token {
+		t.Errorf("second call returned %q, want the token from the file %q", again, token)
+	}
+}
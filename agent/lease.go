@@ -0,0 +1,254 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors returned by the Manager. Use errors.Is to check for them.
+var (
+	ErrLeaseNotFound  = errors.New("lease not found (released or expired)")
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// Loader loads and unloads fonts, i.e. with AddFontResourceW / RemoveFontResourceW.
+type Loader interface {
+	Load(ctx context.Context, fontPath string) error
+	Unload(ctx context.Context, fontPath string) error
+}
+
+// Options are the settings of a Manager.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// DefaultTTL is the lease time-to-live if a job doesn't ask for one. Defaults to one minute.
+	DefaultTTL time.Duration
+	// MaxTTL limits the TTL a job can ask for. Defaults to one hour.
+	MaxTTL time.Duration
+	// Now returns the current time. Defaults to time.Now, tests can use a fake clock.
+	Now func() time.Time
+}
+
+// Lease is a job's claim on a set of fonts.
+type Lease struct {
+	ID      string        `json:"id"`
+	Owner   string        `json:"owner,omitempty"` // free text, i.e. job name and host, for status output
+	Fonts   []string      `json:"fonts"`
+	TTL     time.Duration `json:"-"`
+	Expires time.Time     `json:"expires"`
+}
+
+// FontStatus is a font loaded by the agent and the number of leases that use it.
+type FontStatus struct {
+	Path     string `json:"path"`
+	RefCount int    `json:"refcount"`
+}
+
+// Manager keeps track of leases and reference counts fonts across them. It is safe for concurrent use.
+type Manager struct {
+	loader Loader
+	opts   Options
+	log    *slog.Logger
+
+	mu       sync.Mutex
+	leases   map[string]*Lease
+	refCount map[string]int
+}
+
+// New returns a Manager that loads and unloads fonts with loader.
+func New(loader Loader, opts Options) *Manager {
+	if opts.DefaultTTL <= 0 {
+		opts.DefaultTTL = time.Minute
+	}
+	if opts.MaxTTL <= 0 {
+		opts.MaxTTL = time.Hour
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	log := opts.Logger
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return &Manager{
+		loader:   loader,
+		opts:     opts,
+		log:      log,
+		leases:   map[string]*Lease{},
+		refCount: map[string]int{},
+	}
+}
+
+// Acquire creates a lease for the fonts and loads the ones that aren't loaded yet. A ttl of 0 means the
+// default TTL. If a font fails to load, the fonts loaded for this lease are unloaded again and no lease
+// is created.
+func (m *Manager) Acquire(ctx context.Context, owner string, fonts []string, ttl time.Duration) (Lease, error) {
+	if len(fonts) == 0 {
+		return Lease{}, fmt.Errorf("%w: no fonts", ErrInvalidRequest)
+	}
+	switch {
+	case ttl < 0:
+		return Lease{}, fmt.Errorf("%w: negative ttl", ErrInvalidRequest)
+	case ttl == 0:
+		ttl = m.opts.DefaultTTL
+	case ttl > m.opts.MaxTTL:
+		ttl = m.opts.MaxTTL
+	}
+
+	var paths []string
+	for _, f := range fonts {
+		// the agent's working dir has nothing to do with the job's, so only absolute paths make sense
+		if !filepath.IsAbs(f) {
+			return Lease{}, fmt.Errorf("%w: font path '%s' is not absolute", ErrInvalidRequest, f)
+		}
+		if p := filepath.Clean(f); !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+
+	id, err := newLeaseID()
+	if err != nil {
+		return Lease{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var loaded []string
+	for _, p := range paths {
+		if m.refCount[p] == 0 {
+			if err := m.loader.Load(ctx, p); err != nil {
+				m.log.Error("failed to load font for lease, rolling back", "path", p, "owner", owner, "error", err)
+				for _, l := range loaded {
+					m.unload(ctx, l)
+				}
+				return Lease{}, err
+			}
+			loaded = append(loaded, p)
+			m.log.Debug("font loaded", "path", p)
+		}
+	}
+	for _, p := range paths {
+		m.refCount[p]++
+	}
+
+	lease := &Lease{ID: id, Owner: owner, Fonts: paths, TTL: ttl, Expires: m.opts.Now().Add(ttl)}
+	m.leases[id] = lease
+	m.log.Info("lease acquired", "id", id, "owner", owner, "fonts", len(paths), "ttl", ttl)
+	return *lease, nil
+}
+
+// Heartbeat extends a lease by its TTL.
+func (m *Manager) Heartbeat(id string) (Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lease, ok := m.leases[id]
+	if !ok {
+		return Lease{}, fmt.Errorf("%w: '%s'", ErrLeaseNotFound, id)
+	}
+	lease.Expires = m.opts.Now().Add(lease.TTL)
+	m.log.Debug("lease extended", "id", id, "expires", lease.Expires)
+	return *lease, nil
+}
+
+// Release ends a lease and unloads the fonts that no other lease uses.
+func (m *Manager) Release(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lease, ok := m.leases[id]
+	if !ok {
+		return fmt.Errorf("%w: '%s'", ErrLeaseNotFound, id)
+	}
+	m.release(ctx, lease)
+	m.log.Info("lease released", "id", id, "owner", lease.Owner)
+	return nil
+}
+
+// Expire releases all leases that weren't extended in time and returns their IDs.
+func (m *Manager) Expire(ctx context.Context) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.opts.Now()
+	var expired []string
+	for id, lease := range m.leases {
+		if now.After(lease.Expires) {
+			m.release(ctx, lease)
+			m.log.Warn("lease expired", "id", id, "owner", lease.Owner, "expires", lease.Expires)
+			expired = append(expired, id)
+		}
+	}
+	slices.Sort(expired)
+	return expired
+}
+
+// ReleaseAll ends all leases, i.e. when the agent shuts down.
+func (m *Manager) ReleaseAll(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, lease := range m.leases {
+		m.release(ctx, lease)
+	}
+}
+
+// Leases returns all active leases, ordered by ID.
+func (m *Manager) Leases() []Lease {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Lease, 0, len(m.leases))
+	for _, lease := range m.leases {
+		list = append(list, *lease)
+	}
+	slices.SortFunc(list, func(a, b Lease) int { return strings.Compare(a.ID, b.ID) })
+	return list
+}
+
+// Fonts returns the fonts loaded by the agent with their reference counts, ordered by path.
+func (m *Manager) Fonts() []FontStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]FontStatus, 0, len(m.refCount))
+	for p, n := range m.refCount {
+		list = append(list, FontStatus{Path: p, RefCount: n})
+	}
+	slices.SortFunc(list, func(a, b FontStatus) int { return strings.Compare(a.Path, b.Path) })
+	return list
+}
+
+// release drops the lease and its font references. m.mu must be held.
+func (m *Manager) release(ctx context.Context, lease *Lease) {
+	delete(m.leases, lease.ID)
+	for _, p := range lease.Fonts {
+		m.refCount[p]--
+		if m.refCount[p] <= 0 {
+			delete(m.refCount, p)
+			m.unload(ctx, p)
+		}
+	}
+}
+
+// unload unloads a font. Failures are only logged, there is nothing the job could do about them.
+func (m *Manager) unload(ctx context.Context, fontPath string) {
+	if err := m.loader.Unload(ctx, fontPath); err != nil {
+		m.log.Error("failed to unload font", "path", fontPath, "error", err)
+		return
+	}
+	m.log.Debug("font unloaded", "path", fontPath)
+}
+
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("can't create lease id (%w)", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeLoader records loads and unloads and fails to load the fonts in fail.
+type fakeLoader struct {
+	loaded   []string
+	unloaded []string
+	fail     map[string]bool
+}
+
+func (l *fakeLoader) Load(ctx context.Context, fontPath string) error {
+	if l.fail[fontPath] {
+		return errors.New("can't load font")
+	}
+	l.loaded = append(l.loaded, fontPath)
+	return nil
+}
+
+func (l *fakeLoader) Unload(ctx context.Context, fontPath string) error {
+	l.unloaded = append(l.unloaded, fontPath)
+	return nil
+}
+
+// fakeClock is a clock for Options.Now that only moves with Advance.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestManager(t *testing.T) (*Manager, *fakeLoader, *fakeClock) {
+	t.Helper()
+	loader := &fakeLoader{fail: map[string]bool{}}
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	m := New(loader, Options{DefaultTTL: time.Minute, MaxTTL: 10 * time.Minute, Now: clock.Now})
+	return m, loader, clock
+}
+
+// testFonts returns absolute font paths, which are different on Windows and Linux.
+func testFonts(t *testing.T, names ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+	}
+	return paths
+}
+
+func refCounts(m *Manager) map[string]int {
+	counts := map[string]int{}
+	for _, f := range m.Fonts() {
+		counts[f.Path] = f.RefCount
+	}
+	return counts
+}
+
+func TestAcquireRelease(t *testing.T) {
+	m, loader, _ := newTestManager(t)
+	fonts := testFonts(t, "a.ttf", "b.ttf", "c.ttf")
+	a, b, c := fonts[0], fonts[1], fonts[2]
+
+	first, err := m.Acquire(context.Background(), "job 1", []string{a, b, a}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(first.Fonts, []string{a, b}) {
+		t.Errorf("lease fonts = %v, want duplicates removed", first.Fonts)
+	}
+	if first.TTL != time.Minute {
+		t.Errorf("lease TTL = %s, want the default TTL", first.TTL)
+	}
+	second, err := m.Acquire(context.Background(), "job 2", []string{b, c}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.TTL != 10*time.Minute {
+		t.Errorf("lease TTL = %s, want it limited to the max TTL", second.TTL)
+	}
+	if !slices.Equal(loader.loaded, []string{a, b, c}) {
+		t.Errorf("loaded %v, want every font loaded once", loader.loaded)
+	}
+	if got, want := refCounts(m), map[string]int{a: 1, b: 2, c: 1}; !maps.Equal(got, want) {
+		t.Errorf("refcounts = %v, want %v", got, want)
+	}
+
+	if err := m.Release(context.Background(), first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loader.unloaded, []string{a}) {
+		t.Errorf("unloaded %v, want only the font no other lease uses", loader.unloaded)
+	}
+	if err := m.Release(context.Background(), first.ID); !errors.Is(err, ErrLeaseNotFound) {
+		t.Errorf("second release error = %v, want %v", err, ErrLeaseNotFound)
+	}
+	m.ReleaseAll(context.Background())
+	slices.Sort(loader.unloaded)
+	if !slices.Equal(loader.unloaded, []string{a, b, c}) {
+		t.Errorf("unloaded %v after releasing all leases, want all fonts", loader.unloaded)
+	}
+	if len(m.Fonts()) != 0 || len(m.Leases()) != 0 {
+		t.Errorf("fonts %v and leases %v left after releasing all leases", m.Fonts(), m.Leases())
+	}
+}
+
+func TestAcquireRollback(t *testing.T) {
+	m, loader, _ := newTestManager(t)
+	fonts := testFonts(t, "a.ttf", "b.ttf", "c.ttf")
+	a, b, c := fonts[0], fonts[1], fonts[2]
+	if _, err := m.Acquire(context.Background(), "job 1", []string{a}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	loader.fail[c] = true
+	if _, err := m.Acquire(context.Background(), "job 2", []string{a, b, c}, 0); err == nil {
+		t.Fatal("Acquire() succeeded with a font that can't be loaded")
+	}
+	if !slices.Equal(loader.unloaded, []string{b}) {
+		t.Errorf("unloaded %v, want only the font loaded for the failed lease", loader.unloaded)
+	}
+	if got, want := refCounts(m), map[string]int{a: 1}; !maps.Equal(got, want) {
+		t.Errorf("refcounts = %v, want %v", got, want)
+	}
+	if len(m.Leases()) != 1 {
+		t.Errorf("%d leases, want the failed one not created", len(m.Leases()))
+	}
+}
+
+func TestAcquireInvalid(t *testing.T) {
+	m, _, _ := newTestManager(t)
+	tests := []struct {
+		name  string
+		fonts []string
+		ttl   time.Duration
+	}{
+		{"no fonts", nil, 0},
+		{"relative path", []string{"a.ttf"}, 0},
+		{"negative ttl", testFonts(t, "a.ttf"), -time.Second},
+	}
+	for _, tt := range tests {
+		if _, err := m.Acquire(context.Background(), "job", tt.fonts, tt.ttl); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: Acquire() error = %v, want %v", tt.name, err, ErrInvalidRequest)
+		}
+	}
+}
+
+func TestExpireAndHeartbeat(t *testing.T) {
+	m, loader, clock := newTestManager(t)
+	fonts := testFonts(t, "a.ttf", "b.ttf")
+	a, b := fonts[0], fonts[1]
+
+	kept, err := m.Acquire(context.Background(), "kept alive", []string{a, b}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := m.Acquire(context.Background(), "crashed", []string{b}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(45 * time.Second)
+	if expired := m.Expire(context.Background()); len(expired) != 0 {
+		t.Errorf("expired %v before the TTL", expired)
+	}
+	lease, err := m.Heartbeat(kept.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Now().Add(time.Minute); !lease.Expires.Equal(want) {
+		t.Errorf("heartbeat extended the lease to %s, want %s", lease.Expires, want)
+	}
+
+	clock.Advance(30 * time.Second)
+	if expired := m.Expire(context.Background()); !slices.Equal(expired, []string{dropped.ID}) {
+		t.Errorf("expired %v, want only the lease without heartbeat", expired)
+	}
+	if len(loader.unloaded) != 0 {
+		t.Errorf("unloaded %v, but the other lease still uses the font", loader.unloaded)
+	}
+	if _, err := m.Heartbeat(dropped.ID); !errors.Is(err, ErrLeaseNotFound) {
+		t.Errorf("heartbeat of expired lease error = %v, want %v", err, ErrLeaseNotFound)
+	}
+
+	clock.Advance(time.Minute + time.Second)
+	if expired := m.Expire(context.Background()); !slices.Equal(expired, []string{kept.ID}) {
+		t.Errorf("expired %v, want the lease whose heartbeats stopped", expired)
+	}
+	slices.Sort(loader.unloaded)
+	if !slices.Equal(loader.unloaded, []string{a, b}) {
+		t.Errorf("unloaded %v, want all fonts", loader.unloaded)
+	}
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultTokenPath returns the token file of the current user, fontctl/agent-token in the user's cache dir
+// (i.e. %LocalAppData% on Windows, ~/.cache on Linux).
+func DefaultTokenPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("can't find the user's cache dir (%w)", err)
+	}
+	return filepath.Join(dir, "fontctl", "agent-token"), nil
+}
+
+// LoadOrCreateToken returns the API token in the file at path. If the file doesn't exist, a random token
+// is written to it, readable only by the current user. Jobs of the same user read the token from the file
+// and send it as "Authorization: Bearer <token>".
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("token file '%s' is empty", path)
+		}
+		return token, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("can't read token file '%s' (%w)", path, err)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("can't create token (%w)", err)
+	}
+	token := hex.EncodeToString(b)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("can't create dir for token file '%s' (%w)", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("can't create token file '%s' (%w)", path, err)
+	}
+	_, err = f.WriteString(token + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("can't write token file '%s' (%w)", path, err)
+	}
+	return token, nil
+}
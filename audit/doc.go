@@ -0,0 +1,5 @@
+// Package audit checks the licenses of fonts against a policy and writes the results as CSV or HTML
+// reports for legal review.
+//
+// The package is operating system independent.
+package audit
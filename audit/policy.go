@@ -0,0 +1,128 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fontctl/fonts"
+	"fontctl/license"
+	"fontctl/sbom"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPolicyViolation is returned when fonts with disallowed or unknown licenses were found.
+var ErrPolicyViolation = errors.New("fonts violate the license policy")
+
+// Policy decides which font licenses are allowed. License entries are classes (i.e. "ofl" or
+// "proprietary", see license.Class) or SPDX identifiers (i.e. "OFL-1.1").
+//
+//	allow: [ofl, apache, ufl]
+//	deny: [proprietary]
+//	allow_unknown: false
+//	exceptions: ["Segoe UI*", "corporate-*.otf"]
+type Policy struct {
+	// Allow lists the allowed licenses. If it is empty, all licenses that are not denied are allowed.
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+	// AllowUnknown allows fonts whose license isn't recognized.
+	AllowUnknown bool `yaml:"allow_unknown"`
+	// Exceptions are glob patterns of font names or file names that are allowed whatever their license,
+	// i.e. fonts the company has bought a license for.
+	Exceptions []string `yaml:"exceptions"`
+}
+
+// LoadPolicy reads a policy file. Unknown keys are an error, a misspelled key would silently allow fonts.
+func LoadPolicy(policyPath string) (Policy, error) {
+	var p Policy
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return p, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, policyPath, fonts.WithAccessDenied(err))
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return p, fmt.Errorf("invalid policy '%s' (%w)", policyPath, err)
+	}
+	for _, pattern := range p.Exceptions {
+		if _, err := path.Match(strings.ToLower(pattern), ""); err != nil {
+			return p, fmt.Errorf("invalid policy '%s': exception '%s' (%w)", policyPath, pattern, err)
+		}
+	}
+	return p, nil
+}
+
+// Finding is the license of a font and whether the policy allows it.
+type Finding struct {
+	sbom.Component
+	license.Classification
+	Allowed bool `json:"allowed"`
+	// Verdict explains why the font is allowed or not.
+	Verdict string `json:"verdict"`
+}
+
+// Check classifies the licenses of the components and checks them against the policy.
+func Check(components []sbom.Component, p Policy) []Finding {
+	findings := make([]Finding, 0, len(components))
+	for _, c := range components {
+		f := Finding{Component: c, Classification: license.Classify(c.LicenseText, c.LicenseURL, c.FsType)}
+		f.Allowed, f.Verdict = p.allows(f)
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// Violations returns the findings the policy doesn't allow.
+func Violations(findings []Finding) []Finding {
+	var list []Finding
+	for _, f := range findings {
+		if !f.Allowed {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+func (p Policy) allows(f Finding) (bool, string) {
+	for _, pattern := range p.Exceptions {
+		for _, name := range []string{f.Name, filepath.Base(f.Path)} {
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+				return true, fmt.Sprintf("exception '%s'", pattern)
+			}
+		}
+	}
+	if entry, ok := p.listed(p.Deny, f.Classification); ok {
+		return false, fmt.Sprintf("denied by '%s'", entry)
+	}
+	if f.Class == license.ClassUnknown {
+		if p.AllowUnknown {
+			return true, "unknown licenses are allowed"
+		}
+		return false, "unknown license"
+	}
+	if len(p.Allow) == 0 {
+		return true, "not denied"
+	}
+	if entry, ok := p.listed(p.Allow, f.Classification); ok {
+		return true, fmt.Sprintf("allowed by '%s'", entry)
+	}
+	return false, "not in the allowed licenses"
+}
+
+// listed returns the entry of list that matches the class or SPDX identifier of a classification.
+func (p Policy) listed(list []string, c license.Classification) (string, bool) {
+	i := slices.IndexFunc(list, func(entry string) bool {
+		return strings.EqualFold(entry, string(c.Class)) || c.SPDX != "" && strings.EqualFold(entry, c.SPDX)
+	})
+	if i < 0 {
+		return "", false
+	}
+	return list[i], true
+}
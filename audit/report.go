@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// csvHeader are the columns of the CSV report.
+var csvHeader = []string{"file", "name", "version", "vendor", "class", "spdx", "reason", "allowed", "verdict",
+	"license", "license_url", "fs_type", "sha256"}
+
+// WriteCSV writes the findings as CSV, one font per row.
+func WriteCSV(w io.Writer, findings []Finding) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, f := range findings {
+		row := []string{f.Path, f.Name, f.Version, f.Supplier(), string(f.Class), f.SPDX, f.Reason,
+			fmt.Sprint(f.Allowed), f.Verdict, f.LicenseText, f.LicenseURL, fmt.Sprintf("0x%04x", f.FsType), f.Hash}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Font license audit</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+tr.denied { background: #fdd; }
+td.license { max-width: 40em; white-space: pre-wrap; font-size: 0.85em; }
+</style>
+</head>
+<body>
+<h1>Font license audit</h1>
+<p>{{len .Findings}} fonts, {{.Violations}} not allowed. Created {{.Created.Format "2006-01-02 15:04 MST"}}.</p>
+<table>
+<tr><th>Font</th><th>Version</th><th>Vendor</th><th>Class</th><th>SPDX</th><th>Allowed</th><th>License</th><th>File</th></tr>
+{{range .Findings}}<tr{{if not .Allowed}} class="denied"{{end}}>
+<td>{{.Name}}</td><td>{{.Version}}</td><td>{{.Supplier}}</td>
+<td>{{.Class}}<br><small>{{.Reason}}</small></td><td>{{.SPDX}}</td>
+<td>{{if .Allowed}}yes{{else}}<b>no</b>{{end}}<br><small>{{.Verdict}}</small></td>
+<td class="license">{{.LicenseText}}{{if .LicenseURL}}
+<a href="{{.LicenseURL}}">{{.LicenseURL}}</a>{{end}}</td>
+<td><code>{{.Path}}</code><br><small>sha256:{{.Hash}}</small></td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the findings as a HTML page, the fonts that are not allowed are highlighted.
+func WriteHTML(w io.Writer, findings []Finding, created time.Time) error {
+	return htmlReport.Execute(w, struct {
+		Findings   []Finding
+		Violations int
+		Created    time.Time
+	}{findings, len(Violations(findings)), created})
+}
@@ -0,0 +1,329 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fontctl/fonts"
+)
+
+// ManifestName is the name of the manifest in the bundle.
+const ManifestName = "manifest.json"
+
+// FormatVersion is the version of the bundle format. Bundles with a newer version can't be read.
+const FormatVersion = 1
+
+// ErrInvalidBundle is returned for bundles with a missing or broken manifest, or files that don't
+// match their hashes.
+var ErrInvalidBundle = errors.New("invalid font bundle")
+
+// Scopes of the fonts in a bundle.
+const (
+	ScopeSystem = "system"
+	ScopeUser   = "user"
+)
+
+// Manifest describes the fonts in a bundle.
+type Manifest struct {
+	Format  int       `json:"format"`
+	Created time.Time `json:"created"`
+	Host    string    `json:"host,omitempty"`
+	Fonts   []Font    `json:"fonts"`
+}
+
+// Font is a font in a bundle.
+type Font struct {
+	Name   string `json:"name"`  // registry value name, i.e. "Arial (TrueType)"
+	Scope  string `json:"scope"` // ScopeSystem or ScopeUser
+	File   string `json:"file"`  // path in the bundle, the .pfm file of Type 1 fonts
+	Hash   string `json:"hash"`
+	Source string `json:"source"` // installed path on the machine the bundle was created on
+	// PFB and PFBHash are the .pfb file of Type 1 fonts.
+	PFB     string `json:"pfb,omitempty"`
+	PFBHash string `json:"pfb_hash,omitempty"`
+}
+
+// Source is an installed font to put into a bundle.
+type Source struct {
+	Name  string
+	Scope string
+	Path  string
+	PFB   string // .pfb file of Type 1 fonts
+}
+
+// Options are the settings of Create and Extract.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// Now returns the creation time of the bundle. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return o.Logger
+}
+
+// Create writes a bundle with the source fonts to bundlePath. The file is written to a temporary file
+// first and renamed when it is complete, so a failed export doesn't leave a broken bundle behind.
+func Create(bundlePath string, sources []Source, opts Options) (Manifest, error) {
+	log := opts.log()
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	host, _ := os.Hostname()
+	m := Manifest{Format: FormatVersion, Created: now().UTC(), Host: host, Fonts: []Font{}}
+
+	tmp, err := os.CreateTemp(filepath.Dir(bundlePath), "."+filepath.Base(bundlePath)+".*")
+	if err != nil {
+		return m, fmt.Errorf("can't create bundle '%s' (%w)", bundlePath, fonts.WithAccessDenied(err))
+	}
+	defer os.Remove(tmp.Name()) // after the rename this fails, which is fine
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	used := map[string]bool{}
+	for _, src := range sources {
+		f := Font{Name: src.Name, Scope: src.Scope, Source: src.Path}
+		dir := fontDir(src, used)
+		if f.File, f.Hash, err = addFile(zw, src.Path, dir); err != nil {
+			return m, err
+		}
+		if src.PFB != "" {
+			if f.PFB, f.PFBHash, err = addFile(zw, src.PFB, dir); err != nil {
+				return m, err
+			}
+		}
+		log.Debug("added font to bundle", "name", f.Name, "source", f.Source, "file", f.File)
+		m.Fonts = append(m.Fonts, f)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	w, err := zw.Create(ManifestName)
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), bundlePath)
+	}
+	if err != nil {
+		return m, fmt.Errorf("can't write bundle '%s' (%w)", bundlePath, fonts.WithAccessDenied(err))
+	}
+	return m, nil
+}
+
+// fontDir returns the dir in the bundle for the files of a font: fonts/<scope>, or fonts/<scope>/<n> if
+// a file name is already used there. The files keep their names, which the registry value of a font
+// can refer to, and the .pfm and .pfb file of a Type 1 font stay in the same dir.
+func fontDir(src Source, used map[string]bool) string {
+	var names []string
+	for _, p := range []string{src.Path, src.PFB} {
+		if p != "" {
+			names = append(names, filepath.Base(p))
+		}
+	}
+	// used has the names of the files and, with a trailing slash, of the dirs
+	free := func(dir string) bool {
+		if used[strings.ToLower(dir)] {
+			return false
+		}
+		for _, name := range names {
+			key := strings.ToLower(path.Join(dir, name))
+			if used[key] || used[key+"/"] {
+				return false
+			}
+		}
+		return true
+	}
+	dir := path.Join("fonts", src.Scope)
+	for i := 2; !free(dir); i++ {
+		dir = path.Join("fonts", src.Scope, strconv.Itoa(i))
+	}
+	used[strings.ToLower(dir)+"/"] = true
+	for _, name := range names {
+		used[strings.ToLower(path.Join(dir, name))] = true
+	}
+	return dir
+}
+
+// addFile copies a font file into the bundle as <dir>/<name> and returns its name in the bundle and
+// its hash.
+func addFile(zw *zip.Writer, filePath, dir string) (string, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", "", fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, filePath, fonts.WithAccessDenied(err))
+	}
+	defer f.Close()
+	name := path.Join(dir, filepath.Base(filePath))
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return "", "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), f); err != nil {
+		return "", "", fmt.Errorf("can't read '%s' (%w)", filePath, err)
+	}
+	return name, fonts.FormatHash(h.Sum(nil)), nil
+}
+
+// ReadManifest returns the manifest of a bundle without extracting it.
+func ReadManifest(bundlePath string) (Manifest, error) {
+	zr, err := openBundle(bundlePath)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer zr.Close()
+	return readManifest(zr, bundlePath)
+}
+
+// Extract verifies the fonts of a bundle and extracts them to dir. It returns the manifest and the
+// paths of the extracted fonts in the order of the manifest, the .pfm files for Type 1 fonts. If a file
+// doesn't match its hash, the files extracted so far are removed again.
+func Extract(bundlePath, dir string, opts Options) (m Manifest, paths []string, err error) {
+	log := opts.log()
+	zr, err := openBundle(bundlePath)
+	if err != nil {
+		return m, nil, err
+	}
+	defer zr.Close()
+	if m, err = readManifest(zr, bundlePath); err != nil {
+		return m, nil, err
+	}
+
+	var extracted []string
+	defer func() {
+		if err != nil {
+			for _, p := range extracted {
+				os.Remove(p)
+			}
+		}
+	}()
+	for _, f := range m.Fonts {
+		for _, file := range []struct{ name, hash string }{{f.File, f.Hash}, {f.PFB, f.PFBHash}} {
+			if file.name == "" {
+				continue
+			}
+			// the scope dirs and numbered subdirs keep fonts with the same file name apart
+			target := filepath.Join(dir, filepath.FromSlash(file.name))
+			if err := extractVerified(zr, file.name, file.hash, target); err != nil {
+				return m, nil, fmt.Errorf("%w '%s': %w", ErrInvalidBundle, bundlePath, err)
+			}
+			extracted = append(extracted, target)
+			log.Debug("extracted font", "file", file.name, "path", target)
+		}
+		paths = append(paths, filepath.Join(dir, filepath.FromSlash(f.File)))
+	}
+	return m, paths, nil
+}
+
+// extractVerified writes a file of the bundle to target and checks its hash.
+func extractVerified(zr *zip.ReadCloser, name, hash, target string) error {
+	want, err := fonts.ParseHash(hash)
+	if err != nil {
+		return fmt.Errorf("invalid hash of '%s' (%w)", name, err)
+	}
+	r, err := zr.Open(name)
+	if err != nil {
+		return fmt.Errorf("missing '%s'", name)
+	}
+	defer r.Close()
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("can't create dir '%s' (%w)", filepath.Dir(target), fonts.WithAccessDenied(err))
+	}
+	w, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("can't extract '%s' (%w)", target, fonts.WithAccessDenied(err))
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(w, h), r)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && !bytes.Equal(h.Sum(nil), want) {
+		err = fmt.Errorf("'%s' doesn't match its hash", name)
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
+
+func openBundle(bundlePath string) (*zip.ReadCloser, error) {
+	zr, err := zip.OpenReader(bundlePath)
+	if errors.Is(err, zip.ErrFormat) {
+		return nil, fmt.Errorf("%w '%s': not a zip file", ErrInvalidBundle, bundlePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, bundlePath, fonts.WithAccessDenied(err))
+	}
+	return zr, nil
+}
+
+func readManifest(zr *zip.ReadCloser, bundlePath string) (Manifest, error) {
+	var m Manifest
+	data, err := readZipFile(zr, ManifestName)
+	if err != nil {
+		return m, fmt.Errorf("%w '%s': %w", ErrInvalidBundle, bundlePath, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%w '%s': invalid manifest (%w)", ErrInvalidBundle, bundlePath, err)
+	}
+	if m.Format > FormatVersion {
+		return m, fmt.Errorf("%w '%s': format %d is newer than this fontctl supports (%d)", ErrInvalidBundle, bundlePath, m.Format, FormatVersion)
+	}
+	for _, f := range m.Fonts {
+		for _, name := range []string{f.File, f.PFB} {
+			if name != "" && !validName(name) {
+				return m, fmt.Errorf("%w '%s': invalid file name '%s' in manifest", ErrInvalidBundle, bundlePath, name)
+			}
+		}
+	}
+	return m, nil
+}
+
+// validName reports whether a file name of the manifest is a relative path in the fonts dir of the
+// bundle, so extracting it can't write outside the target dir.
+func validName(name string) bool {
+	clean := path.Clean(name)
+	return clean == name && strings.HasPrefix(name, "fonts/") && !strings.Contains(name, "..") &&
+		!strings.ContainsAny(name, `\:`)
+}
+
+func readZipFile(zr *zip.ReadCloser, name string) ([]byte, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("missing '%s'", name)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("can't read '%s' (%w)", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,197 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"fontctl/fonts"
+)
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"fonts/system/arial.ttf", true},
+		{"fonts/user/2/Foo.otf", true},
+		{"fonts/system/../../evil.dll", false},
+		{"../fonts/system/arial.ttf", false},
+		{"fonts/../manifest.json", false},
+		{"fonts/system/./arial.ttf", false},
+		{"/fonts/system/arial.ttf", false},
+		{"/etc/passwd", false},
+		{`fonts\system\arial.ttf`, false},
+		{`fonts/system/..\..\evil.dll`, false},
+		{"C:/Windows/Fonts/arial.ttf", false},
+		{"fonts/C:evil.ttf", false},
+		{"arial.ttf", false},
+		{"fonts", false},
+	}
+	for _, tt := range tests {
+		if got := validName(tt.name); got != tt.want {
+			t.Errorf("validName(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFontDir(t *testing.T) {
+	used := map[string]bool{}
+	tests := []struct {
+		src  Source
+		want string
+	}{
+		{Source{Scope: ScopeSystem, Path: filepath.Join("Windows", "Fonts", "arial.ttf")}, "fonts/system"},
+		{Source{Scope: ScopeUser, Path: filepath.Join("jane", "Fonts", "arial.ttf")}, "fonts/user"},
+		// the same name with another case, from another user
+		{Source{Scope: ScopeUser, Path: filepath.Join("john", "Fonts", "ARIAL.TTF")}, "fonts/user/2"},
+		{Source{Scope: ScopeUser, Path: filepath.Join("joe", "Fonts", "Arial.ttf")}, "fonts/user/3"},
+		{Source{Scope: ScopeUser, Path: filepath.Join("jane", "Fonts", "foo.ttf")}, "fonts/user"},
+		// a Type 1 font keeps both files together, so it moves on if one name is taken
+		{Source{Scope: ScopeUser, Path: filepath.Join("a", "bar.pfm"), PFB: filepath.Join("a", "bar.pfb")}, "fonts/user"},
+		{Source{Scope: ScopeUser, Path: filepath.Join("b", "baz.pfm"), PFB: filepath.Join("b", "BAR.pfb")}, "fonts/user/2"},
+		{Source{Scope: ScopeUser, Path: filepath.Join("c", "qux.pfm"), PFB: filepath.Join("c", "bar.PFB")}, "fonts/user/3"},
+	}
+	for _, tt := range tests {
+		if got := fontDir(tt.src, used); got != tt.want {
+			t.Errorf("fontDir(%v) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+// writeBundle writes a bundle with a manifest and files, which don't need to match it.
+func writeBundle(t *testing.T, m Manifest, files map[string][]byte) string {
+	t.Helper()
+	bundlePath := filepath.Join(t.TempDir(), "fonts.zip")
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files[ManifestName] = data
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err == nil {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bundlePath
+}
+
+func hashOf(data []byte) string {
+	h := sha256.Sum256(data)
+	return fonts.FormatHash(h[:])
+}
+
+func TestCreateAndExtract(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"a/Foo.ttf", "b/foo.TTF", "Bar.pfm", "Bar.pfb"} {
+		path := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sources := []Source{
+		{Name: "Foo (TrueType)", Scope: ScopeUser, Path: filepath.Join(src, "a", "Foo.ttf")},
+		{Name: "Foo (TrueType)", Scope: ScopeUser, Path: filepath.Join(src, "b", "foo.TTF")},
+		{Name: "Bar", Scope: ScopeSystem, Path: filepath.Join(src, "Bar.pfm"), PFB: filepath.Join(src, "Bar.pfb")},
+	}
+	bundlePath := filepath.Join(t.TempDir(), "fonts.zip")
+	if _, err := Create(bundlePath, sources, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	m, paths, err := Extract(bundlePath, dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "fonts", "user", "Foo.ttf"),
+		filepath.Join(dir, "fonts", "user", "2", "foo.TTF"),
+		filepath.Join(dir, "fonts", "system", "Bar.pfm"),
+	}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("Extract() = %q, want %q", paths, want)
+	}
+	if m.Fonts[2].PFB != "fonts/system/Bar.pfb" {
+		t.Errorf("Extract() manifest .pfb = %q, want fonts/system/Bar.pfb", m.Fonts[2].PFB)
+	}
+	for i, name := range []string{"a/Foo.ttf", "b/foo.TTF", "Bar.pfm"} {
+		if data, err := os.ReadFile(paths[i]); err != nil || string(data) != name {
+			t.Errorf("extracted %s = %q (%v), want %q", paths[i], data, err, name)
+		}
+	}
+}
+
+func TestExtractRemovesFilesOnHashMismatch(t *testing.T) {
+	good, bad := []byte("good font"), []byte("tampered font")
+	m := Manifest{Format: FormatVersion, Fonts: []Font{
+		{Name: "Good", Scope: ScopeUser, File: "fonts/user/Good.ttf", Hash: hashOf(good)},
+		{Name: "Bad", Scope: ScopeUser, File: "fonts/user/Bad.ttf", Hash: hashOf([]byte("original font"))},
+	}}
+	bundlePath := writeBundle(t, m, map[string][]byte{"fonts/user/Good.ttf": good, "fonts/user/Bad.ttf": bad})
+
+	dir := t.TempDir()
+	if _, _, err := Extract(bundlePath, dir, Options{}); !errors.Is(err, ErrInvalidBundle) {
+		t.Fatalf("Extract() error = %v, want %v", err, ErrInvalidBundle)
+	}
+	for _, name := range []string{"Good.ttf", "Bad.ttf"} {
+		if _, err := os.Stat(filepath.Join(dir, "fonts", "user", name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s left after a failed extract (%v)", name, err)
+		}
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	font := []byte("font")
+	tests := []struct {
+		name    string
+		m       Manifest
+		wantErr bool
+	}{
+		{"current format", Manifest{Format: FormatVersion, Fonts: []Font{{File: "fonts/user/Foo.ttf", Hash: hashOf(font)}}}, false},
+		{"newer format", Manifest{Format: FormatVersion + 1}, true},
+		{"path outside the bundle", Manifest{Format: FormatVersion, Fonts: []Font{{File: "fonts/../../Foo.ttf", Hash: hashOf(font)}}}, true},
+		{"invalid .pfb path", Manifest{Format: FormatVersion, Fonts: []Font{{File: "fonts/user/Foo.pfm", PFB: `C:\Foo.pfb`}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundlePath := writeBundle(t, tt.m, map[string][]byte{"fonts/user/Foo.ttf": font})
+			_, err := ReadManifest(bundlePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadManifest() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("ReadManifest() error = %v, want %v", err, ErrInvalidBundle)
+			}
+		})
+	}
+
+	notZip := filepath.Join(t.TempDir(), "fonts.zip")
+	if err := os.WriteFile(notZip, []byte("not a zip file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(notZip); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("ReadManifest() of a file that isn't a zip error = %v, want %v", err, ErrInvalidBundle)
+	}
+}
@@ -0,0 +1,6 @@
+// Package bundle reads and writes font bundles: zip files with font files and a manifest.json that
+// lists their names, hashes and the scope they were installed in. Bundles are used to copy the fonts
+// of one machine to another, the hashes make sure the fonts arrive unchanged.
+//
+// The package is operating system independent.
+package bundle
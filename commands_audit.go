@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"fontctl/audit"
+	"fontctl/fonts"
+	"fontctl/sbom"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// auditCommand returns the audit command. The installed fonts are only known on MS Windows and
+// Linux, directories can be audited on any OS.
+func auditCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "audit",
+		Usage:     "Classify the licenses of fonts and check them against a policy",
+		UsageText: "fontctl audit [<Dir> ...] [--installed] [--policy <policy.yaml>] [--format text|csv|html|json] [--output <File>]",
+		Description: "Classifies every font as ofl, apache, ufl, permissive, proprietary or unknown by its license description " +
+			"and URL (name IDs 13 and 14) and its embedding permissions (OS/2 fsType). With --policy the command fails " +
+			"(exit code 10) if a font has a disallowed or unknown license, after writing the report.\n\n" +
+			"Example policy.yaml:\nallow: [ofl, apache, ufl]\ndeny: [proprietary]\nallow_unknown: false\nexceptions: [\"Corporate Sans*\"]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "installed",
+				Usage: "Audit the fonts installed for all users and for the current user",
+			},
+			&cli.StringFlag{
+				Name:  "policy",
+				Usage: "Policy file with the allowed and denied licenses (default: only classify the licenses)",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "Report format: text, csv, html or json",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "File to write the report to (default: stdout)",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.NArg() == 0 && !c.Bool("installed") {
+				cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+			}
+			format := c.String("format")
+			switch format {
+			case "text", "csv", "html", "json":
+			default:
+				return cli.Exit(fmt.Sprintf("Error - unknown format '%s', use text, csv, html or json", format), exitUsage)
+			}
+			var policy audit.Policy
+			if c.String("policy") != "" {
+				var err error
+				if policy, err = audit.LoadPolicy(c.String("policy")); err != nil {
+					return exitWithError(err)
+				}
+			} else {
+				policy.AllowUnknown = true
+			}
+
+			var paths []string
+			if c.Bool("installed") {
+				installed, err := installedFontFiles()
+				if err != nil {
+					return exitWithError(err)
+				}
+				if len(installed) == 0 {
+					return cli.Exit("no installed fonts found (the installed fonts are only known on MS Windows and Linux)", exitUsage)
+				}
+				for _, f := range installed {
+					paths = append(paths, f.Path)
+				}
+			}
+			for _, dir := range c.Args().Slice() {
+				found, err := fonts.FindFontFiles(dir)
+				if err != nil {
+					return exitWithError(err)
+				}
+				paths = append(paths, found...)
+			}
+			components, err := sbom.Read(paths, sbom.Options{Logger: logger})
+			if err != nil {
+				return exitWithError(err)
+			}
+			findings := audit.Check(components, policy)
+
+			if err := writeAuditReport(c.String("output"), format, findings); err != nil {
+				return exitWithError(err)
+			}
+			if n := len(audit.Violations(findings)); n > 0 {
+				return exitWithError(fmt.Errorf("%w: %d of %d fonts", audit.ErrPolicyViolation, n, len(findings)))
+			}
+			return nil
+		},
+	}
+}
+
+// writeAuditReport writes the findings in format to the output file, or to stdout.
+func writeAuditReport(output, format string, findings []audit.Finding) error {
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("can't create '%s' (%w)", output, fonts.WithAccessDenied(err))
+		}
+		defer file.Close()
+		w = file
+	}
+	var err error
+	switch format {
+	case "csv":
+		err = audit.WriteCSV(w, findings)
+	case "html":
+		err = audit.WriteHTML(w, findings, time.Now())
+	case "json":
+		if findings == nil {
+			findings = []audit.Finding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(findings)
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tVERSION\tCLASS\tSPDX\tALLOWED\tVERDICT")
+		for _, f := range findings {
+			allowed := "yes"
+			if !f.Allowed {
+				allowed = "NO"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Name, f.Version, f.Class, f.SPDX, allowed, f.Verdict)
+		}
+		err = tw.Flush()
+	}
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "wrote report of %d fonts to %s\n", len(findings), output)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"fontctl/duplicates"
+	"fontctl/fonts"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// duplicatesCommand returns the duplicates command. The installed fonts are only known on MS Windows
+// and Linux, directories can be checked on any OS.
+func duplicatesCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "duplicates",
+		Usage:     "Find fonts that are installed more than once or in different versions",
+		UsageText: "fontctl duplicates [--dir <Dir> ...] [--no-installed] [--json]",
+		Description: "Groups the fonts installed for all users and for the current user, and the fonts in --dir, by content hash, " +
+			"PostScript name and full name. For every group the font GDI uses is marked with *, and the fonts that can be " +
+			"removed are listed with the command to remove them.",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "dir",
+				Usage: "Directory with font files to include (can be repeated)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-installed",
+				Usage: "Only check the fonts in --dir, not the installed fonts",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print JSON instead of text",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.NArg() != 0 {
+				cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+			}
+			var files []duplicates.File
+			if !c.Bool("no-installed") {
+				installed, err := installedFontFiles()
+				if err != nil {
+					return exitWithError(err)
+				}
+				files = installed
+			}
+			for _, dir := range c.StringSlice("dir") {
+				paths, err := fonts.FindFontFiles(dir)
+				if err != nil {
+					return exitWithError(err)
+				}
+				for _, p := range paths {
+					files = append(files, duplicates.File{Path: p, Scope: duplicates.ScopeDir})
+				}
+			}
+			if len(files) == 0 {
+				return cli.Exit("no fonts to check, use --dir (the installed fonts are only known on MS Windows and Linux)", exitUsage)
+			}
+
+			groups := duplicates.Find(duplicates.Read(files, duplicates.Options{Logger: logger}))
+			if c.Bool("json") {
+				if groups == nil {
+					groups = []duplicates.Group{}
+				}
+				return printJSON(groups)
+			}
+			for i, g := range groups {
+				if i > 0 {
+					fmt.Println()
+				}
+				printDuplicateGroup(g)
+			}
+			return nil
+		},
+	}
+}
+
+// printDuplicateGroup prints a group of duplicates with the suggested removals.
+func printDuplicateGroup(g duplicates.Group) {
+	fmt.Printf("Same %s: %s\n", g.Kind, g.Key)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, f := range g.Fonts {
+		mark := " "
+		if i == 0 {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "  %s %s\t%s\t%s\t%s\n", mark, f.Scope, f.Path, f.Face.Version, f.Hash[:min(len(f.Hash), 19)])
+	}
+	w.Flush()
+	for _, r := range g.Removals {
+		switch r.Scope {
+		case duplicates.ScopeSystem:
+			fmt.Printf("  remove: fontctl uninstall --systemwide --installed-path \"%s\"  (%s)\n", r.Path, r.Reason)
+		case duplicates.ScopeUser:
+			fmt.Printf("  remove: fontctl uninstall --installed-path \"%s\"  (%s)\n", r.Path, r.Reason)
+		default:
+			fmt.Printf("  remove: \"%s\"  (%s)\n", r.Path, r.Reason)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"fontctl/fontconfig"
+	"fontctl/fonts"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// fontconfigCommand returns the fontconfig command, which works on any OS.
+func fontconfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "fontconfig",
+		Usage: "Generate fontconfig configurations",
+		Commands: []*cli.Command{
+			{
+				Name:      "gen",
+				Usage:     "Generate a fonts.conf for font library dirs",
+				UsageText: "fontctl fontconfig gen --dir <Dir> [--dir ...] [--rules <rules.yaml>] [--output <File>]",
+				Description: "Writes a fonts.conf that adds the dirs to the font dirs, for renderers that take a font config " +
+					"(i.e. FONTCONFIG_FILE=fonts.conf) instead of installed fonts. The rules file can include other configs, " +
+					"substitute families, list preferred families for a family or generic family and block font files by hash:\n\n" +
+					"include: [/etc/fonts/fonts.conf]\n" +
+					"substitute:\n  Helvetica: [Nimbus Sans]\n" +
+					"prefer:\n  sans-serif: [Inter, DejaVu Sans]\n" +
+					"block:\n  - sha256:<hex>\n\n" +
+					"Blocked files in the dirs are rejected with <rejectfont>. Families and hashes of the rules that are not in the dirs are reported as warnings.\n\n" +
+					"Example:\nfontctl fontconfig gen --dir lib/ --rules rules.yaml -o fonts.conf",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:    "dir",
+						Aliases: []string{"d"},
+						Usage:   "Font library dir to add (can be repeated)",
+					},
+					&cli.StringFlag{
+						Name:  "rules",
+						Usage: "YAML file with substitutions, preferred families and blocked hashes",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "File to write the config to (default: stdout)",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.NArg() != 0 || len(c.StringSlice("dir")) == 0 {
+						cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+					}
+					var rules fontconfig.Rules
+					if c.String("rules") != "" {
+						var err error
+						if rules, err = fontconfig.LoadRules(c.String("rules")); err != nil {
+							return exitWithError(err)
+						}
+					}
+					cfg, err := fontconfig.Generate(c.StringSlice("dir"), rules, fontconfig.Options{Logger: logger})
+					if err != nil {
+						return exitWithError(err)
+					}
+					for _, w := range cfg.Warnings {
+						fmt.Fprintf(os.Stderr, "Warning - %s\n", w)
+					}
+
+					output := c.String("output")
+					if output == "" {
+						return fontconfig.Write(os.Stdout, cfg)
+					}
+					file, err := os.Create(output)
+					if err != nil {
+						return exitWithError(fmt.Errorf("can't create '%s' (%w)", output, fonts.WithAccessDenied(err)))
+					}
+					err = fontconfig.Write(file, cfg)
+					if closeErr := file.Close(); err == nil {
+						err = closeErr
+					}
+					if err != nil {
+						return exitWithError(err)
+					}
+					fmt.Printf("wrote %s with %d dirs and %d rejected files\n", output, len(cfg.Dirs), len(cfg.Rejected))
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,680 @@
+//go:build windows || linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"fontctl/agent"
+	"fontctl/bundle"
+	"fontctl/fonts"
+	"fontctl/scan"
+	"fontctl/state"
+	"fontctl/watch"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// fontBackend installs and loads fonts with the font package of the OS, see newFontBackend.
+type fontBackend interface {
+	Install(ctx context.Context, fontPath string, systemWide bool, onConflict fonts.ConflictPolicy) error
+	Uninstall(ctx context.Context, by uninstallBy, value string, systemWide bool) error
+	IsInstalled(fontPath string, systemWide bool) (bool, error)
+	InstalledFonts() ([]installedFont, error)
+	// Load loads the fonts, or none of them if one fails to load.
+	Load(ctx context.Context, fontPaths []string) error
+	// Unload tries to unload all fonts and returns the first error.
+	Unload(ctx context.Context, fontPaths []string) error
+	UnloadAll(ctx context.Context) ([]state.Entry, error)
+	Loaded() ([]state.Entry, error)
+	// Notify makes running applications aware of font changes, also if they were deferred.
+	Notify(ctx context.Context) error
+}
+
+// backendOptions changes a fontBackend from what the global command line flags select.
+type backendOptions struct {
+	deferNotify       bool // changes only notify applications with Notify
+	withoutSignatures bool // fonts don't need a signature, i.e. because they come from a signed bundle
+}
+
+// uninstallBy is how the uninstall command selects the font.
+type uninstallBy int
+
+const (
+	uninstallByFile uninstallBy = iota // the original font file
+	uninstallByName                    // the font name
+	uninstallByHash                    // the content hash of the installed file
+	uninstallByPath                    // the installed file
+)
+
+// installedFont is a font installed for all users or the current user.
+type installedFont struct {
+	Name    string
+	Path    string // the .pfm file for Type 1 fonts
+	PFBPath string // the .pfb file of Type 1 fonts
+	User    bool
+}
+
+// fontHelp has the help texts of the font commands that differ between the OSes.
+type fontHelp struct {
+	installUsageText    string
+	installDescription  string
+	installSystemWide   string
+	uninstallSystemWide string
+	uninstallName       string
+	loadDescription     string
+	loadedDescription   string
+	execExample         string
+	scanExample         string
+	exportDescription   string
+	watchSystemWide     string
+	watchNotify         string // how changes are announced, i.e. "a single fontconfig cache refresh"
+	refreshUsage        string
+	refreshDescription  string
+}
+
+// backendLoader loads and unloads the fonts of agent leases.
+type backendLoader struct {
+	backend fontBackend
+}
+
+func (l backendLoader) Load(ctx context.Context, fontPath string) error {
+	return l.backend.Load(ctx, []string{fontPath})
+}
+
+func (l backendLoader) Unload(ctx context.Context, fontPath string) error {
+	return l.backend.Unload(ctx, []string{fontPath})
+}
+
+// watchBackend loads or installs the fonts of a watched directory. The backend defers its notifications,
+// Notify sends one per batch of changes.
+type watchBackend struct {
+	backend    fontBackend
+	install    bool
+	systemWide bool
+	onConflict fonts.ConflictPolicy
+}
+
+func (b watchBackend) Add(ctx context.Context, fontPath string) error {
+	if b.install {
+		return b.backend.Install(ctx, fontPath, b.systemWide, b.onConflict)
+	}
+	return b.backend.Load(ctx, []string{fontPath})
+}
+
+func (b watchBackend) Remove(ctx context.Context, fontPath string, added watch.FileInfo) error {
+	if b.install {
+		// the source file is gone, so uninstall by content to never remove a different font
+		return b.backend.Uninstall(ctx, uninstallByHash, added.Hash, b.systemWide)
+	}
+	return b.backend.Unload(ctx, []string{fontPath})
+}
+
+func (b watchBackend) Notify(ctx context.Context) error {
+	return b.backend.Notify(ctx)
+}
+
+// fontCommands returns the commands that manage fonts on this OS.
+func fontCommands() []*cli.Command {
+	commands := []*cli.Command{
+		{
+			Name:        "install",
+			Usage:       "Install a font",
+			UsageText:   fontCommandHelp.installUsageText,
+			Description: fontCommandHelp.installDescription,
+			Flags: slices.Concat([]cli.Flag{
+				&cli.BoolFlag{
+					Name:    "systemwide",
+					Aliases: []string{"s"},
+					Usage:   fontCommandHelp.installSystemWide,
+				},
+			}, installUserFlags, []cli.Flag{
+				&cli.StringFlag{
+					Name:  "on-conflict",
+					Value: string(fonts.ConflictFail),
+					Usage: "What to do if a different file with the same name is already installed: fail, skip, overwrite or newer (overwrite if the font version is higher)",
+				},
+			}, targetFlags),
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				if staged, err := stageFont(ctx, c); staged {
+					return err
+				}
+				onConflict, err := fonts.ParseConflictPolicy(c.String("on-conflict"))
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Error - %s", err), exitUsage)
+				}
+				if handled, err := installForUsers(ctx, c, onConflict); handled {
+					return err
+				}
+				err = newFontBackend(backendOptions{}).Install(ctx, c.Args().First(), c.Bool("systemwide"), onConflict)
+				if err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "uninstall",
+			Usage:     "Uninstall a font",
+			UsageText: "fontctl uninstall [--systemwide] <Font File> | --name <Font Name> | --hash sha256:<hex> | --installed-path <File>",
+			Description: "The installed file is only deleted if it has the same content as <Font File>. " +
+				"Use --name, --hash or --installed-path to uninstall a font without the original file.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "systemwide",
+					Aliases: []string{"s"},
+					Usage:   fontCommandHelp.uninstallSystemWide,
+				},
+				&cli.StringFlag{
+					Name:  "name",
+					Usage: fontCommandHelp.uninstallName,
+				},
+				&cli.StringFlag{
+					Name:  "hash",
+					Usage: "Uninstall the installed font files with this content hash (sha256:<hex>)",
+				},
+				&cli.StringFlag{
+					Name:  "installed-path",
+					Usage: "Uninstall this file from the font dir",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				selectors := c.NArg()
+				for _, flag := range []string{"name", "hash", "installed-path"} {
+					if c.IsSet(flag) {
+						selectors++
+					}
+				}
+				if selectors != 1 || c.NArg() > 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				by, value := uninstallByFile, c.Args().First()
+				switch {
+				case c.IsSet("name"):
+					by, value = uninstallByName, c.String("name")
+				case c.IsSet("hash"):
+					by, value = uninstallByHash, c.String("hash")
+				case c.IsSet("installed-path"):
+					by, value = uninstallByPath, c.String("installed-path")
+				}
+				err := newFontBackend(backendOptions{}).Uninstall(ctx, by, value, c.Bool("systemwide"))
+				if err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "load",
+			Usage:       "Load a font into memory",
+			UsageText:   "fontctl load <Font File>",
+			Description: fontCommandHelp.loadDescription,
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				err := newFontBackend(backendOptions{}).Load(ctx, []string{c.Args().First()})
+				if err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "unload",
+			Usage:     "Unload a font from memory",
+			UsageText: "fontctl unload <Font File> | --all",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "all",
+					Usage: "Unload all fonts loaded by fontctl (see fontctl loaded)",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				backend := newFontBackend(backendOptions{})
+				if c.Bool("all") {
+					if c.NArg() != 0 {
+						cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+					}
+					unloaded, err := backend.UnloadAll(ctx)
+					for _, e := range unloaded {
+						fmt.Println(e.Path)
+					}
+					if err != nil {
+						return exitWithError(err)
+					}
+					return nil
+				}
+				if c.NArg() != 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				err := backend.Unload(ctx, []string{c.Args().First()})
+				if err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "loaded",
+			Usage:       "List the fonts loaded by fontctl",
+			UsageText:   "fontctl loaded [--json]",
+			Description: fontCommandHelp.loadedDescription,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print JSON instead of a table",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 0 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				loaded, err := newFontBackend(backendOptions{}).Loaded()
+				if err != nil {
+					return exitWithError(err)
+				}
+				if c.Bool("json") {
+					return printJSON(loaded)
+				}
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "PATH\tREFS\tLOADED\tHASH")
+				for _, e := range loaded {
+					fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", e.Path, e.RefCount, e.LoadedAt.Local().Format(time.DateTime), e.Hash)
+				}
+				return w.Flush()
+			},
+		},
+		{
+			Name:      "exec",
+			Usage:     "Load fonts, run a command and unload the fonts afterwards",
+			UsageText: "fontctl exec --font <Font File or Dir> [--font ...] -- <Command> [Args...]",
+			Description: "The fonts are unloaded when the command exits, also when it fails or gets interrupted with Ctrl-C. " +
+				"fontctl exits with the exit code of the command, or 128 plus the signal number if the command was killed by a signal.\n\nExample:\n" + fontCommandHelp.execExample,
+			// the flags are only listed for the help, execArgs parses them, so the arguments of the command
+			// aren't taken for flags of fontctl
+			SkipFlagParsing: true,
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:    "font",
+					Aliases: []string{"f"},
+					Usage:   "Font file or directory with font files to load (can be repeated)",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				fontArgs, command, err := execArgs(c.Args().Slice())
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Error - %s", err), exitUsage)
+				}
+				if len(command) == 0 || len(fontArgs) == 0 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				fontPaths, err := fonts.ExpandFontPaths(fontArgs)
+				if err != nil {
+					return exitWithError(err)
+				}
+				if len(fontPaths) == 0 {
+					return exitWithError(fmt.Errorf("%w: no font files in %s", fonts.ErrFileNotFound, strings.Join(fontArgs, ", ")))
+				}
+				backend := newFontBackend(backendOptions{})
+				code, err := runWithFonts(ctx, fontPaths, command, backend.Load, backend.Unload)
+				if err != nil {
+					return exitWithError(err)
+				}
+				if code != exitOK {
+					return cli.Exit("", code)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "scan",
+			Usage:     "List the fonts a document uses and load the missing ones from a font library",
+			UsageText: "fontctl scan <Document> [--library <Dir> ...] [--load] [--json]",
+			Description: "Finds the fonts referenced by SVG files (font-family), ASS/SSA subtitles (styles and \\fn overrides), " +
+				"PDF files (font dictionaries) and DOCX/ODT documents (font tables and styles), and reports if they are installed, " +
+				"embedded in the document, available in a font library dir or missing.\n\n" +
+				"With --load the library fonts are loaded, like with fontctl load.\n\n" +
+				"Example:\n" + fontCommandHelp.scanExample,
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:    "library",
+					Aliases: []string{"l"},
+					Usage:   "Directory with font files to look for fonts that are not installed (can be repeated)",
+				},
+				&cli.BoolFlag{
+					Name:  "load",
+					Usage: "Load the fonts that were found in the library",
+				},
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "Print JSON instead of a table",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				refs, err := scan.Extract(c.Args().First())
+				if err != nil {
+					return exitWithError(err)
+				}
+				installed, err := scan.BuildCatalog(installedFontDirs()...)
+				if err != nil {
+					return exitWithError(err)
+				}
+				library, err := scan.BuildCatalog(c.StringSlice("library")...)
+				if err != nil {
+					return exitWithError(err)
+				}
+				results := scan.Resolve(refs, installed, library)
+				if c.Bool("json") {
+					err = printJSON(results)
+				} else {
+					err = printScanResults(results)
+				}
+				if err != nil {
+					return exitWithError(err)
+				}
+				if !c.Bool("load") {
+					return nil
+				}
+				var fontPaths []string
+				for _, r := range results {
+					if r.Status == scan.StatusLibrary {
+						fontPaths = append(fontPaths, r.Files...)
+					}
+				}
+				if len(fontPaths) == 0 {
+					return nil
+				}
+				if err := newFontBackend(backendOptions{}).Load(ctx, fontPaths); err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "export",
+			Usage:     "Export the installed fonts to a bundle",
+			UsageText: "fontctl export [--user] [--systemwide] --output <Bundle File>",
+			Description: fontCommandHelp.exportDescription +
+				"\n\nExample:\nfontctl export --user -o fonts.bundle.zip",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "user",
+					Usage: "Export the fonts installed for the current user (default if --systemwide isn't set)",
+				},
+				&cli.BoolFlag{
+					Name:    "systemwide",
+					Aliases: []string{"s"},
+					Usage:   "Export the fonts installed for all users",
+				},
+				&cli.StringFlag{
+					Name:     "output",
+					Aliases:  []string{"o"},
+					Usage:    "Bundle file to write",
+					Required: true,
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 0 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				user, system := c.Bool("user"), c.Bool("systemwide")
+				if !system {
+					user = true
+				}
+				installed, err := newFontBackend(backendOptions{}).InstalledFonts()
+				if err != nil {
+					return exitWithError(err)
+				}
+				var sources []bundle.Source
+				for _, f := range installed {
+					if f.User && !user || !f.User && !system {
+						continue
+					}
+					scope := bundle.ScopeSystem
+					if f.User {
+						scope = bundle.ScopeUser
+					}
+					sources = append(sources, bundle.Source{Name: f.Name, Scope: scope, Path: f.Path, PFB: f.PFBPath})
+				}
+				m, err := bundle.Create(c.String("output"), sources, bundle.Options{Logger: logger})
+				if err != nil {
+					return exitWithError(err)
+				}
+				fmt.Printf("exported %d fonts to %s\n", len(m.Fonts), c.String("output"))
+				return nil
+			},
+		},
+		{
+			Name:      "import",
+			Usage:     "Install the fonts of a bundle",
+			UsageText: "fontctl import [--systemwide] [--on-conflict fail|skip|overwrite|newer] <Bundle File>",
+			Description: "Verifies the hashes of all fonts in a bundle written by fontctl export and installs them. " +
+				"Fonts that are already installed with the same content are skipped.",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "systemwide",
+					Aliases: []string{"s"},
+					Usage:   fontCommandHelp.installSystemWide,
+				},
+				&cli.StringFlag{
+					Name:  "on-conflict",
+					Value: string(fonts.ConflictFail),
+					Usage: "What to do if a different file with the same name is already installed: fail, skip, overwrite or newer (overwrite if the font version is higher)",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				onConflict, err := fonts.ParseConflictPolicy(c.String("on-conflict"))
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Error - %s", err), exitUsage)
+				}
+				tmpDir, err := os.MkdirTemp("", "fontctl-import-")
+				if err != nil {
+					return exitWithError(err)
+				}
+				defer os.RemoveAll(tmpDir)
+				// a signed bundle vouches for its fonts, they only need to be in the allowlist
+				if err := verifier.VerifySignature(c.Args().First()); err != nil {
+					return exitWithError(err)
+				}
+				m, paths, err := bundle.Extract(c.Args().First(), tmpDir, bundle.Options{Logger: logger})
+				if err != nil {
+					return exitWithError(err)
+				}
+
+				backend := newFontBackend(backendOptions{deferNotify: true, withoutSignatures: true})
+				systemWide := c.Bool("systemwide")
+				var firstErr error
+				installedAny := false
+				for i, fontPath := range paths {
+					name := m.Fonts[i].Name
+					if ok, err := backend.IsInstalled(fontPath, systemWide); err == nil && ok {
+						fmt.Printf("= %s (already installed)\n", name)
+						continue
+					}
+					if err := backend.Install(ctx, fontPath, systemWide, onConflict); err != nil {
+						fmt.Fprintf(os.Stderr, "Error - %s: %s\n", name, err)
+						if firstErr == nil {
+							firstErr = err
+						}
+						continue
+					}
+					installedAny = true
+					fmt.Printf("+ %s\n", name)
+				}
+				if installedAny {
+					if err := backend.Notify(ctx); err != nil && firstErr == nil {
+						firstErr = err
+					}
+				}
+				if firstErr != nil {
+					return exitWithError(firstErr)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "watch",
+			Usage:     "Load or install the fonts in a directory and keep them in sync",
+			UsageText: "fontctl watch [--load | --install [--systemwide] [--on-conflict ...]] <Dir>",
+			Description: "Watches the directory and its subdirs for changes. New font files are loaded (default) or installed, " +
+				"removed ones unloaded or uninstalled. Changes are applied after the directory didn't change for --debounce, " +
+				"with " + fontCommandHelp.watchNotify + ". Loaded fonts are unloaded again when fontctl watch stops (Ctrl+C).",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "load",
+					Usage: "Load the fonts (default)",
+				},
+				&cli.BoolFlag{
+					Name:  "install",
+					Usage: "Install the fonts instead of loading them",
+				},
+				&cli.BoolFlag{
+					Name:    "systemwide",
+					Aliases: []string{"s"},
+					Usage:   fontCommandHelp.watchSystemWide,
+				},
+				&cli.StringFlag{
+					Name:  "on-conflict",
+					Value: string(fonts.ConflictFail),
+					Usage: "With --install: what to do if a different file with the same name is already installed: fail, skip, overwrite or newer",
+				},
+				&cli.DurationFlag{
+					Name:  "debounce",
+					Value: 2 * time.Second,
+					Usage: "How long the directory must be unchanged before changes are applied",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 1 || (c.Bool("load") && c.Bool("install")) {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				onConflict, err := fonts.ParseConflictPolicy(c.String("on-conflict"))
+				if err != nil {
+					return cli.Exit(fmt.Sprintf("Error - %s", err), exitUsage)
+				}
+				dir := c.Args().First()
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					return exitWithError(fmt.Errorf("%w '%s' (not a directory)", fonts.ErrFileNotFound, dir))
+				}
+
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+				defer stop()
+
+				backend := watchBackend{
+					backend:    newFontBackend(backendOptions{deferNotify: true}),
+					install:    c.Bool("install"),
+					systemWide: c.Bool("systemwide"),
+					onConflict: onConflict,
+				}
+				fmt.Printf("fontctl watching %s\n", dir)
+				err = watch.WatchDir(ctx, dir, backend, watch.Options{
+					Logger:       logger,
+					Debounce:     c.Duration("debounce"),
+					RemoveOnExit: !backend.install,
+					OnSync:       printWatchChanges,
+				})
+				if err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "refresh",
+			Usage:       fontCommandHelp.refreshUsage,
+			UsageText:   "fontctl refresh",
+			Description: fontCommandHelp.refreshDescription,
+			Action: func(ctx context.Context, c *cli.Command) error {
+				err := newFontBackend(backendOptions{}).Notify(ctx)
+				if err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "agent",
+			Usage:     "Run the font session agent",
+			UsageText: "fontctl agent [--listen 127.0.0.1:7878] [--token-file <File>] [--default-ttl 1m] [--max-ttl 1h]",
+			Description: "Serves a local HTTP/JSON API where jobs lease fonts: POST /v1/leases loads the fonts, " +
+				"POST /v1/leases/{id}/heartbeat keeps the lease alive and DELETE /v1/leases/{id} releases it. " +
+				"Fonts are reference counted across leases and unloaded when the last lease using them is released or expires. " +
+				"All fonts are unloaded when the agent stops (Ctrl+C).\n\n" +
+				"Requests must send the token from --token-file as \"Authorization: Bearer <token>\" and JSON bodies as application/json. " +
+				"The token file is created with a random token, readable only by the current user, if it doesn't exist.",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "listen",
+					Value: "127.0.0.1:7878",
+					Usage: "address to listen on. Only requests to localhost or a loopback address are served, so don't expose it to the network.",
+				},
+				&cli.StringFlag{
+					Name:  "token-file",
+					Usage: "file with the API token (default: fontctl/agent-token in the user's cache dir)",
+				},
+				&cli.DurationFlag{
+					Name:  "default-ttl",
+					Value: time.Minute,
+					Usage: "lease time-to-live if a job doesn't ask for one",
+				},
+				&cli.DurationFlag{
+					Name:  "max-ttl",
+					Value: time.Hour,
+					Usage: "maximum lease time-to-live a job can ask for",
+				},
+			},
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 0 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				tokenPath := c.String("token-file")
+				if tokenPath == "" {
+					var err error
+					if tokenPath, err = agent.DefaultTokenPath(); err != nil {
+						return exitWithError(err)
+					}
+				}
+				token, err := agent.LoadOrCreateToken(tokenPath)
+				if err != nil {
+					return exitWithError(err)
+				}
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+				defer stop()
+
+				listener, err := net.Listen("tcp", c.String("listen"))
+				if err != nil {
+					return exitWithError(err)
+				}
+				m := agent.New(backendLoader{backend: newFontBackend(backendOptions{})}, agent.Options{
+					Logger:     logger,
+					DefaultTTL: c.Duration("default-ttl"),
+					MaxTTL:     c.Duration("max-ttl"),
+				})
+				fmt.Printf("fontctl agent listening on http://%s, token in %s\n", listener.Addr(), tokenPath)
+				if err := agent.Serve(ctx, listener, m, token, time.Second); err != nil {
+					return exitWithError(err)
+				}
+				return nil
+			},
+		},
+	}
+	return append(commands, platformCommands()...)
+}
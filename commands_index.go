@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"fontctl/index"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// openIndex opens the index of the --index flag, or the default index.
+func openIndex(c *cli.Command) (*index.Index, error) {
+	path := c.String("index")
+	if path == "" {
+		var err error
+		if path, err = index.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return index.Open(path, index.Options{Logger: logger, Workers: int(c.Int("workers"))})
+}
+
+// indexCommand returns the index command. The index works on any OS, it only reads font files.
+func indexCommand() *cli.Command {
+	indexFlag := &cli.StringFlag{
+		Name:  "index",
+		Usage: "Index database file (default: index.db in the fontctl dir of the user cache dir)",
+	}
+	return &cli.Command{
+		Name:  "index",
+		Usage: "Index a font library and find fonts in it by name",
+		Description: "The index parses every font file once and stores its names, weight, slant, character coverage " +
+			"and hash in a local database, so fonts in a large library (i.e. on a network share) can be found by name quickly.",
+		Commands: []*cli.Command{
+			{
+				Name:      "build",
+				Usage:     "Add the fonts in a directory to the index, or update them",
+				UsageText: "fontctl index build <Dir> [<Dir> ...]",
+				Description: "Only files that are new or changed (by size and modification time) are parsed, " +
+					"files that were removed from the directory are removed from the index.",
+				Flags: []cli.Flag{
+					indexFlag,
+					&cli.IntFlag{
+						Name:  "workers",
+						Usage: "Number of files to parse in parallel",
+						Value: 8,
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print JSON instead of text",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.NArg() == 0 {
+						cli.ShowSubcommandHelpAndExit(c, exitUsage)
+					}
+					ix, err := openIndex(c)
+					if err != nil {
+						return exitWithError(err)
+					}
+					defer ix.Close()
+					all := map[string]index.BuildStats{}
+					for _, dir := range c.Args().Slice() {
+						stats, err := ix.Build(ctx, dir)
+						if c.Bool("json") {
+							all[dir] = stats
+						} else {
+							fmt.Printf("%s: %d added, %d updated, %d unchanged, %d removed, %d failed\n",
+								dir, stats.Added, stats.Updated, stats.Unchanged, stats.Removed, stats.Failed)
+						}
+						if err != nil {
+							return exitWithError(err)
+						}
+					}
+					if c.Bool("json") {
+						return printJSON(all)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "find",
+				Usage:     "Find the font files of a font by name",
+				UsageText: "fontctl index find <Name> [--style <Style>] [--all] [--json]",
+				Description: "The name is a family, full or PostScript name. The best matching faces for the style are " +
+					"printed, or all faces of the font with --all.\n\nExample:\nfontctl index find \"Helvetica Neue\" --style \"Bold Italic\"",
+				Flags: []cli.Flag{
+					indexFlag,
+					&cli.StringFlag{
+						Name:  "style",
+						Usage: "Style, i.e. \"Bold Italic\", \"Light\" or \"SemiBold\" (default: Regular)",
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Print all faces of the font, best match first",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print JSON instead of a table",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.NArg() != 1 {
+						cli.ShowSubcommandHelpAndExit(c, exitUsage)
+					}
+					ix, err := openIndex(c)
+					if err != nil {
+						return exitWithError(err)
+					}
+					defer ix.Close()
+					matches, err := ix.Find(c.Args().First(), c.String("style"))
+					if err != nil {
+						return exitWithError(err)
+					}
+					if !c.Bool("all") {
+						best := matches[0].Score
+						for i, m := range matches {
+							if m.Score != best {
+								matches = matches[:i]
+								break
+							}
+						}
+					}
+					if c.Bool("json") {
+						return printJSON(matches)
+					}
+					w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+					fmt.Fprintln(w, "PATH\tFAMILY\tSTYLE\tWEIGHT\tITALIC\tSCRIPTS")
+					for _, m := range matches {
+						var scripts []string
+						if m.Coverage != nil {
+							scripts = m.Coverage.Scripts
+						}
+						fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%v\t%s\n", m.Path, m.Family, m.Subfamily, m.Weight, m.Italic, strings.Join(scripts, ","))
+					}
+					return w.Flush()
+				},
+			},
+		},
+	}
+}
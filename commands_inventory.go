@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"fontctl/fonts"
+	"fontctl/inventory"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// inventoryCommand returns the inventory command. The installed fonts are only known on MS Windows
+// and Linux, directories can be recorded on any OS.
+func inventoryCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "inventory",
+		Usage:     "Record the installed fonts with their files, hashes and versions",
+		UsageText: "fontctl inventory [--output <File>] [--dir <Dir> ...] [--no-installed]",
+		Description: "Writes the fonts installed for all users and for the current user, and the fonts in --dir, as JSON. " +
+			"Compare the inventories of two machines with fontctl diff.\n\n" +
+			"Example:\nfontctl inventory -o node17.json",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "File to write the inventory to (default: stdout)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "dir",
+				Usage: "Directory with font files to include (can be repeated)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-installed",
+				Usage: "Only record the fonts in --dir, not the installed fonts",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.NArg() != 0 {
+				cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+			}
+			var sources []inventory.Source
+			if !c.Bool("no-installed") {
+				installed, err := installedFontFiles()
+				if err != nil {
+					return exitWithError(err)
+				}
+				for _, f := range installed {
+					sources = append(sources, inventory.Source{Name: f.RegistryName, Scope: string(f.Scope), Path: f.Path})
+				}
+			}
+			for _, dir := range c.StringSlice("dir") {
+				paths, err := fonts.FindFontFiles(dir)
+				if err != nil {
+					return exitWithError(err)
+				}
+				for _, p := range paths {
+					sources = append(sources, inventory.Source{Scope: "dir", Path: p})
+				}
+			}
+			if len(sources) == 0 {
+				return cli.Exit("no fonts to record, use --dir (the installed fonts are only known on MS Windows and Linux)", exitUsage)
+			}
+
+			inv := inventory.Collect(sources, inventory.Options{Logger: logger})
+			output := c.String("output")
+			if output == "" {
+				return inventory.Write(os.Stdout, inv)
+			}
+			file, err := os.Create(output)
+			if err != nil {
+				return exitWithError(fmt.Errorf("can't create '%s' (%w)", output, fonts.WithAccessDenied(err)))
+			}
+			err = inventory.Write(file, inv)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return exitWithError(err)
+			}
+			fmt.Printf("recorded %d fonts in %s\n", len(inv.Fonts), output)
+			return nil
+		},
+	}
+}
+
+// diffCommand returns the diff command, which compares saved inventories on any OS.
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Compare the font inventories of two machines",
+		UsageText: "fontctl diff <Inventory A> <Inventory B> [--json]",
+		Description: "Lists the fonts that are only in A (-) or only in B (+), the fonts that changed (~) with their version, " +
+			"and the names that more than one different font file provides on one machine but not on the other (!).\n\n" +
+			"Example:\nfontctl diff node16.json node17.json",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print JSON instead of text",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.NArg() != 2 {
+				cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+			}
+			pathA, pathB := c.Args().Get(0), c.Args().Get(1)
+			a, err := inventory.Load(pathA)
+			if err != nil {
+				return exitWithError(err)
+			}
+			b, err := inventory.Load(pathB)
+			if err != nil {
+				return exitWithError(err)
+			}
+			d := inventory.Compare(a, b)
+			if c.Bool("json") {
+				return printJSON(d)
+			}
+			return printInventoryDiff(d, pathA, pathB)
+		},
+	}
+}
+
+// printInventoryDiff prints the differences of two inventories, one font per line.
+func printInventoryDiff(d inventory.Diff, labelA, labelB string) error {
+	if d.Empty() {
+		fmt.Println("no differences")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, f := range d.Removed {
+		fmt.Fprintf(w, "- %s\t%s\t%s\t%s\n", f.Name, f.Scope, f.Version, inventory.BaseName(f.File))
+	}
+	for _, f := range d.Added {
+		fmt.Fprintf(w, "+ %s\t%s\t%s\t%s\n", f.Name, f.Scope, f.Version, inventory.BaseName(f.File))
+	}
+	for _, ch := range d.Changed {
+		fmt.Fprintf(w, "~ %s\t%s\t%s\n", ch.Name, ch.New.Scope, strings.Join(ch.Changes, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, col := range d.Collisions {
+		label := labelA
+		if col.In == "b" {
+			label = labelB
+		}
+		files := make([]string, len(col.Fonts))
+		for i, f := range col.Fonts {
+			files[i] = fmt.Sprintf("%s (%s)", f.Name, strings.Join(slices.DeleteFunc([]string{f.Scope, f.Version}, func(s string) bool { return s == "" }), ", "))
+		}
+		fmt.Printf("! %s: provided by %d files in %s: %s\n", col.Name, len(col.Fonts), label, strings.Join(files, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+
+	"fontctl/duplicates"
+	"fontctl/fonts"
+	"fontctl/linuxfont"
+	"fontctl/state"
+	"fontctl/substitutes"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// linuxfontOptions returns the linuxfont options for the global command line flags.
+func linuxfontOptions() linuxfont.Options {
+	opts := linuxfont.Options{Logger: logger}
+	if verifier.Enabled() {
+		opts.Verify = verifier.VerifyCopy
+	}
+	store, err := linuxfont.DefaultStateStore()
+	if err != nil {
+		logger.Warn("can't use state file, loaded fonts are not tracked", "error", err)
+		return opts
+	}
+	opts.State = store
+	return opts
+}
+
+// installedFontDirs returns the system font dirs and the user's font dir.
+func installedFontDirs() []string {
+	return linuxfont.FontDirs()
+}
+
+// installedSubstitutes returns the FontSubstitutes rules, which only exist on MS Windows.
+func installedSubstitutes() ([]substitutes.Substitute, error) {
+	return nil, nil
+}
+
+// installedFontFiles returns the fonts in the system font dirs and the user's font dir with their scope.
+func installedFontFiles() ([]duplicates.File, error) {
+	installed, err := linuxfont.InstalledFonts(linuxfontOptions())
+	if err != nil {
+		return nil, err
+	}
+	files := make([]duplicates.File, 0, len(installed))
+	for _, f := range installed {
+		scope := duplicates.ScopeSystem
+		if f.User {
+			scope = duplicates.ScopeUser
+		}
+		files = append(files, duplicates.File{Path: f.Path, Scope: scope})
+	}
+	return files, nil
+}
+
+var fontCommandHelp = fontHelp{
+	installUsageText: "fontctl install [--systemwide] [--on-conflict fail|skip|overwrite|newer] [--target macos-layout --root <Dir> [--mac-user <Name>]] <Font File>",
+	installDescription: "With --target macos-layout, the font is copied into the macOS font dir layout under --root instead of being installed, " +
+		"to prepare a payload for Macs. Font suitcases without their resource fork are rebuilt as .dfont files from their ._ file.",
+	installSystemWide:   "Install in " + linuxfont.SystemFontDir + " (default: install in the current user's font dir). Requires root.",
+	uninstallSystemWide: "Uninstall from " + linuxfont.SystemFontDir + " (default: uninstall from the user font dir). Requires root.",
+	uninstallName:       "Uninstall the font with this full or PostScript name, i.e. \"Foo Bold\"",
+	loadDescription:     "This makes a font temporarily available to applications, until the font gets unloaded or the user logs out",
+	loadedDescription:   "Lists the fonts that fontctl load, exec and agent loaded in this login session and that weren't unloaded yet. Fonts loaded by other tools are not listed.",
+	execExample:         "fontctl exec --font a.ttf --font fonts/ -- inkscape --export-type=pdf poster.svg",
+	scanExample:         "fontctl scan --library /mnt/fonts --load subtitles.ass",
+	exportDescription: "Writes the installed font files (in the XDG font dirs) and a manifest with their names, " +
+		"hashes and scope to a zip file, which fontctl import installs on another machine.",
+	watchSystemWide:    "With --install: install in " + linuxfont.SystemFontDir + ". Requires root.",
+	watchNotify:        "a single fontconfig cache refresh",
+	refreshUsage:       "Refresh the fontconfig cache",
+	refreshDescription: "Runs fc-cache for all font dirs, so applications become aware of font changes.",
+}
+
+// installUserFlags are the install flags for the fonts of other users. Linux has none.
+var installUserFlags []cli.Flag
+
+// installForUsers installs fonts for other users. That isn't supported on Linux, so it never handles the
+// install command.
+func installForUsers(ctx context.Context, c *cli.Command, onConflict fonts.ConflictPolicy) (bool, error) {
+	return false, nil
+}
+
+// platformCommands returns the font commands that only exist on this OS. Linux has none.
+func platformCommands() []*cli.Command {
+	return nil
+}
+
+// linuxfontBackend is the fontBackend for Linux. Notifying applications refreshes the fontconfig cache.
+type linuxfontBackend struct {
+	opts linuxfont.Options
+}
+
+func newFontBackend(o backendOptions) fontBackend {
+	opts := linuxfontOptions()
+	opts.DeferRefresh = o.deferNotify
+	if o.withoutSignatures && verifier.Enabled() {
+		opts.Verify = verifier.WithoutSignatures().VerifyCopy
+	}
+	return linuxfontBackend{opts: opts}
+}
+
+func (b linuxfontBackend) Install(ctx context.Context, fontPath string, systemWide bool, onConflict fonts.ConflictPolicy) error {
+	return linuxfont.InstallFontFromFile(ctx, fontPath, linuxfont.InstallOptions{Options: b.opts, SystemWide: systemWide, OnConflict: onConflict})
+}
+
+func (b linuxfontBackend) Uninstall(ctx context.Context, by uninstallBy, value string, systemWide bool) error {
+	opts := linuxfont.UninstallOptions{Options: b.opts, SystemWide: systemWide}
+	switch by {
+	case uninstallByName:
+		return linuxfont.UninstallFontByName(ctx, value, opts)
+	case uninstallByHash:
+		return linuxfont.UninstallFontByHash(ctx, value, opts)
+	case uninstallByPath:
+		return linuxfont.UninstallFontByPath(ctx, value, opts)
+	}
+	return linuxfont.UninstallFontFromFile(ctx, value, opts)
+}
+
+func (b linuxfontBackend) IsInstalled(fontPath string, systemWide bool) (bool, error) {
+	return linuxfont.IsInstalled(fontPath, systemWide, b.opts)
+}
+
+func (b linuxfontBackend) InstalledFonts() ([]installedFont, error) {
+	installed, err := linuxfont.InstalledFonts(b.opts)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]installedFont, 0, len(installed))
+	for _, f := range installed {
+		list = append(list, installedFont{Name: f.Name, Path: f.Path, PFBPath: f.PFBPath, User: f.User})
+	}
+	return list, nil
+}
+
+func (b linuxfontBackend) Load(ctx context.Context, fontPaths []string) error {
+	return linuxfont.LoadFonts(ctx, fontPaths, b.opts)
+}
+
+func (b linuxfontBackend) Unload(ctx context.Context, fontPaths []string) error {
+	return linuxfont.UnloadFonts(ctx, fontPaths, b.opts)
+}
+
+func (b linuxfontBackend) UnloadAll(ctx context.Context) ([]state.Entry, error) {
+	return linuxfont.UnloadAllFonts(ctx, b.opts)
+}
+
+func (b linuxfontBackend) Loaded() ([]state.Entry, error) {
+	return linuxfont.LoadedFonts(b.opts)
+}
+
+func (b linuxfontBackend) Notify(ctx context.Context) error {
+	opts := b.opts
+	opts.DeferRefresh = false
+	return linuxfont.RefreshCache(ctx, opts)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"fontctl/fonts"
+	"fontctl/match"
+	"fontctl/scan"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// matchCommand returns the match command. It only reads font files, so it works on any OS with --dir.
+func matchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "match",
+		Usage:     "Show which font file GDI would use for a font name and style",
+		UsageText: "fontctl match <Font Name> [--weight <100-900>] [--italic] [--charset <Charset>] [--dir <Dir> ...] [--all] [--json]",
+		Description: "Simulates the GDI font mapper (CreateFont) for the installed fonts, or the fonts in --dir, and prints the " +
+			"font file it would pick and whether bold or italic would be synthesized. Unlike preview font, this shows when " +
+			"Windows would silently fall back to another font. On MS Windows, the FontSubstitutes rules are applied to names " +
+			"that no font has.\n\nExample:\nfontctl match \"Segoe UI\" --weight 700 --italic",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "weight",
+				Usage: "Requested weight, 400 is regular and 700 bold",
+				Value: 400,
+			},
+			&cli.BoolFlag{
+				Name:  "italic",
+				Usage: "Request an italic font",
+			},
+			&cli.StringFlag{
+				Name:  "charset",
+				Usage: "Requested character set, i.e. ANSI, RUSSIAN, SHIFTJIS or a number",
+				Value: "DEFAULT",
+			},
+			&cli.StringSliceFlag{
+				Name:  "dir",
+				Usage: "Directory with the fonts to match against instead of the installed fonts (can be repeated)",
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Print all candidates, best first",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print JSON instead of text",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.NArg() != 1 {
+				cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+			}
+			charset, err := fonts.ParseCharset(c.String("charset"))
+			if err != nil {
+				return cli.Exit(err.Error(), exitUsage)
+			}
+			dirs := c.StringSlice("dir")
+			if len(dirs) == 0 {
+				dirs = installedFontDirs()
+			}
+			if len(dirs) == 0 {
+				return cli.Exit("--dir is required, there are no installed fonts to match against on this OS", exitUsage)
+			}
+			faces, err := scan.BuildCatalog(dirs...)
+			if err != nil {
+				return exitWithError(err)
+			}
+			subs, err := installedSubstitutes()
+			if err != nil {
+				logger.Warn("can't read the font substitutes, matching without them", "error", err)
+			}
+			req := match.Request{FaceName: c.Args().First(), Weight: int(c.Int("weight")), Italic: c.Bool("italic"), Charset: charset}
+			result, err := match.Match(faces, req, subs)
+			if err != nil {
+				return exitWithError(err)
+			}
+			if c.Bool("json") {
+				if !c.Bool("all") {
+					result.Candidates = nil
+				}
+				return printJSON(result)
+			}
+			if c.Bool("all") {
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "PENALTY\tPATH\tFULL NAME\tWEIGHT\tITALIC\tMATCHED BY\tSYNTHESIZED")
+				for _, m := range result.Candidates {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%v\t%s\t%s\n", m.Penalty, m.Path, m.FullName, m.Weight, m.Italic, m.MatchedBy, synthesized(m))
+				}
+				return w.Flush()
+			}
+			m := result.Candidate
+			if result.Substitute != "" {
+				fmt.Printf("Substitute:  %s, no font is named '%s'\n", result.Substitute, req.FaceName)
+			}
+			fmt.Printf("File:        %s\n", m.Path)
+			fmt.Printf("Face:        %s (family %s, weight %d, italic %v)\n", m.FullName, strings.Join(m.GDIFamilies, ", "), m.Weight, m.Italic)
+			if m.MatchedBy == match.Fallback {
+				fmt.Printf("Matched by:  fallback, no font is named '%s'\n", req.FaceName)
+			} else {
+				fmt.Printf("Matched by:  %s\n", m.MatchedBy)
+			}
+			if !m.CharsetSupported {
+				fmt.Printf("Charset:     %s is not supported by the font\n", fonts.CharsetName(charset))
+			}
+			fmt.Printf("Synthesized: %s\n", synthesized(m))
+			return nil
+		},
+	}
+}
+
+// synthesized lists the styles GDI would synthesize for a candidate.
+func synthesized(m match.Candidate) string {
+	var styles []string
+	if m.SynthesizedBold {
+		styles = append(styles, "bold")
+	}
+	if m.SynthesizedItalic {
+		styles = append(styles, "italic")
+	}
+	if len(styles) == 0 {
+		return "none"
+	}
+	return strings.Join(styles, ", ")
+}
@@ -0,0 +1,31 @@
+//go:build !windows && !linux
+
+package main
+
+import (
+	"fontctl/duplicates"
+	"fontctl/substitutes"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// fontCommands returns the commands that manage fonts on this OS. Installing and loading fonts is only
+// supported on MS Windows and Linux so far.
+func fontCommands() []*cli.Command {
+	return nil
+}
+
+// installedFontDirs returns the dirs of the installed fonts. There are none on this OS so far.
+func installedFontDirs() []string {
+	return nil
+}
+
+// installedSubstitutes returns the FontSubstitutes rules, which only exist on MS Windows.
+func installedSubstitutes() ([]substitutes.Substitute, error) {
+	return nil, nil
+}
+
+// installedFontFiles returns the installed font files with their scope. There are none on this OS so far.
+func installedFontFiles() ([]duplicates.File, error) {
+	return nil, nil
+}
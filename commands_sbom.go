@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"fontctl/fonts"
+	"fontctl/sbom"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// sbomCommand returns the sbom command. The installed fonts are only known on MS Windows and Linux,
+// directories can be described on any OS.
+func sbomCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "sbom",
+		Usage:     "Write a software bill of materials of fonts",
+		UsageText: "fontctl sbom [<Dir> ...] [--installed] [--format spdx-json|cyclonedx-json] [--output <File>]",
+		Description: "Describes every font file in the dirs, or the installed fonts, as a component with its name, version, " +
+			"vendor, license and SHA-256 hash. Licenses are mapped to SPDX identifiers where they are recognized " +
+			"(i.e. OFL-1.1, Apache-2.0, Ubuntu-font-1.0), other license texts are included as they are.\n\n" +
+			"Example:\nfontctl sbom assets\\fonts --format cyclonedx-json -o fonts.cdx.json",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "installed",
+				Usage: "Describe the fonts installed for all users and for the current user",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: sbom.FormatSPDX,
+				Usage: "Document format: spdx-json or cyclonedx-json",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "File to write the document to (default: stdout)",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.NArg() == 0 && !c.Bool("installed") {
+				cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+			}
+			format := c.String("format")
+			if format != sbom.FormatSPDX && format != sbom.FormatCycloneDX {
+				return cli.Exit(fmt.Sprintf("Error - unknown format '%s', use %s or %s", format, sbom.FormatSPDX, sbom.FormatCycloneDX), exitUsage)
+			}
+			var paths []string
+			if c.Bool("installed") {
+				installed, err := installedFontFiles()
+				if err != nil {
+					return exitWithError(err)
+				}
+				if len(installed) == 0 {
+					return cli.Exit("no installed fonts found (the installed fonts are only known on MS Windows and Linux)", exitUsage)
+				}
+				for _, f := range installed {
+					paths = append(paths, f.Path)
+				}
+			}
+			for _, dir := range c.Args().Slice() {
+				found, err := fonts.FindFontFiles(dir)
+				if err != nil {
+					return exitWithError(err)
+				}
+				paths = append(paths, found...)
+			}
+
+			opts := sbom.Options{Logger: logger}
+			components, err := sbom.Read(paths, opts)
+			if err != nil {
+				return exitWithError(err)
+			}
+			output := c.String("output")
+			if output == "" {
+				return sbom.Write(os.Stdout, format, components, opts)
+			}
+			file, err := os.Create(output)
+			if err != nil {
+				return exitWithError(fmt.Errorf("can't create '%s' (%w)", output, fonts.WithAccessDenied(err)))
+			}
+			err = sbom.Write(file, format, components, opts)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return exitWithError(err)
+			}
+			fmt.Printf("described %d fonts in %s\n", len(components), output)
+			return nil
+		},
+	}
+}
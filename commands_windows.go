@@ -0,0 +1,434 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"fontctl/duplicates"
+	"fontctl/fonts"
+	"fontctl/state"
+	"fontctl/substitutes"
+	"fontctl/winfont"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// winfontOptions returns the winfont options for the global command line flags.
+func winfontOptions() winfont.Options {
+	opts := winfont.Options{Logger: logger}
+	if verifier.Enabled() {
+		opts.Verify = verifier.VerifyCopy
+	}
+	store, err := winfont.DefaultStateStore()
+	if err != nil {
+		logger.Warn("can't use state file, loaded fonts are not tracked", "error", err)
+		return opts
+	}
+	opts.State = store
+	return opts
+}
+
+// installedFontDirs returns the system and user font dirs.
+func installedFontDirs() []string {
+	return winfont.FontDirs()
+}
+
+// installedSubstitutes returns the FontSubstitutes rules GDI applies.
+func installedSubstitutes() ([]substitutes.Substitute, error) {
+	return winfont.ListFontSubstitutes(winfontOptions())
+}
+
+// installedFontFiles returns the fonts registered in HKLM and HKCU with their scope.
+func installedFontFiles() ([]duplicates.File, error) {
+	installed, err := winfont.InstalledFonts(winfontOptions())
+	if err != nil {
+		return nil, err
+	}
+	files := make([]duplicates.File, 0, len(installed))
+	for _, f := range installed {
+		scope := duplicates.ScopeSystem
+		if f.User {
+			scope = duplicates.ScopeUser
+		}
+		files = append(files, duplicates.File{Path: f.Path, Scope: scope, RegistryName: f.Name})
+	}
+	return files, nil
+}
+
+var fontCommandHelp = fontHelp{
+	installUsageText: "fontctl install [--systemwide | --for-user <Name|SID> ... | --all-users] [--on-conflict fail|skip|overwrite|newer] [--target macos-layout --root <Dir> [--mac-user <Name>]] <Font File>",
+	installDescription: "With --for-user or --all-users, the font is installed into the profiles of other users, i.e. from a service account. " +
+		"It is copied into their AppData\\Local\\Microsoft\\Windows\\Fonts and registered in their registry hive, which is loaded from their NTUSER.DAT if they aren't signed in. " +
+		"They get the font with their next sign-in.\n\n" +
+		"With --target macos-layout, the font is copied into the macOS font dir layout under --root instead of being installed, " +
+		"to prepare a payload for Macs. Font suitcases without their resource fork are rebuilt as .dfont files from their ._ file.",
+	installSystemWide:   "Install in the system font dir (default: install in the current user's userprofile). Requires Admin privileges.",
+	uninstallSystemWide: "Uninstall from the system font dir (default: uninstall from the user font dir). Requires Admin privileges.",
+	uninstallName:       "Uninstall the font registered under this name, i.e. \"Foo Bold (TrueType)\"",
+	loadDescription:     "This makes a font temporarily available to applications, until the font gets unloaded or the next reboot",
+	loadedDescription:   "Lists the fonts that fontctl load, exec and agent loaded in this Windows session and that weren't unloaded yet. Fonts loaded by other tools are not listed.",
+	execExample:         "fontctl exec --font a.ttf --font fonts\\ -- aerender -project x.aep",
+	scanExample:         "fontctl scan --library \\\\server\\fonts --load subtitles.ass",
+	exportDescription: "Writes the installed font files (as registered in the font registry keys) and a manifest with their names, " +
+		"hashes and scope to a zip file, which fontctl import installs on another machine.",
+	watchSystemWide:    "With --install: install in the system font dir. Requires Admin privileges.",
+	watchNotify:        "a single WM_FONTCHANGE broadcast",
+	refreshUsage:       "Refresh known fonts for current user session",
+	refreshDescription: "Sends a WM_FONTCHANGE broadcast so currently running applications become aware of font changes.",
+}
+
+// installUserFlags are the install flags for the fonts of other users.
+var installUserFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "for-user",
+		Usage: "Install in the userprofile of another user, given by name (i.e. DOMAIN\\jane) or SID. Can be repeated. Requires Admin privileges.",
+	},
+	&cli.BoolFlag{
+		Name:  "all-users",
+		Usage: "Install in the userprofiles of all users that have signed in on this machine. Requires Admin privileges.",
+	},
+}
+
+// installForUsers installs the font of the install command into the profiles selected with --for-user and
+// --all-users, and prints a line per user. It returns false if neither flag is set.
+func installForUsers(ctx context.Context, c *cli.Command, onConflict fonts.ConflictPolicy) (bool, error) {
+	if !c.IsSet("for-user") && !c.Bool("all-users") {
+		return false, nil
+	}
+	if c.Bool("systemwide") {
+		return true, cli.Exit("Error - --for-user and --all-users can't be combined with --systemwide", exitUsage)
+	}
+	opts := winfont.InstallOptions{Options: winfontOptions(), OnConflict: onConflict}
+	var profiles []winfont.UserProfile
+	if c.Bool("all-users") {
+		all, err := winfont.UserProfiles(opts.Options)
+		if err != nil {
+			return true, exitWithError(err)
+		}
+		profiles = all
+	}
+	for _, user := range c.StringSlice("for-user") {
+		p, err := winfont.LookupUserProfile(user, opts.Options)
+		if err != nil {
+			return true, exitWithError(err)
+		}
+		if !slices.ContainsFunc(profiles, func(q winfont.UserProfile) bool { return q.SID == p.SID }) {
+			profiles = append(profiles, p)
+		}
+	}
+
+	var firstErr error
+	for _, p := range profiles {
+		if err := winfont.InstallFontForUser(ctx, c.Args().First(), p, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error - %s: %s\n", p, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		fmt.Printf("+ %s\n", p)
+	}
+	if firstErr != nil {
+		return true, exitWithError(firstErr)
+	}
+	return true, nil
+}
+
+// winfontBackend is the fontBackend for MS Windows. Notifying applications sends a WM_FONTCHANGE
+// broadcast.
+type winfontBackend struct {
+	opts winfont.Options
+}
+
+func newFontBackend(o backendOptions) fontBackend {
+	opts := winfontOptions()
+	opts.DeferNotify = o.deferNotify
+	if o.withoutSignatures && verifier.Enabled() {
+		opts.Verify = verifier.WithoutSignatures().VerifyCopy
+	}
+	return winfontBackend{opts: opts}
+}
+
+func (b winfontBackend) Install(ctx context.Context, fontPath string, systemWide bool, onConflict fonts.ConflictPolicy) error {
+	return winfont.InstallFontFromFile(ctx, fontPath, winfont.InstallOptions{Options: b.opts, SystemWide: systemWide, OnConflict: onConflict})
+}
+
+func (b winfontBackend) Uninstall(ctx context.Context, by uninstallBy, value string, systemWide bool) error {
+	opts := winfont.UninstallOptions{Options: b.opts, SystemWide: systemWide}
+	switch by {
+	case uninstallByName:
+		return winfont.UninstallFontByName(ctx, value, opts)
+	case uninstallByHash:
+		return winfont.UninstallFontByHash(ctx, value, opts)
+	case uninstallByPath:
+		return winfont.UninstallFontByPath(ctx, value, opts)
+	}
+	return winfont.UninstallFontFromFile(ctx, value, opts)
+}
+
+func (b winfontBackend) IsInstalled(fontPath string, systemWide bool) (bool, error) {
+	return winfont.IsInstalled(fontPath, systemWide, b.opts)
+}
+
+func (b winfontBackend) InstalledFonts() ([]installedFont, error) {
+	installed, err := winfont.InstalledFonts(b.opts)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]installedFont, 0, len(installed))
+	for _, f := range installed {
+		list = append(list, installedFont{Name: f.Name, Path: f.Path, PFBPath: f.PFBPath, User: f.User})
+	}
+	return list, nil
+}
+
+func (b winfontBackend) Load(ctx context.Context, fontPaths []string) error {
+	return winfont.LoadFonts(ctx, fontPaths, b.opts)
+}
+
+func (b winfontBackend) Unload(ctx context.Context, fontPaths []string) error {
+	return winfont.UnloadFonts(ctx, fontPaths, b.opts)
+}
+
+func (b winfontBackend) UnloadAll(ctx context.Context) ([]state.Entry, error) {
+	return winfont.UnloadAllFonts(ctx, b.opts)
+}
+
+func (b winfontBackend) Loaded() ([]state.Entry, error) {
+	return winfont.LoadedFonts(b.opts)
+}
+
+func (b winfontBackend) Notify(ctx context.Context) error {
+	opts := b.opts
+	opts.DeferNotify = false
+	return winfont.NotifyFontChange(ctx, opts)
+}
+
+// platformCommands returns the font commands that only exist on MS Windows.
+func platformCommands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      "getname",
+			Usage:     "Get the font name from a file",
+			UsageText: "fontctl getname <Font File>",
+			Action: func(ctx context.Context, c *cli.Command) error {
+				if c.NArg() != 1 {
+					cli.ShowCommandHelpAndExit(ctx, c.Root(), c.Name, exitUsage)
+				}
+				fontName, err := winfont.GetFontNameFromFile(ctx, c.Args().First(), winfontOptions())
+				if err != nil {
+					return exitWithError(err)
+				}
+				fmt.Println(fontName)
+				return nil
+			},
+		},
+		{
+			Name:  "substitute",
+			Usage: "Manage font substitutes",
+			Description: `Font substitutes (HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontSubstitutes) make Windows give applications asking for a font that is not installed a different font, i.e. Arial for Helvetica. Names can have a character set after a comma, i.e. "Helvetica,ANSI" or "Helvetica,0". Changes are made for 64-bit and 32-bit applications, need Admin privileges and are used by applications started after the next sign-in.
+
+Example:
+fontctl substitute add "Helvetica" "Arial"`,
+			Commands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Add a font substitute",
+					ArgsUsage: "<Font Name> <Substitute Font Name>",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "force",
+							Usage: "Replace a different substitute of the font",
+						},
+					},
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() != 2 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						err := winfont.AddFontSubstitute(c.Args().Get(0), c.Args().Get(1), c.Bool("force"), winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						return nil
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "Remove a font substitute",
+					ArgsUsage: "<Font Name>",
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() != 1 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						err := winfont.RemoveFontSubstitute(c.Args().First(), winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						return nil
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List the font substitutes",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "json",
+							Usage: "Print JSON instead of a table",
+						},
+					},
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() != 0 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						list, err := winfont.ListFontSubstitutes(winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						if c.Bool("json") {
+							return printJSON(list)
+						}
+						w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(w, "FONT\tSUBSTITUTE")
+						for _, s := range list {
+							fmt.Fprintf(w, "%s\t%s\n", s.Font, s.Substitute)
+						}
+						return w.Flush()
+					},
+				},
+			},
+		},
+		{
+			Name:  "fallback",
+			Usage: "Manage fallback fonts",
+			Description: `Fallback fonts (HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontLink\SystemLink) are used for characters that a font doesn't have, in the order they are listed. A fallback is a font file in the fonts dir and a family name, separated by a comma. Changes are made for 64-bit and 32-bit applications, need Admin privileges and are used by applications started after the next sign-in.
+
+Example:
+fontctl fallback add "Segoe UI" "NotoSansCJK.ttc,Noto Sans CJK"`,
+			Commands: []*cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Add a fallback font",
+					ArgsUsage: "<Font Name> <Font File,Family>",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "first",
+							Usage: "Put the fallback before the existing ones instead of after them",
+						},
+					},
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() != 2 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						err := winfont.AddFontFallback(c.Args().Get(0), c.Args().Get(1), c.Bool("first"), winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						return nil
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "Remove a fallback font, or all fallbacks of a font",
+					ArgsUsage: "<Font Name> [<Font File,Family>]",
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() < 1 || c.NArg() > 2 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						err := winfont.RemoveFontFallback(c.Args().Get(0), c.Args().Get(1), winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						return nil
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List the fallback fonts",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "json",
+							Usage: "Print JSON instead of a table",
+						},
+					},
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() != 0 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						list, err := winfont.ListFontFallbacks(winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						if c.Bool("json") {
+							return printJSON(list)
+						}
+						w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+						fmt.Fprintln(w, "FONT\tFALLBACK")
+						for _, f := range list {
+							for _, link := range f.Links {
+								fmt.Fprintf(w, "%s\t%s\n", f.Font, link)
+							}
+						}
+						return w.Flush()
+					},
+				},
+			},
+		},
+		{
+			Name:  "preview",
+			Usage: "Preview a font",
+			Commands: []*cli.Command{
+				{
+					Name:      "file",
+					Usage:     "Preview a font file using Windows Font Viewer",
+					ArgsUsage: "<Font File>",
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() < 1 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						err := winfont.PreviewFontWithFontview(ctx, c.Args().First(), winfontOptions())
+						if err != nil {
+							return exitWithError(err)
+						}
+						return nil
+					},
+				},
+				{
+					Name:  "font",
+					Usage: "Preview a loaded font using Windows GDI",
+					Description: `Render font preview in a gdi window. Please note that when a font is missing or can't be used, Windows will fall back to displaying the text in a default font. So what you are seeing might not always be the font that you've requested. Use fontctl match to check which font Windows picks.
+
+Example:
+fontctl preview font "Comic Sans MS" "regular"`,
+					ArgsUsage: "<Font Name> <Font Style: regular|bold|italic|bold-italic>",
+					Action: func(ctx context.Context, c *cli.Command) error {
+						if c.NArg() < 1 {
+							cli.ShowSubcommandHelpAndExit(c, exitUsage)
+						}
+						if c.NArg() < 2 {
+							return cli.Exit("Missing Font Name or Font Style", exitUsage)
+						}
+						fontName := c.Args().Get(0)
+						fontStyle := strings.ToLower(c.Args().Get(1))
+						allowed := map[string]bool{
+							"regular":     true,
+							"bold":        true,
+							"italic":      true,
+							"bold-italic": true,
+						}
+						if !allowed[fontStyle] {
+							return cli.Exit(fmt.Sprintf("invalid Font Style: %s (must be one of regular, bold, italic, bold-italic)", fontStyle), exitUsage)
+						}
+						winfont.PreviewFontWithGDI(fontName, fontStyle)
+						return nil
+					},
+				},
+			},
+		},
+	}
+}
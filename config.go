@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"fontctl/config"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// configFlags are the global flags that select the config file and profile.
+var configFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "config",
+		Usage:   "config file with defaults and profiles (default: config.toml in the fontctl dir of the user config dir)",
+		Sources: cli.EnvVars(config.EnvPrefix + "CONFIG"),
+	},
+	&cli.StringFlag{
+		Name:    "profile",
+		Usage:   "profile of the config file to use (default: the profile set in the config file)",
+		Sources: cli.EnvVars(config.EnvPrefix + "PROFILE"),
+	},
+}
+
+// applyConfig loads the config file and the FONTCTL_* environment variables and applies the settings
+// of the selected profile as defaults of the command line flags. Flags set on the command line win
+// over the environment, the environment wins over the config file.
+func applyConfig(c *cli.Command) error {
+	path, missingOK := c.String("config"), false
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return nil // no user config dir, i.e. a service account without profile
+		}
+		missingOK = true
+	}
+	cfg, err := config.Load(path, missingOK)
+	if err != nil {
+		return err
+	}
+	p, err := cfg.Resolve(c.String("profile"))
+	if err != nil {
+		return err
+	}
+	env, err := config.FromEnv(os.Getenv)
+	if err != nil {
+		return err
+	}
+	p = p.Merge(env)
+
+	// the global flags are already parsed, set them unless they are on the command line
+	for name, value := range map[string]string{
+		"log-level":         p.LogLevel,
+		"log-format":        p.LogFormat,
+		"log-file":          p.LogFile,
+		"require-allowlist": p.Allowlist,
+	} {
+		if value != "" && !c.IsSet(name) {
+			if err := c.Set(name, value); err != nil {
+				return err
+			}
+		}
+	}
+	if p.Debug != nil && !c.IsSet("debug") {
+		if err := c.Set("debug", strconv.FormatBool(*p.Debug)); err != nil {
+			return err
+		}
+	}
+	if !c.IsSet("trusted-key") {
+		for _, key := range p.TrustedKeys {
+			if err := c.Set("trusted-key", key); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the flags of the commands are parsed later, change their defaults
+	for _, cmd := range c.Commands {
+		setFlagDefaults(cmd, p)
+	}
+	return nil
+}
+
+// setFlagDefaults sets the defaults of the flags of a command and its subcommands to the settings of
+// a profile.
+func setFlagDefaults(cmd *cli.Command, p config.Profile) {
+	for _, fl := range cmd.Flags {
+		switch f := fl.(type) {
+		case *cli.BoolFlag:
+			switch {
+			case f.Name == "systemwide" && p.SystemWide != nil:
+				f.Value = *p.SystemWide
+			case f.Name == "json" && p.Output != "":
+				f.Value = p.Output == "json"
+			}
+		case *cli.StringFlag:
+			switch {
+			case f.Name == "on-conflict" && p.OnConflict != "":
+				f.Value = p.OnConflict
+			case f.Name == "index" && p.Index != "":
+				f.Value = p.Index
+			}
+		case *cli.StringSliceFlag:
+			// the font library is searched by scan, and checked by match and duplicates
+			if p.Library != nil && (f.Name == "library" || f.Name == "dir" && (cmd.Name == "match" || cmd.Name == "duplicates")) {
+				f.Value = p.Library
+			}
+		}
+	}
+	for _, sub := range cmd.Commands {
+		setFlagDefaults(sub, p)
+	}
+}
+
+// configError turns an error of the config file or environment into a usage error, unless it has an
+// exit code of its own (i.e. a --config file that doesn't exist).
+func configError(err error) error {
+	if exitCode(err) != exitError {
+		return exitWithError(err)
+	}
+	return cli.Exit(fmt.Sprintf("Error - %s", err), exitUsage)
+}
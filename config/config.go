@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fontctl/fonts"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the settings of the config file,
+// i.e. FONTCTL_ON_CONFLICT for on_conflict.
+const EnvPrefix = "FONTCTL_"
+
+// Profile are the settings of a profile. Unset fields don't change the default of the command line flag.
+type Profile struct {
+	SystemWide  *bool    `toml:"systemwide"`   // install for all users
+	OnConflict  string   `toml:"on_conflict"`  // fail, skip, overwrite or newer
+	Allowlist   string   `toml:"allowlist"`    // --require-allowlist
+	TrustedKeys []string `toml:"trusted_keys"` // --trusted-key, signature validation
+	Library     []string `toml:"library"`      // font library roots
+	Index       string   `toml:"index"`        // index database file
+	Output      string   `toml:"output"`       // text or json
+	Debug       *bool    `toml:"debug"`
+	LogLevel    string   `toml:"log_level"`
+	LogFormat   string   `toml:"log_format"`
+	LogFile     string   `toml:"log_file"`
+}
+
+// Config is the content of the config file.
+//
+//	profile = "workstation"      # profile used if --profile isn't set
+//
+//	[defaults]                   # settings of all profiles
+//	log_level = "warn"
+//
+//	[profiles.renderfarm]
+//	systemwide = true
+//	on_conflict = "newer"
+//	allowlist = '\\fileserver\fonts\allow.txt'
+//	library = ['\\fileserver\fonts']
+type Config struct {
+	Profile  string             `toml:"profile"`
+	Defaults Profile            `toml:"defaults"`
+	Profiles map[string]Profile `toml:"profiles"`
+}
+
+// DefaultPath returns the path of the config file: config.toml in the fontctl dir of the user config
+// dir, i.e. %APPDATA%\fontctl\config.toml on MS Windows and $XDG_CONFIG_HOME/fontctl/config.toml on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "fontctl", "config.toml"), nil
+}
+
+// Load reads a config file. A missing file is an empty config if missingOK is set, so the default
+// config file is optional.
+func Load(path string, missingOK bool) (Config, error) {
+	var cfg Config
+	md, err := toml.DecodeFile(path, &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		if missingOK {
+			return Config{}, nil
+		}
+		return cfg, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, path, err)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("invalid config file '%s' (%w)", path, fonts.WithAccessDenied(err))
+	}
+	// a misspelled key would silently be ignored, i.e. an allowlist that is never checked
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return cfg, fmt.Errorf("invalid config file '%s': unknown key '%s'", path, undecoded[0])
+	}
+	if err := cfg.Defaults.validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config file '%s': defaults: %w", path, err)
+	}
+	for name, p := range cfg.Profiles {
+		if err := p.validate(); err != nil {
+			return cfg, fmt.Errorf("invalid config file '%s': profile '%s': %w", path, name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Resolve returns the settings of a profile merged over the defaults. An empty name selects the profile
+// of the config file, if it has one.
+func (c Config) Resolve(name string) (Profile, error) {
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return c.Defaults, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("unknown profile '%s' (profiles in the config file: %s)", name, strings.Join(names, ", "))
+	}
+	return c.Defaults.Merge(p), nil
+}
+
+// Merge returns p with the fields that are set in o replaced.
+func (p Profile) Merge(o Profile) Profile {
+	if o.SystemWide != nil {
+		p.SystemWide = o.SystemWide
+	}
+	if o.Debug != nil {
+		p.Debug = o.Debug
+	}
+	for _, f := range []struct{ dst, src *string }{
+		{&p.OnConflict, &o.OnConflict}, {&p.Allowlist, &o.Allowlist}, {&p.Index, &o.Index}, {&p.Output, &o.Output},
+		{&p.LogLevel, &o.LogLevel}, {&p.LogFormat, &o.LogFormat}, {&p.LogFile, &o.LogFile},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if o.TrustedKeys != nil {
+		p.TrustedKeys = o.TrustedKeys
+	}
+	if o.Library != nil {
+		p.Library = o.Library
+	}
+	return p
+}
+
+// FromEnv returns the settings of the FONTCTL_* environment variables, i.e. FONTCTL_SYSTEMWIDE=true.
+// Lists are separated like PATH. getenv is os.Getenv, or a fake for tests.
+func FromEnv(getenv func(string) string) (Profile, error) {
+	var p Profile
+	var err error
+	if p.SystemWide, err = envBool(getenv, "SYSTEMWIDE"); err != nil {
+		return p, err
+	}
+	if p.Debug, err = envBool(getenv, "DEBUG"); err != nil {
+		return p, err
+	}
+	p.OnConflict = getenv(EnvPrefix + "ON_CONFLICT")
+	p.Allowlist = getenv(EnvPrefix + "ALLOWLIST")
+	p.Index = getenv(EnvPrefix + "INDEX")
+	p.Output = getenv(EnvPrefix + "OUTPUT")
+	p.LogLevel = getenv(EnvPrefix + "LOG_LEVEL")
+	p.LogFormat = getenv(EnvPrefix + "LOG_FORMAT")
+	p.LogFile = getenv(EnvPrefix + "LOG_FILE")
+	if v := getenv(EnvPrefix + "TRUSTED_KEYS"); v != "" {
+		p.TrustedKeys = filepath.SplitList(v)
+	}
+	if v := getenv(EnvPrefix + "LIBRARY"); v != "" {
+		p.Library = filepath.SplitList(v)
+	}
+	if err := p.validate(); err != nil {
+		return p, fmt.Errorf("environment: %w", err)
+	}
+	return p, nil
+}
+
+func envBool(getenv func(string) string, name string) (*bool, error) {
+	v := getenv(EnvPrefix + name)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("environment: invalid %s%s '%s' (must be true or false)", EnvPrefix, name, v)
+	}
+	return &b, nil
+}
+
+func (p Profile) validate() error {
+	for _, f := range []struct {
+		key, value string
+		valid      []string
+	}{
+		{"on_conflict", p.OnConflict, []string{"fail", "skip", "overwrite", "newer"}},
+		{"output", p.Output, []string{"text", "json"}},
+		{"log_level", strings.ToLower(p.LogLevel), []string{"debug", "info", "warn", "error", "none"}},
+		{"log_format", strings.ToLower(p.LogFormat), []string{"text", "json"}},
+	} {
+		if f.value != "" && !slices.Contains(f.valid, f.value) {
+			return fmt.Errorf("invalid %s '%s' (must be %s)", f.key, f.value, strings.Join(f.valid, ", "))
+		}
+	}
+	return nil
+}
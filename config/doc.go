@@ -0,0 +1,5 @@
+// Package config reads the fontctl configuration file with default settings and named profiles, and
+// the FONTCTL_* environment variables that override them.
+//
+// The package is operating system independent.
+package config
@@ -0,0 +1,7 @@
+// Package duplicates finds fonts that are installed or stored more than once: files with the same
+// content, and different files with the same PostScript or full name (i.e. two versions of a font, one
+// installed for all users and one for the current user). For every group it tells which font GDI uses
+// and which ones can be removed.
+//
+// The package is operating system independent, the caller provides the installed font files.
+package duplicates
@@ -0,0 +1,260 @@
+package duplicates
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Scope is where a font file comes from.
+type Scope string
+
+const (
+	ScopeSystem Scope = "system" // installed for all users (HKLM)
+	ScopeUser   Scope = "user"   // installed for the current user (HKCU)
+	ScopeDir    Scope = "dir"    // not installed, in a directory that was searched
+)
+
+// rank orders the scopes by the preference of GDI: fonts installed for all users are loaded first at
+// logon, and GDI keeps using the first loaded font of a name.
+func (s Scope) rank() int {
+	switch s {
+	case ScopeSystem:
+		return 0
+	case ScopeUser:
+		return 1
+	}
+	return 2
+}
+
+// File is a font file to check.
+type File struct {
+	Path  string `json:"path"`
+	Scope Scope  `json:"scope"`
+	// RegistryName is the registry value name of installed fonts, i.e. "Arial (TrueType)".
+	RegistryName string `json:"registry_name,omitempty"`
+}
+
+// Font is a face of a font file.
+type Font struct {
+	File
+	Hash string     `json:"hash"`
+	Face fonts.Face `json:"face"`
+}
+
+// Kinds of duplicates.
+const (
+	KindHash           = "hash"
+	KindPostScriptName = "postscript name"
+	KindFullName       = "full name"
+)
+
+// Group is a set of duplicate fonts.
+type Group struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"` // the hash or name the fonts have in common
+	// Fonts are ordered by preference, GDI uses the first one.
+	Fonts    []Font    `json:"fonts"`
+	Removals []Removal `json:"removals,omitempty"`
+}
+
+// Removal is a font that can be removed.
+type Removal struct {
+	File
+	Reason string `json:"reason"`
+}
+
+// Options are the settings of Read.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return o.Logger
+}
+
+// Read reads the faces and hashes of the files. Files that can't be read are logged and skipped, as
+// are files that were already read (i.e. registered in both HKLM and HKCU), so the first scope wins.
+func Read(files []File, opts Options) []Font {
+	log := opts.log()
+	var list []Font
+	var seen []string
+	for _, f := range files {
+		if slices.ContainsFunc(seen, func(p string) bool { return strings.EqualFold(p, f.Path) }) {
+			log.Debug("skipping font file that was already read", "path", f.Path, "scope", f.Scope)
+			continue
+		}
+		seen = append(seen, f.Path)
+		hash, err := fonts.HashFile(f.Path)
+		if err != nil {
+			log.Warn("can't read font file, skipping it", "path", f.Path, "error", err)
+			continue
+		}
+		faces, err := fonts.ReadFaces(f.Path)
+		if err != nil {
+			log.Warn("can't read font file, skipping it", "path", f.Path, "error", err)
+			continue
+		}
+		for _, face := range faces {
+			list = append(list, Font{File: f, Hash: fonts.FormatHash(hash), Face: face})
+		}
+	}
+	return list
+}
+
+// Find groups the fonts by content hash, PostScript name and full name. Only groups of different files
+// are returned. Name groups of identical files are left out, they are a hash group already, and so is a
+// full name group if a PostScript name group has the same files. Identical files are not reported if
+// only one of them is installed, the others are the source of the installed font.
+func Find(list []Font) []Group {
+	var groups []Group
+	add := func(kind string, key func(Font) string) {
+		byKey := map[string][]Font{}
+		var keys []string
+		for _, f := range list {
+			k := key(f)
+			if k == "" {
+				continue
+			}
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			// one face per file, the faces of a collection have the same hash
+			if !slices.ContainsFunc(byKey[k], func(o Font) bool { return strings.EqualFold(o.Path, f.Path) }) {
+				byKey[k] = append(byKey[k], f)
+			}
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fs := byKey[k]
+			if len(fs) < 2 || kind != KindHash && allSameHash(fs) {
+				continue
+			}
+			// an installed font and its source files in a directory are expected
+			if kind == KindHash && countInstalled(fs) == 1 {
+				continue
+			}
+			if kind == KindFullName && slices.ContainsFunc(groups, func(g Group) bool { return g.Kind == KindPostScriptName && sameFiles(g.Fonts, fs) }) {
+				continue
+			}
+			groups = append(groups, newGroup(kind, fs))
+		}
+	}
+	add(KindHash, func(f Font) string { return f.Hash })
+	add(KindPostScriptName, func(f Font) string { return strings.ToLower(f.Face.PostScriptName) })
+	add(KindFullName, func(f Font) string { return strings.ToLower(f.Face.FullName) })
+	return groups
+}
+
+func newGroup(kind string, list []Font) Group {
+	first := list[0]
+	list = slices.Clone(list)
+	slices.SortStableFunc(list, func(a, b Font) int { return a.Scope.rank() - b.Scope.rank() })
+	key := first.Hash
+	switch kind {
+	case KindPostScriptName:
+		key = first.Face.PostScriptName
+	case KindFullName:
+		key = first.Face.FullName
+	}
+	g := Group{Kind: kind, Key: key, Fonts: list}
+	if kind == KindHash {
+		g.Removals = hashRemovals(list)
+	} else {
+		g.Removals = nameRemovals(list)
+	}
+	return g
+}
+
+// hashRemovals suggests to remove the copies of the font GDI uses. Files in directories are only
+// suggested if the font isn't installed at all, then they are duplicates in a font library.
+func hashRemovals(list []Font) []Removal {
+	kept := list[0]
+	var removals []Removal
+	for _, f := range list[1:] {
+		if f.Scope == ScopeDir && kept.Scope != ScopeDir {
+			continue
+		}
+		removals = append(removals, Removal{File: f.File, Reason: fmt.Sprintf("identical copy of '%s'", kept.Path)})
+	}
+	return removals
+}
+
+// nameRemovals suggests to remove the installed fonts with the same name that are older than the newest
+// installed one, or that have the same version and are never used. Of equal versions the one GDI uses
+// is kept.
+func nameRemovals(list []Font) []Removal {
+	newest := -1
+	for i, f := range list {
+		if f.Scope == ScopeDir {
+			continue
+		}
+		if newest < 0 || fonts.CompareFaceVersions(f.Face, list[newest].Face) > 0 {
+			newest = i
+		}
+	}
+	if newest < 0 {
+		return nil // nothing installed, different fonts in a library are not a problem
+	}
+	kept := list[newest]
+	var removals []Removal
+	for i, f := range list {
+		if i == newest || f.Scope == ScopeDir {
+			continue
+		}
+		reason := ""
+		switch {
+		case f.Hash == kept.Hash:
+			reason = fmt.Sprintf("identical copy of '%s'", kept.Path)
+		case fonts.CompareFaceVersions(f.Face, kept.Face) < 0 && i == 0:
+			reason = fmt.Sprintf("older version (%s) than '%s' (%s), but GDI uses this one", versionOf(f), kept.Path, versionOf(kept))
+		case fonts.CompareFaceVersions(f.Face, kept.Face) < 0:
+			reason = fmt.Sprintf("older version (%s) than '%s' (%s)", versionOf(f), kept.Path, versionOf(kept))
+		default:
+			reason = fmt.Sprintf("different file with the same version as '%s', GDI doesn't use it", list[0].Path)
+		}
+		removals = append(removals, Removal{File: f.File, Reason: reason})
+	}
+	return removals
+}
+
+func versionOf(f Font) string {
+	if f.Face.Version == "" {
+		return "unknown"
+	}
+	return f.Face.Version
+}
+
+func countInstalled(list []Font) int {
+	n := 0
+	for _, f := range list {
+		if f.Scope != ScopeDir {
+			n++
+		}
+	}
+	return n
+}
+
+func allSameHash(list []Font) bool {
+	return !slices.ContainsFunc(list, func(f Font) bool { return f.Hash != list[0].Hash })
+}
+
+func sameFiles(a, b []Font) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, f := range a {
+		if !slices.ContainsFunc(b, func(o Font) bool { return strings.EqualFold(o.Path, f.Path) }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// execArgs splits the arguments of the exec command into the values of its --font flags and the
+// command. The command starts after "--" or at the first argument that isn't a flag, and its arguments
+// are passed through as they are, even if they look like flags of fontctl. --help returns no fonts, so
+// the caller shows the help.
+func execArgs(args []string) (fontPaths, command []string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return fontPaths, args[i+1:], nil
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return fontPaths, args[i:], nil
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch name {
+		case "font", "f":
+			if !hasValue {
+				if i+1 == len(args) {
+					return nil, nil, fmt.Errorf("flag needs an argument: %s", arg)
+				}
+				i++
+				value = args[i]
+			}
+			fontPaths = append(fontPaths, value)
+		case "help", "h":
+			return nil, nil, nil
+		default:
+			return nil, nil, fmt.Errorf("flag provided but not defined: %s", arg)
+		}
+	}
+	return fontPaths, nil, nil
+}
+
+// fontsFunc loads or unloads a set of fonts.
+type fontsFunc func(ctx context.Context, fontPaths []string) error
+
+// runWithFonts loads the fonts, runs the command and unloads the fonts again, also when the command
+// fails or fontctl gets interrupted. It returns the exit code of the command.
+//
+// The first Ctrl-C (or SIGTERM) is forwarded to the command and fontctl waits for it to exit. On Windows,
+// the command gets Ctrl-C from the console itself. A second one kills the command.
+func runWithFonts(ctx context.Context, fontPaths []string, args []string, load, unload fontsFunc) (int, error) {
+	// catch signals before loading, so there is no window in which an interrupt skips the cleanup
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	if err := load(ctx, fontPaths); err != nil {
+		return exitError, err
+	}
+	logger.Debug("fonts loaded", "count", len(fontPaths))
+	defer func() {
+		if err := unload(context.WithoutCancel(ctx), fontPaths); err != nil {
+			logger.Error("failed to unload fonts after command", "error", err)
+			fmt.Fprintf(os.Stderr, "Warning - failed to unload fonts (%s)\n", err)
+			return
+		}
+		logger.Debug("fonts unloaded", "count", len(fontPaths))
+	}()
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return exitError, fmt.Errorf("failed to start '%s' (%w)", args[0], err)
+	}
+	logger.Debug("command started", "command", args, "pid", cmd.Process.Pid)
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- cmd.Wait() }()
+
+	interrupted := false
+	for {
+		select {
+		case sig := <-sigs:
+			if !interrupted {
+				interrupted = true
+				logger.Info("forwarding signal to command", "signal", sig.String())
+				if err := cmd.Process.Signal(sig); err != nil {
+					logger.Debug("can't forward signal, waiting for command to exit", "signal", sig.String(), "error", err)
+				}
+				continue
+			}
+			logger.Info("second interrupt, killing command", "signal", sig.String())
+			cmd.Process.Kill()
+		case <-ctx.Done():
+			cmd.Process.Kill()
+			<-waitErr
+			return exitError, ctx.Err()
+		case err := <-waitErr:
+			var exitErr *exec.ExitError
+			switch {
+			case err == nil:
+				return exitOK, nil
+			case errors.As(err, &exitErr):
+				logger.Debug("command exited", "command", args, "exitcode", exitErr.ExitCode())
+				if code := exitErr.ExitCode(); code > 0 {
+					return code, nil
+				}
+				// killed by a signal, exit like a shell does
+				if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+					return 128 + int(ws.Signal()), nil
+				}
+				return exitError, nil
+			}
+			return exitError, err
+		}
+	}
+}
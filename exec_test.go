@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestExecArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantFonts []string
+		wantCmd   []string
+		wantErr   bool
+	}{
+		{"separator", []string{"--font", "a.ttf", "-f", "dir", "--", "sh", "-c", "exit 7"}, []string{"a.ttf", "dir"}, []string{"sh", "-c", "exit 7"}, false},
+		{"flags with =", []string{"--font=a.ttf", "-f=b.ttf", "--", "aerender", "-project", "x.aep"}, []string{"a.ttf", "b.ttf"}, []string{"aerender", "-project", "x.aep"}, false},
+		{"no separator", []string{"-f", "a.ttf", "make", "-j4"}, []string{"a.ttf"}, []string{"make", "-j4"}, false},
+		{"flags of the command after the separator", []string{"-f", "a.ttf", "--", "--font", "x"}, []string{"a.ttf"}, []string{"--font", "x"}, false},
+		{"no command", []string{"-f", "a.ttf", "--"}, []string{"a.ttf"}, []string{}, false},
+		{"help", []string{"--help", "--", "sh"}, nil, nil, false},
+		{"unknown flag", []string{"--fnt", "a.ttf", "--", "sh"}, nil, nil, true},
+		{"missing value", []string{"--font"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fonts, cmd, err := execArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("execArgs() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !slices.Equal(fonts, tt.wantFonts) || !slices.Equal(cmd, tt.wantCmd) {
+				t.Errorf("execArgs() = %q, %q, want %q, %q", fonts, cmd, tt.wantFonts, tt.wantCmd)
+			}
+		})
+	}
+}
+
+func TestRunWithFonts(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run")
+	}
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var calls []string
+	load := func(ctx context.Context, fontPaths []string) error {
+		calls = append(calls, "load")
+		return nil
+	}
+	unload := func(ctx context.Context, fontPaths []string) error {
+		calls = append(calls, "unload")
+		return nil
+	}
+	_, command, err := execArgs([]string{"--font", "a.ttf", "--", sh, "-c", "exit 7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := runWithFonts(context.Background(), []string{"a.ttf"}, command, load, unload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 7 {
+		t.Errorf("runWithFonts() = %d, want the exit code 7 of the command", code)
+	}
+	if !slices.Equal(calls, []string{"load", "unload"}) {
+		t.Errorf("runWithFonts() called %q, want the fonts loaded and unloaded", calls)
+	}
+
+	if runtime.GOOS == "windows" {
+		return // no signals
+	}
+	code, err = runWithFonts(context.Background(), []string{"a.ttf"}, []string{sh, "-c", "kill -9 $$"}, load, unload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 128+9 {
+		t.Errorf("runWithFonts() = %d for a killed command, want 128 plus the signal number", code)
+	}
+}
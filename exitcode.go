@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"fontctl/audit"
+	"fontctl/fonts"
+	"fontctl/index"
+	"fontctl/substitutes"
+	"fontctl/trust"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// Exit codes of the fontctl CLI. They are part of the public interface (documented in the README),
+// so don't renumber them.
+const (
+	exitOK                     = 0
+	exitError                  = 1 // any error not covered below
+	exitUsage                  = 2 // invalid command line arguments
+	exitFileNotFound           = 3 // also used for fonts that are not installed or not in the index and missing substitution rules
+	exitNotAFont               = 4
+	exitAccessDenied           = 5
+	exitFileExistsAndDifferent = 6
+	exitRegistry               = 7
+	exitGDI                    = 8
+	exitRebootRequired         = 9
+	exitPolicyViolation        = 10
+	exitUntrusted              = 11 // not in the allowlist or no valid signature
+)
+
+// exitCode maps an error to the exit code of the CLI. Access denied is checked first, as
+// registry and GDI errors can be caused by missing privileges.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, fonts.ErrRebootRequired):
+		return exitRebootRequired
+	case errors.Is(err, fonts.ErrAccessDenied):
+		return exitAccessDenied
+	case errors.Is(err, fonts.ErrFileNotFound), errors.Is(err, fonts.ErrNotInstalled), errors.Is(err, index.ErrNoMatch), errors.Is(err, substitutes.ErrNotFound):
+		return exitFileNotFound
+	case errors.Is(err, fonts.ErrNotAFont):
+		return exitNotAFont
+	case errors.Is(err, fonts.ErrFileExistsAndIsDifferent), errors.Is(err, substitutes.ErrExists):
+		return exitFileExistsAndDifferent
+	case errors.Is(err, fonts.ErrRegistry):
+		return exitRegistry
+	case errors.Is(err, fonts.ErrGDI):
+		return exitGDI
+	case errors.Is(err, trust.ErrNotAllowed), errors.Is(err, trust.ErrBadSignature):
+		return exitUntrusted
+	case errors.Is(err, audit.ErrPolicyViolation):
+		return exitPolicyViolation
+	}
+	return exitError
+}
+
+// exitWithError turns an error into a cli exit error with the matching exit code.
+func exitWithError(err error) error {
+	return cli.Exit(fmt.Sprintf("Error - %s", err), exitCode(err))
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"fontctl/fonts"
+	"fontctl/index"
+	"fontctl/trust"
+)
+
+func TestExitCode(t *testing.T) {
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"), index.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	_, noMatch := ix.Find("Helvetica Neue", "Bold")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"no error", nil, exitOK},
+		{"index find without match", noMatch, exitFileNotFound},
+		{"file not found", fmt.Errorf("%w 'Foo.ttf'", fonts.ErrFileNotFound), exitFileNotFound},
+		{"access denied first", fmt.Errorf("%w (%w)", fonts.ErrRegistry, fonts.ErrAccessDenied), exitAccessDenied},
+		{"not in the allowlist", fmt.Errorf("%w: 'Foo.ttf'", trust.ErrNotAllowed), exitUntrusted},
+		{"other", errors.New("boom"), exitError},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%s: %v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// Package fontconfig generates fonts.conf files for fontconfig, for renderers that are pointed at a font
+// config (i.e. with FONTCONFIG_FILE) instead of using installed fonts. The config adds font library dirs,
+// substitutes and prefers families according to a rules file and rejects blocked font files by their
+// hash.
+//
+// The package is operating system independent.
+package fontconfig
@@ -0,0 +1,220 @@
+package fontconfig
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Options are the settings for Generate.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return discardLogger
+	}
+	return o.Logger
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// Config is a generated fontconfig configuration.
+type Config struct {
+	Dirs  []string // absolute font dirs
+	Rules Rules
+	// Rejected are the files in Dirs whose hash is blocked by the rules.
+	Rejected []string
+	// Warnings are rules that refer to families or hashes that are not in Dirs. They are not errors, the
+	// families can come from an included config.
+	Warnings []string
+}
+
+// Generate reads the fonts in dirs and returns the config for them and the rules. All files in the dirs
+// are hashed, so blocked files are rejected whatever their format.
+func Generate(dirs []string, rules Rules, opts Options) (*Config, error) {
+	log := opts.log()
+	blocked := make(map[string]bool, len(rules.Block))
+	for _, h := range rules.Block {
+		hash, err := fonts.ParseHash(h)
+		if err != nil {
+			return nil, err
+		}
+		blocked[fonts.FormatHash(hash)] = false
+	}
+
+	cfg := &Config{Rules: rules}
+	families := map[string]bool{}
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(cfg.Dirs, abs) {
+			cfg.Dirs = append(cfg.Dirs, abs)
+		}
+		var rejected []string
+		err = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			hash, err := fonts.HashFile(path)
+			if err != nil {
+				return err
+			}
+			if _, ok := blocked[fonts.FormatHash(hash)]; ok {
+				log.Debug("rejecting blocked font file", "path", path, "hash", fonts.FormatHash(hash))
+				blocked[fonts.FormatHash(hash)] = true
+				rejected = append(rejected, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, dir, fonts.WithAccessDenied(err))
+		}
+		cfg.Rejected = append(cfg.Rejected, rejected...)
+
+		files, err := fonts.FindFontFiles(abs)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range files {
+			if slices.Contains(rejected, path) {
+				continue
+			}
+			faces, err := fonts.ReadFaces(path)
+			if err != nil {
+				log.Debug("can't read font names, skipping file", "path", path, "error", err)
+				continue
+			}
+			for _, f := range faces {
+				for _, name := range append(f.Families, f.Family) {
+					families[strings.ToLower(name)] = true
+				}
+			}
+		}
+	}
+
+	for _, kind := range []string{"substitute", "prefer"} {
+		m := rules.Substitute
+		if kind == "prefer" {
+			m = rules.Prefer
+		}
+		for _, family := range sortedKeys(m) {
+			for _, f := range m[family] {
+				if !families[strings.ToLower(f)] {
+					cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("%s %s: family '%s' is not in the font dirs", kind, family, f))
+				}
+			}
+		}
+	}
+	for _, h := range sortedKeys(blocked) {
+		if !blocked[h] {
+			cfg.Warnings = append(cfg.Warnings, fmt.Sprintf("block: no file with hash %s in the font dirs", h))
+		}
+	}
+	return cfg, nil
+}
+
+// fontconfig XML elements, see fonts-conf(5)
+type (
+	xmlConfig struct {
+		XMLName    xml.Name       `xml:"fontconfig"`
+		Comment    xml.Comment    `xml:",comment"`
+		Includes   []xmlInclude   `xml:"include"`
+		Dirs       []string       `xml:"dir"`
+		CacheDir   xmlCacheDir    `xml:"cachedir"`
+		SelectFont *xmlSelectFont `xml:"selectfont"`
+		Matches    []xmlMatch     `xml:"match"`
+		Aliases    []xmlAlias     `xml:"alias"`
+	}
+	xmlInclude struct {
+		IgnoreMissing string `xml:"ignore_missing,attr"`
+		Path          string `xml:",chardata"`
+	}
+	xmlCacheDir struct {
+		Prefix string `xml:"prefix,attr"`
+		Path   string `xml:",chardata"`
+	}
+	xmlSelectFont struct {
+		Globs []string `xml:"rejectfont>glob"`
+	}
+	xmlMatch struct {
+		Target string  `xml:"target,attr"`
+		Test   xmlTest `xml:"test"`
+		Edit   xmlEdit `xml:"edit"`
+	}
+	xmlTest struct {
+		Qual   string `xml:"qual,attr"`
+		Name   string `xml:"name,attr"`
+		String string `xml:"string"`
+	}
+	xmlEdit struct {
+		Name    string   `xml:"name,attr"`
+		Mode    string   `xml:"mode,attr"`
+		Binding string   `xml:"binding,attr"`
+		Strings []string `xml:"string"`
+	}
+	xmlAlias struct {
+		Family string   `xml:"family"`
+		Prefer []string `xml:"prefer>family"`
+	}
+)
+
+// Write writes the config as fonts.conf XML. Substitutions replace the requested family with strong
+// binding, so they win over the family itself, preferred families become <alias> rules.
+func Write(w io.Writer, cfg *Config) error {
+	doc := xmlConfig{
+		Comment:  xml.Comment(" Generated by fontctl fontconfig gen, changes will be overwritten. "),
+		Dirs:     cfg.Dirs,
+		CacheDir: xmlCacheDir{Prefix: "xdg", Path: "fontconfig"},
+	}
+	for _, path := range cfg.Rules.Include {
+		doc.Includes = append(doc.Includes, xmlInclude{IgnoreMissing: "yes", Path: path})
+	}
+	if len(cfg.Rejected) > 0 {
+		doc.SelectFont = &xmlSelectFont{Globs: cfg.Rejected}
+	}
+	for _, family := range sortedKeys(cfg.Rules.Substitute) {
+		doc.Matches = append(doc.Matches, xmlMatch{
+			Target: "pattern",
+			Test:   xmlTest{Qual: "any", Name: "family", String: family},
+			Edit:   xmlEdit{Name: "family", Mode: "assign", Binding: "strong", Strings: cfg.Rules.Substitute[family]},
+		})
+	}
+	for _, family := range sortedKeys(cfg.Rules.Prefer) {
+		doc.Aliases = append(doc.Aliases, xmlAlias{Family: family, Prefer: cfg.Rules.Prefer[family]})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<!DOCTYPE fontconfig SYSTEM \"urn:fontconfig:fonts.dtd\">\n")
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
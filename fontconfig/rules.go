@@ -0,0 +1,63 @@
+package fontconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fontctl/fonts"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rules are the font selection rules of a generated config.
+//
+//	include: [/etc/fonts/fonts.conf]
+//	substitute:
+//	  Helvetica: [Nimbus Sans]
+//	prefer:
+//	  sans-serif: [Inter, DejaVu Sans]
+//	block:
+//	  - sha256:0f1e...
+type Rules struct {
+	// Include lists other configs to include, i.e. the system config. Missing files are ignored.
+	Include []string `yaml:"include"`
+	// Substitute replaces requested families with other families: a document asking for the key gets
+	// the first of the listed families that is available.
+	Substitute map[string][]string `yaml:"substitute"`
+	// Prefer lists preferred families for a family or a generic family (sans-serif, serif, monospace),
+	// which are used if available, before the family itself.
+	Prefer map[string][]string `yaml:"prefer"`
+	// Block lists the hashes (sha256:<hex>) of font files that must not be used.
+	Block []string `yaml:"block"`
+}
+
+// LoadRules reads a rules file. Unknown keys are an error, a misspelled key would silently drop rules.
+func LoadRules(rulesPath string) (Rules, error) {
+	var r Rules
+	data, err := os.ReadFile(rulesPath)
+	if err != nil {
+		return r, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, rulesPath, fonts.WithAccessDenied(err))
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&r); err != nil && !errors.Is(err, io.EOF) {
+		return r, fmt.Errorf("invalid rules '%s' (%w)", rulesPath, err)
+	}
+	for _, h := range r.Block {
+		if _, err := fonts.ParseHash(h); err != nil {
+			return r, fmt.Errorf("invalid rules '%s': block (%w)", rulesPath, err)
+		}
+	}
+	for _, m := range []map[string][]string{r.Substitute, r.Prefer} {
+		for family, families := range m {
+			if strings.TrimSpace(family) == "" || len(families) == 0 {
+				return r, fmt.Errorf("invalid rules '%s': family '%s' needs a list of families", rulesPath, family)
+			}
+		}
+	}
+	return r, nil
+}
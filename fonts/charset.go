@@ -0,0 +1,111 @@
+package fonts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Windows character sets (LOGFONT.lfCharSet), as used by GDI to pick a font.
+const (
+	CharsetANSI        = 0
+	CharsetDefault     = 1 // any character set
+	CharsetSymbol      = 2
+	CharsetMac         = 77
+	CharsetShiftJIS    = 128
+	CharsetHangul      = 129
+	CharsetJohab       = 130
+	CharsetGB2312      = 134
+	CharsetChineseBig5 = 136
+	CharsetGreek       = 161
+	CharsetTurkish     = 162
+	CharsetVietnamese  = 163
+	CharsetHebrew      = 177
+	CharsetArabic      = 178
+	CharsetBaltic      = 186
+	CharsetRussian     = 204
+	CharsetThai        = 222
+	CharsetEastEurope  = 238
+	CharsetOEM         = 255
+)
+
+var charsetNames = map[int]string{
+	CharsetANSI:        "ANSI",
+	CharsetDefault:     "DEFAULT",
+	CharsetSymbol:      "SYMBOL",
+	CharsetMac:         "MAC",
+	CharsetShiftJIS:    "SHIFTJIS",
+	CharsetHangul:      "HANGUL",
+	CharsetJohab:       "JOHAB",
+	CharsetGB2312:      "GB2312",
+	CharsetChineseBig5: "CHINESEBIG5",
+	CharsetGreek:       "GREEK",
+	CharsetTurkish:     "TURKISH",
+	CharsetVietnamese:  "VIETNAMESE",
+	CharsetHebrew:      "HEBREW",
+	CharsetArabic:      "ARABIC",
+	CharsetBaltic:      "BALTIC",
+	CharsetRussian:     "RUSSIAN",
+	CharsetThai:        "THAI",
+	CharsetEastEurope:  "EASTEUROPE",
+	CharsetOEM:         "OEM",
+}
+
+// codePageCharsets maps the bits of the OS/2 ulCodePageRange fields to the character sets.
+var codePageCharsets = map[int]int{
+	0:  CharsetANSI,
+	1:  CharsetEastEurope,
+	2:  CharsetRussian,
+	3:  CharsetGreek,
+	4:  CharsetTurkish,
+	5:  CharsetHebrew,
+	6:  CharsetArabic,
+	7:  CharsetBaltic,
+	8:  CharsetVietnamese,
+	16: CharsetThai,
+	17: CharsetShiftJIS,
+	18: CharsetGB2312,
+	19: CharsetHangul,
+	20: CharsetChineseBig5,
+	21: CharsetJohab,
+	29: CharsetMac,
+	31: CharsetSymbol,
+}
+
+// CharsetName returns the name of a character set without the _CHARSET suffix, i.e. "ANSI", or the
+// number for unknown ones.
+func CharsetName(charset int) string {
+	if name, ok := charsetNames[charset]; ok {
+		return name
+	}
+	return strconv.Itoa(charset)
+}
+
+// ParseCharset parses a character set name like "ANSI" or "RUSSIAN_CHARSET" (case-insensitive) or number.
+func ParseCharset(s string) (int, error) {
+	name := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "_CHARSET")
+	for charset, n := range charsetNames {
+		if n == name {
+			return charset, nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 && n <= 255 {
+		return n, nil
+	}
+	return 0, fmt.Errorf("unknown character set '%s'", s)
+}
+
+// charsetsFromCodePages returns the character sets of the OS/2 ulCodePageRange bits, in bit order.
+// Fonts without code page ranges are taken as ANSI fonts, like GDI does.
+func charsetsFromCodePages(codePages uint64) []int {
+	var charsets []int
+	for bit := 0; bit < 64; bit++ {
+		if charset, ok := codePageCharsets[bit]; ok && codePages&(1<<bit) != 0 {
+			charsets = append(charsets, charset)
+		}
+	}
+	if len(charsets) == 0 {
+		return []int{CharsetANSI}
+	}
+	return charsets
+}
@@ -0,0 +1,178 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"slices"
+	"sort"
+	"unicode"
+)
+
+// Coverage summarizes the characters a font has glyphs for.
+type Coverage struct {
+	Codepoints int      `json:"codepoints"`
+	Scripts    []string `json:"scripts,omitempty"` // Unicode scripts the font covers, i.e. "Latin", "Cyrillic", "Han"
+}
+
+// Covers reports whether the font covers the Unicode script.
+func (c Coverage) Covers(script string) bool {
+	return slices.Contains(c.Scripts, script)
+}
+
+// maxCmapRunes limits the characters read from a cmap subtable. A font can't map more than all code points,
+// but overlapping ranges in a broken or crafted font could list them over and over.
+const maxCmapRunes = unicode.MaxRune + 1
+
+// minScriptCodepoints is the number of characters of a script a font needs to cover it. Fonts often
+// have a few characters of other scripts, i.e. the Greek µ and π, which doesn't make them Greek fonts.
+const minScriptCodepoints = 20
+
+// parseCmap reads the Unicode mappings of the cmap table (format 4 or 12 subtables) and summarizes them.
+func parseCmap(data []byte) Coverage {
+	if len(data) < 4 {
+		return Coverage{}
+	}
+	// full Unicode subtables first, BMP only subtables second
+	best, bestScore := -1, 0
+	numTables := int(binary.BigEndian.Uint16(data[2:]))
+	for i := 0; i < numTables && 4+8*i+8 <= len(data); i++ {
+		rec := data[4+8*i:]
+		platform, encoding := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[2:])
+		offset := int(binary.BigEndian.Uint32(rec[4:]))
+		if offset+2 > len(data) {
+			continue
+		}
+		format := binary.BigEndian.Uint16(data[offset:])
+		score := 0
+		switch {
+		case format == 12 && (platform == platformUnicode || platform == platformWindows && encoding == 10):
+			score = 2
+		case format == 4 && (platform == platformUnicode || platform == platformWindows && encoding == 1):
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = offset, score
+		}
+	}
+	if best < 0 {
+		return Coverage{}
+	}
+
+	var runes []rune
+	if bestScore == 2 {
+		runes = cmapFormat12(data[best:])
+	} else {
+		runes = cmapFormat4(data[best:])
+	}
+	slices.Sort(runes)
+	runes = slices.Compact(runes)
+	return Coverage{Codepoints: len(runes), Scripts: scriptsOf(runes)}
+}
+
+// cmapFormat4 returns the characters of a format 4 subtable (segment mapping to delta values) that map
+// to a glyph.
+func cmapFormat4(st []byte) []rune {
+	if len(st) < 14 {
+		return nil
+	}
+	segCount := int(binary.BigEndian.Uint16(st[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + 2*segCount + 2
+	idDeltas := startCodes + 2*segCount
+	idRangeOffsets := idDeltas + 2*segCount
+	if idRangeOffsets+2*segCount > len(st) {
+		return nil
+	}
+	var runes []rune
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(st[endCodes+2*i:]))
+		start := int(binary.BigEndian.Uint16(st[startCodes+2*i:]))
+		delta := int(binary.BigEndian.Uint16(st[idDeltas+2*i:]))
+		rangeOffset := int(binary.BigEndian.Uint16(st[idRangeOffsets+2*i:]))
+		for c := start; c <= end && c < 0xFFFF && len(runes) < maxCmapRunes; c++ {
+			glyph := 0
+			if rangeOffset == 0 {
+				glyph = (c + delta) & 0xFFFF
+			} else {
+				// the offset is relative to the idRangeOffset entry itself
+				pos := idRangeOffsets + 2*i + rangeOffset + 2*(c-start)
+				if pos+2 > len(st) {
+					break
+				}
+				if glyph = int(binary.BigEndian.Uint16(st[pos:])); glyph != 0 {
+					glyph = (glyph + delta) & 0xFFFF
+				}
+			}
+			if glyph != 0 {
+				runes = append(runes, rune(c))
+			}
+		}
+	}
+	return runes
+}
+
+// cmapFormat12 returns the characters of a format 12 subtable (segmented coverage).
+func cmapFormat12(st []byte) []rune {
+	if len(st) < 16 {
+		return nil
+	}
+	numGroups := int(binary.BigEndian.Uint32(st[12:]))
+	var runes []rune
+	for i := 0; i < numGroups && 16+12*i+12 <= len(st) && len(runes) < maxCmapRunes; i++ {
+		g := st[16+12*i:]
+		// code points beyond Unicode have no characters, the part of a group in the Unicode range is kept
+		start, end := binary.BigEndian.Uint32(g), min(binary.BigEndian.Uint32(g[4:]), unicode.MaxRune)
+		if end < start {
+			continue
+		}
+		startGlyph := binary.BigEndian.Uint32(g[8:])
+		for c := start; c <= end && len(runes) < maxCmapRunes; c++ {
+			if c == start && startGlyph == 0 {
+				continue // mapped to .notdef
+			}
+			runes = append(runes, rune(c))
+		}
+	}
+	return runes
+}
+
+// scriptsOf returns the names of the Unicode scripts the sorted characters cover, in alphabetical order.
+func scriptsOf(runes []rune) []string {
+	// count returns how many characters of the sorted list are in [lo, hi]
+	count := func(lo, hi rune) int {
+		i := sort.Search(len(runes), func(i int) bool { return runes[i] >= lo })
+		j := sort.Search(len(runes), func(i int) bool { return runes[i] > hi })
+		return j - i
+	}
+	var scripts []string
+	for name, table := range unicode.Scripts {
+		if name == "Common" || name == "Inherited" {
+			continue
+		}
+		covered, size := 0, 0
+		for _, r := range table.R16 {
+			n, s := countRange(rune(r.Lo), rune(r.Hi), rune(r.Stride), count)
+			covered, size = covered+n, size+s
+		}
+		for _, r := range table.R32 {
+			n, s := countRange(rune(r.Lo), rune(r.Hi), rune(r.Stride), count)
+			covered, size = covered+n, size+s
+		}
+		if covered > 0 && covered >= min(minScriptCodepoints, size) {
+			scripts = append(scripts, name)
+		}
+	}
+	slices.Sort(scripts)
+	return scripts
+}
+
+// countRange returns how many characters of a Unicode range table entry are covered, and its size.
+func countRange(lo, hi, stride rune, count func(lo, hi rune) int) (covered, size int) {
+	if stride == 1 {
+		return count(lo, hi), int(hi-lo) + 1
+	}
+	for c := lo; c <= hi; c += stride {
+		covered += count(c, c)
+		size++
+	}
+	return covered, size
+}
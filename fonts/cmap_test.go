@@ -0,0 +1,51 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode"
+)
+
+// cmapFormat12Table returns a format 12 subtable with groups of start, end and start glyph.
+func cmapFormat12Table(groups ...[3]uint32) []byte {
+	st := binary.BigEndian.AppendUint16(nil, 12)
+	st = binary.BigEndian.AppendUint16(st, 0)
+	st = binary.BigEndian.AppendUint32(st, uint32(16+12*len(groups)))
+	st = binary.BigEndian.AppendUint32(st, 0) // language
+	st = binary.BigEndian.AppendUint32(st, uint32(len(groups)))
+	for _, g := range groups {
+		st = binary.BigEndian.AppendUint32(st, g[0])
+		st = binary.BigEndian.AppendUint32(st, g[1])
+		st = binary.BigEndian.AppendUint32(st, g[2])
+	}
+	return st
+}
+
+func TestCmapFormat12(t *testing.T) {
+	fullRange := [3]uint32{0, unicode.MaxRune, 1}
+	tests := []struct {
+		name   string
+		groups [][3]uint32
+		want   int
+	}{
+		{"ASCII letters", [][3]uint32{{'A', 'Z', 1}, {'a', 'z', 27}}, 52},
+		{"starts with .notdef", [][3]uint32{{'A', 'Z', 0}}, 25},
+		{"end beyond Unicode is clamped", [][3]uint32{{unicode.MaxRune - 9, 0xFFFFFFFF, 1}}, 10},
+		{"start beyond Unicode", [][3]uint32{{0xFFFFFFF0, 0xFFFFFFFF, 1}, {0x80000000, 0x80000001, 1}}, 0},
+		{"end before start", [][3]uint32{{'Z', 'A', 1}}, 0},
+		{"overlapping full ranges", [][3]uint32{fullRange, fullRange, fullRange, fullRange}, maxCmapRunes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runes := cmapFormat12(cmapFormat12Table(tt.groups...))
+			if len(runes) != tt.want {
+				t.Errorf("cmapFormat12() returned %d characters, want %d", len(runes), tt.want)
+			}
+			for _, r := range runes {
+				if r < 0 || r > unicode.MaxRune {
+					t.Fatalf("cmapFormat12() returned %U, which is no code point", r)
+				}
+			}
+		})
+	}
+}
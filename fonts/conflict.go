@@ -0,0 +1,80 @@
+package fonts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// ConflictPolicy is what an install does when the font dir already has a different file with the same
+// name, typically an older or newer version of the font.
+type ConflictPolicy string
+
+const (
+	ConflictFail      ConflictPolicy = "fail"      // return ErrFileExistsAndIsDifferent
+	ConflictSkip      ConflictPolicy = "skip"      // keep the existing file and do nothing
+	ConflictOverwrite ConflictPolicy = "overwrite" // replace the existing file
+	ConflictNewer     ConflictPolicy = "newer"     // replace the existing file if the new one has a higher version
+)
+
+// ParseConflictPolicy parses the name of a ConflictPolicy. An empty string is ConflictFail.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case "":
+		return ConflictFail, nil
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictNewer:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid conflict policy '%s' (must be fail, skip, overwrite or newer)", s)
+}
+
+// ResolveConflict decides, according to policy, whether the existing file dst gets replaced by src. It
+// returns false without error if dst should be kept, and an error wrapping ErrFileExistsAndIsDifferent
+// for ConflictFail or if ConflictNewer can't compare the versions. log can be nil.
+func ResolveConflict(policy ConflictPolicy, src, dst string, log *slog.Logger) (bool, error) {
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	conflictErr := fmt.Errorf("%w: '%s'", ErrFileExistsAndIsDifferent, dst)
+	switch policy {
+	case ConflictSkip:
+		log.Info("a different file already exists, skipping", "source", src, "dest", dst)
+		return false, nil
+	case ConflictOverwrite:
+		log.Debug("a different file already exists, overwriting", "source", src, "dest", dst)
+		return true, nil
+	case ConflictNewer:
+		newer, err := IsNewerFont(src, dst)
+		if err != nil {
+			return false, fmt.Errorf("%w (can't compare font versions: %v)", conflictErr, err)
+		}
+		if !newer {
+			log.Info("existing font has the same or a higher version, skipping", "source", src, "dest", dst)
+			return false, nil
+		}
+		log.Debug("font has a higher version than the existing one, replacing it", "source", src, "dest", dst)
+		return true, nil
+	}
+	return false, conflictErr
+}
+
+// IsNewerFont reports whether the TrueType/OpenType font src has a higher version than dst. Collections
+// are compared by their first font. Other formats have no comparable version.
+func IsNewerFont(src, dst string) (bool, error) {
+	if IsType1Path(src) {
+		return false, errors.New("Type 1 fonts have no version")
+	}
+	srcFonts, err := ParseSFNT(src)
+	if err != nil {
+		return false, err
+	}
+	dstFonts, err := ParseSFNT(dst)
+	if err != nil {
+		return false, err
+	}
+	if len(srcFonts) == 0 || len(dstFonts) == 0 {
+		return false, errors.New("font collection without fonts")
+	}
+	return CompareVersions(srcFonts[0], dstFonts[0]) > 0, nil
+}
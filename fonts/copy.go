@@ -0,0 +1,76 @@
+package fonts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HashFile returns the SHA-256 hash of a file.
+func HashFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+// FormatHash returns a file hash from HashFile in the "sha256:<hex>" notation used on the command line.
+func FormatHash(hash []byte) string {
+	return "sha256:" + hex.EncodeToString(hash)
+}
+
+// ParseHash parses a hash in "sha256:<hex>" notation. The "sha256:" prefix is optional.
+func ParseHash(s string) ([]byte, error) {
+	hexHash := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "sha256:")
+	hash, err := hex.DecodeString(hexHash)
+	if err != nil || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("invalid hash '%s' (expected sha256:<64 hex digits>)", s)
+	}
+	return hash, nil
+}
+
+// CopyFile copies src into dstDir. If a file with the same name and content already exists in dstDir,
+// nothing is copied and copied is false. If it exists with different content, ErrFileExistsAndIsDifferent
+// is returned unless overwrite is set.
+func CopyFile(src, dstDir string, overwrite bool) (copied bool, err error) {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return false, fmt.Errorf("failed to open source file '%s' (%w)", src, WithAccessDenied(err))
+	}
+	defer srcFile.Close()
+	dstPath := filepath.Join(dstDir, filepath.Base(src))
+	if _, err := os.Stat(dstPath); err == nil {
+		srcHash, err1 := HashFile(src)
+		dstHash, err2 := HashFile(dstPath)
+		// If both hashes match, the files are identical
+		if err1 == nil && err2 == nil && string(srcHash) == string(dstHash) {
+			return false, nil
+		} else if !overwrite {
+			return false, fmt.Errorf("%w: '%s'", ErrFileExistsAndIsDifferent, dstPath)
+		}
+	}
+
+	// Open the destination file properly, avoiding truncation issues.
+	dstFile, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to create destination file '%s' (%w)", dstPath, WithAccessDenied(err))
+	}
+	defer func() {
+		dstFile.Sync() // Ensure data is flushed before closing
+		dstFile.Close()
+	}()
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return false, fmt.Errorf("failed to copy file '%s' to '%s' (%w)", src, dstPath, err)
+	}
+	return true, nil
+}
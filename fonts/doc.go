@@ -0,0 +1,3 @@
+// Package fonts implements the operating system independent parts of fontctl: detecting and parsing
+// font files, copying and hashing them, and the errors shared by all fontctl packages.
+package fonts
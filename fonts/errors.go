@@ -0,0 +1,28 @@
+package fonts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Errors returned by fontctl operations. Use errors.Is to check for them, they are usually wrapped
+// with details about the file or registry key involved.
+var (
+	ErrFileNotFound             = errors.New("can't find or open file")
+	ErrNotAFont                 = errors.New("not a supported font file")
+	ErrNotInstalled             = errors.New("font is not installed")
+	ErrAccessDenied             = errors.New("access denied (this operation might require Admin privileges)")
+	ErrFileExistsAndIsDifferent = errors.New("destination file exists and is different")
+	ErrRegistry                 = errors.New("registry operation failed")
+	ErrGDI                      = errors.New("GDI operation failed")
+	ErrRebootRequired           = errors.New("file is in use and gets replaced on the next reboot")
+)
+
+// WithAccessDenied marks permission errors from the OS with ErrAccessDenied.
+func WithAccessDenied(err error) error {
+	if err != nil && errors.Is(err, fs.ErrPermission) && !errors.Is(err, ErrAccessDenied) {
+		return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package fonts
+
+import (
+	"slices"
+	"strings"
+)
+
+// Face identifies a single font in a font file by its names. A collection or a .fon file can contain
+// several faces.
+type Face struct {
+	Path           string   `json:"path"`  // font file, the .pfm file for Type 1 fonts
+	Index          int      `json:"index"` // index in a collection or .fon file
+	Format         Format   `json:"-"`
+	Family         string   `json:"family"`
+	Subfamily      string   `json:"subfamily,omitempty"`
+	FullName       string   `json:"full_name,omitempty"`
+	PostScriptName string   `json:"postscript_name,omitempty"`
+	Families       []string `json:"families,omitempty"` // all family names: typographic, legacy and localized
+	Version        string   `json:"version,omitempty"`
+	FontRevision   float64  `json:"font_revision,omitempty"` // head.fontRevision
+	Weight         int      `json:"weight"`                  // 100 (thin) to 900 (black), 400 is regular and 700 bold
+	Italic         bool     `json:"italic"`
+	// GDIFamilies are the legacy family names (name ID 1, all languages), which GDI knows the face by.
+	// Typographic families group more styles than GDI, i.e. "Segoe UI Semibold" is a family of its own.
+	GDIFamilies []string `json:"gdi_families,omitempty"`
+	Charsets    []int    `json:"charsets,omitempty"` // Windows character sets, see CharsetANSI
+	// Coverage is only set by ReadFacesWithCoverage, and only for TrueType/OpenType fonts.
+	Coverage *Coverage `json:"coverage,omitempty"`
+}
+
+// ReadFaces returns the faces in a font file of any supported format.
+func ReadFaces(fontPath string) ([]Face, error) {
+	return readFaces(fontPath, false)
+}
+
+// ReadFacesWithCoverage is ReadFaces, but also reads the character coverage of TrueType/OpenType fonts.
+func ReadFacesWithCoverage(fontPath string) ([]Face, error) {
+	return readFaces(fontPath, true)
+}
+
+func readFaces(fontPath string, withCoverage bool) ([]Face, error) {
+	format, err := DetectFormat(fontPath)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatType1:
+		pfm, pfb, err := ResolveType1Files(fontPath)
+		if err != nil {
+			return nil, err
+		}
+		t1, err := ParseType1(pfm, pfb)
+		if err != nil {
+			return nil, err
+		}
+		return []Face{{
+			Path:           pfm,
+			Format:         format,
+			Family:         t1.Face,
+			FullName:       t1.RegistryName(),
+			PostScriptName: t1.PostScriptName,
+			Families:       []string{t1.Face},
+			GDIFamilies:    []string{t1.Face},
+			Charsets:       []int{t1.CharSet},
+			Weight:         t1.Weight,
+			Italic:         t1.Italic,
+		}}, nil
+	case FormatBitmap, FormatRawBitmap:
+		var resources []FntInfo
+		if format == FormatBitmap {
+			info, err := ParseFon(fontPath)
+			if err != nil {
+				return nil, err
+			}
+			resources = info.Resources
+		} else {
+			info, err := ParseFnt(fontPath)
+			if err != nil {
+				return nil, err
+			}
+			resources = []FntInfo{info}
+		}
+		// the sizes of a .fon file are all the same face
+		var faces []Face
+		for i, r := range resources {
+			if slices.ContainsFunc(faces, func(f Face) bool { return f.Family == r.Face }) {
+				continue
+			}
+			faces = append(faces, Face{
+				Path:        fontPath,
+				Index:       i,
+				Format:      format,
+				Family:      r.Face,
+				FullName:    r.Face,
+				Families:    []string{r.Face},
+				GDIFamilies: []string{r.Face},
+				Charsets:    []int{r.CharSet},
+				Weight:      r.Weight,
+				Italic:      r.Italic,
+			})
+		}
+		return faces, nil
+	}
+
+	parse := ParseSFNT
+	if withCoverage {
+		parse = ParseSFNTWithCoverage
+	}
+	sfnts, err := parse(fontPath)
+	if err != nil {
+		return nil, err
+	}
+	faces := make([]Face, 0, len(sfnts))
+	for _, f := range sfnts {
+		face := Face{
+			Path:           fontPath,
+			Index:          f.Index,
+			Format:         f.Format,
+			Family:         f.Name(NameTypographicFamily),
+			Subfamily:      f.Name(NameTypographicSubfamily),
+			FullName:       f.Name(NameFull),
+			PostScriptName: f.Name(NamePostScript),
+			Version:        f.Version(),
+			FontRevision:   f.FontRevision,
+			GDIFamilies:    f.AllNames(NameFamily),
+			Charsets:       charsetsFromCodePages(f.CodePages),
+			Weight:         f.Weight,
+			Italic:         f.Italic,
+		}
+		if withCoverage {
+			face.Coverage = &f.Coverage
+		}
+		if face.Family == "" {
+			face.Family = f.Name(NameFamily)
+		}
+		if face.Subfamily == "" {
+			face.Subfamily = f.Name(NameSubfamily)
+		}
+		for _, n := range append(f.AllNames(NameTypographicFamily), f.AllNames(NameFamily)...) {
+			if !slices.ContainsFunc(face.Families, func(s string) bool { return strings.EqualFold(s, n) }) {
+				face.Families = append(face.Families, n)
+			}
+		}
+		faces = append(faces, face)
+	}
+	return faces, nil
+}
+
+// Matches reports whether the face is known by name: one of its family names, its full name or its
+// PostScript name. Names are compared case-insensitively.
+func (f Face) Matches(name string) bool {
+	if strings.EqualFold(f.FullName, name) || strings.EqualFold(f.PostScriptName, name) {
+		return true
+	}
+	return slices.ContainsFunc(f.Families, func(s string) bool { return strings.EqualFold(s, name) })
+}
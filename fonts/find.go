@@ -0,0 +1,71 @@
+package fonts
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fontExtensions are the file extensions FindFontFiles looks at. .pfb files are left out, Type 1 fonts
+// are found through their .pfm file.
+var fontExtensions = map[string]bool{
+	".ttf": true,
+	".ttc": true,
+	".otf": true,
+	".otc": true,
+	".fon": true,
+	".fnt": true,
+	".pfm": true,
+}
+
+// HasFontExtension reports whether the file has the extension of a font file FindFontFiles looks at.
+func HasFontExtension(path string) bool {
+	return fontExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// FindFontFiles returns the font files in dir and its subdirs, ordered by path. Files are picked by
+// extension and then checked with DetectFormat, so i.e. a .fon file that isn't a font is skipped.
+func FindFontFiles(dir string) ([]string, error) {
+	var list []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !HasFontExtension(path) {
+			return nil
+		}
+		if IsType1Path(path) {
+			if _, _, err := ResolveType1Files(path); err != nil {
+				return nil // .pfm without .pfb
+			}
+		} else if _, err := DetectFormat(path); err != nil {
+			return nil
+		}
+		list = append(list, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, dir, WithAccessDenied(err))
+	}
+	return list, nil
+}
+
+// ExpandFontPaths replaces the directories in paths with the font files in them (see FindFontFiles).
+// Files are returned as they are, so they can also be Type 1 "<pfm>|<pfb>" pairs.
+func ExpandFontPaths(paths []string) ([]string, error) {
+	var list []string
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			files, err := FindFontFiles(p)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, files...)
+			continue
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
@@ -0,0 +1,199 @@
+package fonts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// offsets into the Windows FONTINFO header that is shared by .fnt resources and .pfm files
+const (
+	fntOffsetVersion = 0
+	fntOffsetType    = 66
+	fntOffsetPoints  = 68
+	fntOffsetVertRes = 70
+	fntOffsetItalic  = 80
+	fntOffsetWeight  = 83
+	fntOffsetCharSet = 85
+	fntOffsetFace    = 105
+	fntHeaderSize    = 117
+
+	neResourceTypeFont = 0x8008 // RT_FONT | 0x8000 (integer type id)
+	maxAlignShift      = 16     // resources are aligned to at most 64 KiB, larger shifts are corrupt
+)
+
+// FntInfo holds the fields of a Windows font resource header that are needed to name the font.
+type FntInfo struct {
+	Face    string
+	Points  int
+	VertRes int
+	Weight  int
+	Italic  bool
+	Vector  bool
+	CharSet int
+}
+
+// BitmapFontInfo describes a .fon file, which bundles one or more font resources.
+type BitmapFontInfo struct {
+	Description string // module description, i.e. "FONTRES 100,96,96 : Courier 10,12,15"
+	Resources   []FntInfo
+}
+
+func parseFntHeader(data []byte) (FntInfo, error) {
+	var info FntInfo
+	if len(data) < fntHeaderSize {
+		return info, fmt.Errorf("font resource header too short (%d bytes): %w", len(data), ErrNotAFont)
+	}
+	version := binary.LittleEndian.Uint16(data[fntOffsetVersion:])
+	if version != 0x0100 && version != 0x0200 && version != 0x0300 {
+		return info, fmt.Errorf("unsupported font resource version 0x%04x: %w", version, ErrNotAFont)
+	}
+	info.Vector = binary.LittleEndian.Uint16(data[fntOffsetType:])&0x0001 != 0
+	info.Points = int(binary.LittleEndian.Uint16(data[fntOffsetPoints:]))
+	info.VertRes = int(binary.LittleEndian.Uint16(data[fntOffsetVertRes:]))
+	info.Italic = data[fntOffsetItalic] != 0
+	info.Weight = int(binary.LittleEndian.Uint16(data[fntOffsetWeight:]))
+	info.CharSet = int(data[fntOffsetCharSet])
+	faceOffset := binary.LittleEndian.Uint32(data[fntOffsetFace:])
+	if faceOffset != 0 && int(faceOffset) < len(data) {
+		info.Face = cString(data[faceOffset:])
+	}
+	return info, nil
+}
+
+// ParseFnt parses a single raw Windows font resource (.fnt).
+func ParseFnt(fontPath string) (FntInfo, error) {
+	data, err := os.ReadFile(fontPath)
+	if err != nil {
+		return FntInfo{}, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, fontPath, WithAccessDenied(err))
+	}
+	return parseFntHeader(data)
+}
+
+// ParseFon parses a 16-bit NE font resource library (.fon) and returns the headers of all font resources in it.
+func ParseFon(fontPath string) (BitmapFontInfo, error) {
+	var info BitmapFontInfo
+	data, err := os.ReadFile(fontPath)
+	if err != nil {
+		return info, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, fontPath, WithAccessDenied(err))
+	}
+	if len(data) < 0x40 || string(data[:2]) != "MZ" {
+		return info, fmt.Errorf("file '%s' is not a .fon font resource file: %w", fontPath, ErrNotAFont)
+	}
+	ne := int(binary.LittleEndian.Uint32(data[0x3C:]))
+	if ne+0x40 > len(data) {
+		return info, fmt.Errorf("file '%s' has a truncated executable header: %w", fontPath, ErrNotAFont)
+	}
+	if string(data[ne:ne+2]) != "NE" {
+		return info, fmt.Errorf("file '%s' is not a 16-bit NE font resource file (signature '%s'): %w", fontPath, strings.TrimRight(string(data[ne:ne+2]), "\x00"), ErrNotAFont)
+	}
+
+	// the first entry of the non-resident name table is the module description
+	nonResTab := int(binary.LittleEndian.Uint32(data[ne+0x2C:]))
+	if nonResTab > 0 && nonResTab < len(data) {
+		n := int(data[nonResTab])
+		if nonResTab+1+n <= len(data) {
+			info.Description = string(data[nonResTab+1 : nonResTab+1+n])
+		}
+	}
+
+	pos := ne + int(binary.LittleEndian.Uint16(data[ne+0x24:]))
+	if pos+2 > len(data) {
+		return info, fmt.Errorf("file '%s' has a truncated resource table: %w", fontPath, ErrNotAFont)
+	}
+	alignShift := binary.LittleEndian.Uint16(data[pos:])
+	if alignShift > maxAlignShift {
+		return info, fmt.Errorf("file '%s' has an invalid resource alignment shift %d: %w", fontPath, alignShift, ErrNotAFont)
+	}
+	pos += 2
+	for pos+8 <= len(data) {
+		typeID := binary.LittleEndian.Uint16(data[pos:])
+		if typeID == 0 {
+			break
+		}
+		count := int(binary.LittleEndian.Uint16(data[pos+2:]))
+		pos += 8
+		for i := 0; i < count && pos+12 <= len(data); i++ {
+			if typeID == neResourceTypeFont {
+				offset := int(binary.LittleEndian.Uint16(data[pos:])) << alignShift
+				length := int(binary.LittleEndian.Uint16(data[pos+2:])) << alignShift
+				if offset+length > len(data) {
+					return info, fmt.Errorf("file '%s' has a font resource outside of the file: %w", fontPath, ErrNotAFont)
+				}
+				fnt, err := parseFntHeader(data[offset : offset+length])
+				if err != nil {
+					return info, fmt.Errorf("file '%s': %w", fontPath, err)
+				}
+				info.Resources = append(info.Resources, fnt)
+			}
+			pos += 12
+		}
+	}
+
+	if len(info.Resources) == 0 {
+		return info, fmt.Errorf("file '%s' does not contain any font resources: %w", fontPath, ErrNotAFont)
+	}
+	return info, nil
+}
+
+// RegistryName returns the name Windows uses for the font in the Fonts registry key,
+// i.e. "Courier 10,12,15" for raster fonts or "Modern (All res)" for vector fonts.
+func (b BitmapFontInfo) RegistryName() string {
+	vector := false
+	for _, r := range b.Resources {
+		if r.Vector {
+			vector = true
+		}
+	}
+
+	// prefer the name the font vendor put into the module description, that's what the Windows font installer uses
+	name := ""
+	if _, after, found := strings.Cut(b.Description, ":"); found && strings.HasPrefix(strings.ToUpper(b.Description), "FONTRES") {
+		name = strings.TrimSpace(after)
+	}
+	if name == "" {
+		name = bitmapFontName(b.Resources)
+	}
+
+	if vector && !strings.HasSuffix(name, ")") {
+		name += " (All res)"
+	}
+	return name
+}
+
+// bitmapFontName builds a "<Face> <size>,<size>,..." name from the resource headers.
+func bitmapFontName(resources []FntInfo) string {
+	if len(resources) == 0 {
+		return ""
+	}
+	face := resources[0].Face
+	if resources[0].Vector {
+		return face
+	}
+	seen := map[int]bool{}
+	var points []int
+	for _, r := range resources {
+		if !seen[r.Points] {
+			seen[r.Points] = true
+			points = append(points, r.Points)
+		}
+	}
+	sort.Ints(points)
+	sizes := make([]string, len(points))
+	for i, p := range points {
+		sizes[i] = strconv.Itoa(p)
+	}
+	return face + " " + strings.Join(sizes, ",")
+}
+
+// cString returns the string up to the first NUL byte.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
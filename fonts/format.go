@@ -0,0 +1,91 @@
+package fonts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the container format of a font file, which decides how
+// Windows registers the font.
+type Format int
+
+const (
+	FormatUnknown            Format = iota
+	FormatTrueType                  // sfnt with TrueType outlines (.ttf)
+	FormatOpenType                  // sfnt with CFF outlines (.otf)
+	FormatTrueTypeCollection        // sfnt collection (.ttc, .otc)
+	FormatBitmap                    // NE font resource file (.fon)
+	FormatRawBitmap                 // single Windows font resource (.fnt)
+	FormatType1                     // Adobe Type 1 (.pfm + .pfb)
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatTrueType:
+		return "TrueType"
+	case FormatOpenType:
+		return "OpenType"
+	case FormatTrueTypeCollection:
+		return "TrueType Collection"
+	case FormatBitmap:
+		return "Bitmap"
+	case FormatRawBitmap:
+		return "Raw Bitmap"
+	case FormatType1:
+		return "Type 1"
+	}
+	return "unknown"
+}
+
+// DetectFormat identifies the font format by looking at the magic bytes of the file.
+// Type 1 fonts can be passed as .pfm, .pfb or as a "<pfm>|<pfb>" pair.
+func DetectFormat(fontPath string) (Format, error) {
+	if IsType1Path(fontPath) {
+		return FormatType1, nil
+	}
+
+	f, err := os.Open(fontPath)
+	if err != nil {
+		return FormatUnknown, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, fontPath, WithAccessDenied(err))
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return FormatUnknown, fmt.Errorf("file '%s' is too short to be a font (%w)", fontPath, ErrNotAFont)
+	}
+
+	switch {
+	case bytes.Equal(magic, []byte{0x00, 0x01, 0x00, 0x00}), bytes.Equal(magic, []byte("true")):
+		return FormatTrueType, nil
+	case bytes.Equal(magic, []byte("OTTO")):
+		return FormatOpenType, nil
+	case bytes.Equal(magic, []byte("ttcf")):
+		return FormatTrueTypeCollection, nil
+	case bytes.Equal(magic[:2], []byte("MZ")):
+		return FormatBitmap, nil
+	}
+
+	if version := binary.LittleEndian.Uint16(magic); version == 0x0200 || version == 0x0300 {
+		return FormatRawBitmap, nil
+	}
+
+	return FormatUnknown, fmt.Errorf("file '%s' is %w", fontPath, ErrNotAFont)
+}
+
+// IsType1Path reports whether the path refers to one half of a Type 1 font or an explicit "<pfm>|<pfb>" pair.
+func IsType1Path(fontPath string) bool {
+	if strings.Contains(fontPath, "|") {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(fontPath)) {
+	case ".pfm", ".pfb":
+		return true
+	}
+	return false
+}
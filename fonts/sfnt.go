@@ -0,0 +1,331 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// Name IDs of the OpenType name table.
+const (
+	NameCopyright            = 0
+	NameFamily               = 1
+	NameSubfamily            = 2
+	NameUniqueID             = 3
+	NameFull                 = 4
+	NameVersion              = 5
+	NamePostScript           = 6
+	NameTrademark            = 7
+	NameManufacturer         = 8
+	NameDesigner             = 9
+	NameDescription          = 10
+	NameVendorURL            = 11
+	NameDesignerURL          = 12
+	NameLicense              = 13
+	NameLicenseURL           = 14
+	NameTypographicFamily    = 16
+	NameTypographicSubfamily = 17
+)
+
+const (
+	platformUnicode   = 0
+	platformMac       = 1
+	platformWindows   = 3
+	langWindowsEnUS   = 0x0409
+	langMacEnglish    = 0
+	encodingMacRoman  = 0
+	maxCollectionSize = 1024
+	maxTableSize      = 64 << 20 // the tables we read are small, this only protects against broken fonts
+)
+
+// NameRecord is a decoded entry of the name table.
+type NameRecord struct {
+	PlatformID uint16
+	EncodingID uint16
+	LanguageID uint16
+	NameID     uint16
+	Value      string
+}
+
+// SFNT is a single TrueType or OpenType font, possibly one of several in a collection.
+type SFNT struct {
+	Format       Format
+	Index        int     // index in the collection, 0 for single fonts
+	FontRevision float64 // head.fontRevision
+	Names        []NameRecord
+	Weight       int      // OS/2.usWeightClass, 400 if the font has no OS/2 table
+	Italic       bool     // OS/2.fsSelection or head.macStyle italic bit
+	CodePages    uint64   // OS/2.ulCodePageRange1 and 2 (high bits), 0 if the font doesn't have them
+	FsType       uint16   // OS/2.fsType, the embedding permissions, see EmbeddingPermissions
+	VendorID     string   // OS/2.achVendID, the registered tag of the font vendor, i.e. "MS  " trimmed to "MS"
+	Coverage     Coverage // only read by ParseSFNTWithCoverage
+}
+
+// sfntTables are the tables ParseSFNT reads, the others are skipped.
+var sfntTables = []string{"head", "name", "OS/2"}
+
+// ParseSFNT parses a TrueType/OpenType font or collection and returns all fonts in it. Only the tables
+// it needs are read, so this is cheap even for large fonts.
+func ParseSFNT(fontPath string) ([]SFNT, error) {
+	return parseSFNTFile(fontPath, sfntTables)
+}
+
+// ParseSFNTWithCoverage is ParseSFNT, but also reads the cmap table for the Coverage of the fonts.
+func ParseSFNTWithCoverage(fontPath string) ([]SFNT, error) {
+	return parseSFNTFile(fontPath, append(slices.Clip(sfntTables), "cmap"))
+}
+
+func parseSFNTFile(fontPath string, tables []string) ([]SFNT, error) {
+	f, err := os.Open(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, fontPath, WithAccessDenied(err))
+	}
+	defer f.Close()
+	list, err := parseSFNTData(f, tables)
+	if err != nil {
+		return nil, fmt.Errorf("file '%s': %w", fontPath, err)
+	}
+	return list, nil
+}
+
+func parseSFNTData(r io.ReaderAt, tables []string) ([]SFNT, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("font too short: %w", ErrNotAFont)
+	}
+	if string(header[:4]) != "ttcf" {
+		font, err := parseSFNTAt(r, 0, tables)
+		if err != nil {
+			return nil, err
+		}
+		return []SFNT{font}, nil
+	}
+
+	numFonts := int(binary.BigEndian.Uint32(header[8:]))
+	if numFonts == 0 || numFonts > maxCollectionSize {
+		return nil, fmt.Errorf("invalid font collection header: %w", ErrNotAFont)
+	}
+	offsets := make([]byte, 4*numFonts)
+	if _, err := r.ReadAt(offsets, 12); err != nil {
+		return nil, fmt.Errorf("invalid font collection header: %w", ErrNotAFont)
+	}
+	list := make([]SFNT, 0, numFonts)
+	for i := 0; i < numFonts; i++ {
+		font, err := parseSFNTAt(r, int64(binary.BigEndian.Uint32(offsets[4*i:])), tables)
+		if err != nil {
+			return nil, fmt.Errorf("font %d in collection: %w", i, err)
+		}
+		font.Format = FormatTrueTypeCollection
+		font.Index = i
+		list = append(list, font)
+	}
+	return list, nil
+}
+
+// parseSFNTAt parses the table directory at offset and the tables we are interested in.
+func parseSFNTAt(r io.ReaderAt, offset int64, wanted []string) (SFNT, error) {
+	font := SFNT{Weight: 400}
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return font, fmt.Errorf("table directory out of range: %w", ErrNotAFont)
+	}
+	switch string(header[:4]) {
+	case "\x00\x01\x00\x00", "true":
+		font.Format = FormatTrueType
+	case "OTTO":
+		font.Format = FormatOpenType
+	default:
+		return font, fmt.Errorf("unknown sfnt version: %w", ErrNotAFont)
+	}
+
+	tables, err := readTables(r, offset, int(binary.BigEndian.Uint16(header[4:])), wanted)
+	if err != nil {
+		return font, err
+	}
+	if head, ok := tables["head"]; ok && len(head) >= 8 {
+		font.FontRevision = float64(int32(binary.BigEndian.Uint32(head[4:]))) / 65536
+	}
+	if head, ok := tables["head"]; ok && len(head) >= 46 {
+		font.Italic = binary.BigEndian.Uint16(head[44:])&0x02 != 0
+	}
+	if os2, ok := tables["OS/2"]; ok && len(os2) >= 64 {
+		if w := int(binary.BigEndian.Uint16(os2[4:])); w > 0 {
+			font.Weight = w
+		}
+		font.FsType = binary.BigEndian.Uint16(os2[8:])
+		if id := strings.TrimRight(string(os2[58:62]), " \x00"); isPrintableASCII(id) {
+			font.VendorID = id
+		}
+		font.Italic = binary.BigEndian.Uint16(os2[62:])&0x01 != 0
+		if version := binary.BigEndian.Uint16(os2); version >= 1 && len(os2) >= 86 {
+			font.CodePages = uint64(binary.BigEndian.Uint32(os2[78:])) | uint64(binary.BigEndian.Uint32(os2[82:]))<<32
+		}
+	}
+	if cmap, ok := tables["cmap"]; ok {
+		font.Coverage = parseCmap(cmap)
+	}
+	if name, ok := tables["name"]; ok {
+		font.Names = parseNameTable(name)
+	}
+	return font, nil
+}
+
+// readTables reads the table directory of the font at offset and returns the data of the wanted tables
+// by tag. Tables the font doesn't have are missing from the map.
+func readTables(r io.ReaderAt, offset int64, numTables int, wanted []string) (map[string][]byte, error) {
+	dir := make([]byte, 16*numTables)
+	if _, err := r.ReadAt(dir, offset+12); err != nil {
+		return nil, fmt.Errorf("truncated table directory: %w", ErrNotAFont)
+	}
+	tables := make(map[string][]byte, len(wanted))
+	for i := 0; i < numTables; i++ {
+		rec := dir[16*i:]
+		tag := string(rec[:4])
+		if !slices.Contains(wanted, tag) {
+			continue
+		}
+		tOffset := int64(binary.BigEndian.Uint32(rec[8:]))
+		tLength := int(binary.BigEndian.Uint32(rec[12:]))
+		if tLength > maxTableSize {
+			return nil, fmt.Errorf("table '%s' too large: %w", tag, ErrNotAFont)
+		}
+		data := make([]byte, tLength)
+		n, err := r.ReadAt(data, tOffset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("can't read table '%s' (%w)", tag, err)
+		}
+		// some fonts in the wild have slightly off table lengths, clamp instead of failing
+		tables[tag] = data[:n]
+	}
+	return tables, nil
+}
+
+func parseNameTable(table []byte) []NameRecord {
+	if len(table) < 6 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	storage := int(binary.BigEndian.Uint16(table[4:]))
+	var records []NameRecord
+	for i := 0; i < count && 6+12*(i+1) <= len(table); i++ {
+		rec := table[6+12*i:]
+		r := NameRecord{
+			PlatformID: binary.BigEndian.Uint16(rec[0:]),
+			EncodingID: binary.BigEndian.Uint16(rec[2:]),
+			LanguageID: binary.BigEndian.Uint16(rec[4:]),
+			NameID:     binary.BigEndian.Uint16(rec[6:]),
+		}
+		length := int(binary.BigEndian.Uint16(rec[8:]))
+		start := storage + int(binary.BigEndian.Uint16(rec[10:]))
+		if start+length > len(table) {
+			continue
+		}
+		raw := table[start : start+length]
+		switch {
+		case r.PlatformID == platformWindows || r.PlatformID == platformUnicode:
+			r.Value = decodeUTF16BE(raw)
+		case r.PlatformID == platformMac && r.EncodingID == encodingMacRoman:
+			r.Value = decodeMacRoman(raw)
+		default:
+			continue
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// Name returns the value of a name table entry, preferring Windows English (US), then any Windows language,
+// then Unicode and Mac English. Returns "" if the font doesn't have the name.
+func (f SFNT) Name(nameID uint16) string {
+	best, bestScore := "", 0
+	for _, r := range f.Names {
+		if r.NameID != nameID || r.Value == "" {
+			continue
+		}
+		score := 1
+		switch {
+		case r.PlatformID == platformWindows && r.LanguageID == langWindowsEnUS:
+			score = 4
+		case r.PlatformID == platformWindows:
+			score = 3
+		case r.PlatformID == platformUnicode || (r.PlatformID == platformMac && r.LanguageID == langMacEnglish):
+			score = 2
+		}
+		if score > bestScore {
+			best, bestScore = r.Value, score
+		}
+	}
+	return best
+}
+
+// AllNames returns all distinct values of a name table entry across platforms and languages,
+// i.e. the localized family names.
+func (f SFNT) AllNames(nameID uint16) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, r := range f.Names {
+		if r.NameID == nameID && r.Value != "" && !seen[r.Value] {
+			seen[r.Value] = true
+			names = append(names, r.Value)
+		}
+	}
+	return names
+}
+
+// Version returns the version string from the name table (name ID 5).
+func (f SFNT) Version() string {
+	return f.Name(NameVersion)
+}
+
+// EmbeddingPermissions describes the embedding permissions of OS/2.fsType: "installable", "restricted",
+// "preview & print" or "editable". Fonts that may be embedded only restricted are usually commercial.
+func EmbeddingPermissions(fsType uint16) string {
+	switch {
+	case fsType&0x0002 != 0 && fsType&0x000c == 0:
+		return "restricted"
+	case fsType&0x0004 != 0:
+		return "preview & print"
+	case fsType&0x0008 != 0:
+		return "editable"
+	}
+	return "installable"
+}
+
+// isPrintableASCII reports whether s only has printable ASCII characters, broken fonts have garbage in
+// their tags.
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeUTF16BE(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// macRomanHigh maps the bytes 0x80-0xFF of the Mac OS Roman encoding.
+var macRomanHigh = []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+
+func decodeMacRoman(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		if c < 0x80 {
+			r[i] = rune(c)
+		} else {
+			r[i] = macRomanHigh[c-0x80]
+		}
+	}
+	return string(r)
+}
@@ -0,0 +1,66 @@
+package fonts
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// StageFiles copies files into a new temp dir that only the current user can write to, and returns the
+// dir and the paths of the copies, which keep the file names. Installers check and install the copies,
+// so a source file that is replaced after the check can't get installed unchecked. The caller removes
+// the dir.
+func StageFiles(files ...string) (dir string, staged []string, err error) {
+	dir, err = os.MkdirTemp("", "fontctl-stage-")
+	if err != nil {
+		return "", nil, fmt.Errorf("can't create staging dir (%w)", WithAccessDenied(err))
+	}
+	for _, f := range files {
+		if _, err := CopyFile(f, dir, false); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, err
+		}
+		staged = append(staged, filepath.Join(dir, filepath.Base(f)))
+	}
+	return dir, staged, nil
+}
+
+// InstallFile copies a font file into a font dir with CopyFile and logs what happened. A different file
+// with the same name isn't overwritten, see ResolveConflict. log can be nil.
+func InstallFile(src, dstDir string, log *slog.Logger) error {
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	dstPath := filepath.Join(dstDir, filepath.Base(src))
+	copied, err := CopyFile(src, dstDir, false)
+	if err != nil {
+		log.Error("copy failed", "source", src, "dest", dstPath, "error", err)
+		return err
+	}
+	if !copied {
+		log.Debug("destination file is identical, skipping copy", "source", src, "dest", dstPath)
+	} else {
+		log.Debug("file copied", "source", src, "dest", dstPath)
+	}
+	return nil
+}
+
+// VerifyInstalledCopy checks that the installed file has the same content as the source file, before
+// an uninstall deletes it.
+func VerifyInstalledCopy(src, installed string) error {
+	dstHash, err := HashFile(installed)
+	if err != nil {
+		return fmt.Errorf("%w '%s' (%w)", ErrNotInstalled, installed, WithAccessDenied(err))
+	}
+	srcHash, err := HashFile(src)
+	if err != nil {
+		return fmt.Errorf("could not hash source file '%s' (%w), uninstall aborted", src, WithAccessDenied(err))
+	}
+	if !bytes.Equal(srcHash, dstHash) {
+		return fmt.Errorf("%w: '%s' is not identical to '%s', uninstall aborted", ErrFileExistsAndIsDifferent, installed, src)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package fonts
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	pfmOffsetDriverInfo = 139 // dfDriverInfo in the PFMEXTENSION, points to the PostScript name
+	pfmMinSize          = 143
+)
+
+// Type1Font is an Adobe Type 1 font, which consists of a metrics file (.pfm) and an outline file (.pfb).
+type Type1Font struct {
+	PFM            string
+	PFB            string
+	Face           string // Windows family name
+	PostScriptName string
+	Weight         int
+	Italic         bool
+	CharSet        int
+}
+
+// ResolveType1Files finds both files of a Type 1 font. The path can either be an explicit "<pfm>|<pfb>" pair
+// or one of the two files, in which case the other one is expected next to it with the same base name.
+func ResolveType1Files(fontPath string) (pfm, pfb string, err error) {
+	if before, after, found := strings.Cut(fontPath, "|"); found {
+		pfm, pfb = before, after
+	} else {
+		switch strings.ToLower(filepath.Ext(fontPath)) {
+		case ".pfm":
+			pfm = fontPath
+			pfb, err = findSiblingWithExt(fontPath, ".pfb")
+		case ".pfb":
+			pfb = fontPath
+			pfm, err = findSiblingWithExt(fontPath, ".pfm")
+		default:
+			return "", "", fmt.Errorf("file '%s' is not a Type 1 font file (.pfm/.pfb): %w", fontPath, ErrNotAFont)
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	for _, p := range []string{pfm, pfb} {
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			return "", "", fmt.Errorf("%w '%s'", ErrFileNotFound, p)
+		}
+	}
+	return pfm, pfb, nil
+}
+
+// findSiblingWithExt looks for a file with the same base name but a different extension, ignoring case.
+func findSiblingWithExt(path, ext string) (string, error) {
+	dir := filepath.Dir(path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: can't read dir '%s' to find matching %s file for '%s'", ErrFileNotFound, dir, ext, path)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if strings.EqualFold(name, base+ext) {
+			return filepath.Join(dir, name), nil
+		}
+	}
+	return "", fmt.Errorf("%w: can't find matching %s file for Type 1 font '%s'", ErrFileNotFound, ext, path)
+}
+
+// ParseType1 reads the font names from the .pfm file of a Type 1 font.
+func ParseType1(pfm, pfb string) (Type1Font, error) {
+	font := Type1Font{PFM: pfm, PFB: pfb}
+	data, err := os.ReadFile(pfm)
+	if err != nil {
+		return font, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, pfm, WithAccessDenied(err))
+	}
+	if len(data) < pfmMinSize {
+		return font, fmt.Errorf("file '%s' is too short to be a .pfm file: %w", pfm, ErrNotAFont)
+	}
+	header, err := parseFntHeader(data)
+	if err != nil {
+		return font, fmt.Errorf("file '%s' is not a valid .pfm file: %w", pfm, err)
+	}
+	if header.Face == "" {
+		return font, fmt.Errorf("file '%s' does not contain a font name: %w", pfm, ErrNotAFont)
+	}
+	font.Face = header.Face
+	font.Weight = header.Weight
+	font.Italic = header.Italic
+	font.CharSet = header.CharSet
+
+	if off := binary.LittleEndian.Uint32(data[pfmOffsetDriverInfo:]); off != 0 && int(off) < len(data) {
+		font.PostScriptName = cString(data[off:])
+	}
+
+	pfbData := make([]byte, 2)
+	if f, err := os.Open(pfb); err == nil {
+		_, err = f.Read(pfbData)
+		f.Close()
+		if err != nil || pfbData[0] != 0x80 {
+			return font, fmt.Errorf("file '%s' is not a valid .pfb file: %w", pfb, ErrNotAFont)
+		}
+	} else {
+		return font, fmt.Errorf("%w '%s' (%w)", ErrFileNotFound, pfb, WithAccessDenied(err))
+	}
+
+	return font, nil
+}
+
+// RegistryName returns the name Windows uses for the font in the Type 1 Fonts registry key, i.e. "Helvetica Bold Italic".
+func (t Type1Font) RegistryName() string {
+	name := t.Face
+	bold := t.Weight >= 600
+	switch {
+	case bold && t.Italic:
+		name += " Bold Italic"
+	case bold:
+		name += " Bold"
+	case t.Italic:
+		name += " Italic"
+	}
+	return name
+}
+
+// GDIPath returns the "<pfm>|<pfb>" notation that AddFontResource expects for Type 1 fonts.
+func (t Type1Font) GDIPath() string {
+	return t.PFM + "|" + t.PFB
+}
@@ -0,0 +1,65 @@
+package fonts
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var versionNumberRe = regexp.MustCompile(`\d+`)
+
+// CompareVersions compares the versions of two fonts and returns -1 if a is older than b, +1 if a is newer
+// and 0 if they can't be told apart. head.fontRevision is compared first, as it is what the font tools
+// bump. If it is equal, the numbers in the version strings (name ID 5, i.e. "Version 1.002;PS 001.002")
+// are compared one by one.
+func CompareVersions(a, b SFNT) int {
+	return compareVersions(a.FontRevision, b.FontRevision, a.Version(), b.Version())
+}
+
+// CompareFaceVersions is CompareVersions for faces. Faces without a version (Type 1 and bitmap fonts)
+// can't be told apart.
+func CompareFaceVersions(a, b Face) int {
+	return compareVersions(a.FontRevision, b.FontRevision, a.Version, b.Version)
+}
+
+func compareVersions(revA, revB float64, versionA, versionB string) int {
+	switch {
+	case revA < revB:
+		return -1
+	case revA > revB:
+		return 1
+	}
+	return compareVersionStrings(versionA, versionB)
+}
+
+// compareVersionStrings compares the numeric parts of two version strings. Leading zeros of the first
+// fractional part are significant ("1.010" is newer than "1.002", the OpenType convention of "1.05"
+// meaning 1.050), so that part is padded to the same length. All other parts are compared as integers
+// ("1.2.10" is newer than "1.2.9").
+func compareVersionStrings(a, b string) int {
+	pa := versionNumberRe.FindAllString(a, -1)
+	pb := versionNumberRe.FindAllString(b, -1)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		x, y := pa[i], pb[i]
+		if i == 1 {
+			// pad the first fractional part on the right: "1.1" vs "1.02" compares 10 vs 02
+			for len(x) < len(y) {
+				x += "0"
+			}
+			for len(y) < len(x) {
+				y += "0"
+			}
+		}
+		nx, errx := strconv.ParseUint(x, 10, 64)
+		ny, erry := strconv.ParseUint(y, 10, 64)
+		if errx != nil || erry != nil {
+			return 0
+		}
+		switch {
+		case nx < ny:
+			return -1
+		case nx > ny:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+package fonts
+
+import "testing"
+
+func TestCompareVersionStrings(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"Version 1.010", "Version 1.002", 1},
+		{"Version 1.1", "Version 1.02", 1},
+		{"Version 1.05", "Version 1.050", 0},
+		{"1.2.10", "1.2.9", 1},
+		{"1.2.9", "1.2.10", -1},
+		{"Version 2.000;PS 001.002", "Version 2.000;PS 001.001", 1},
+		{"Version 1.0", "Version 1.0", 0},
+		{"no version", "Version 1.0", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersionStrings(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersionStrings(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fontctl/fonts"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// batchSize is the number of parsed files written per transaction. Committed batches survive an
+// interrupted Build, so the next Build continues where it stopped.
+const batchSize = 200
+
+// BuildStats are the changes Build made to the index.
+type BuildStats struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	Failed    int `json:"failed"` // files that couldn't be parsed, they are in the index with their error
+}
+
+// Build indexes the font files in dir and its subdirs. Files that are already indexed with the same
+// size and modification time are skipped, files that are gone from dir are removed from the index.
+// Subdirs that can't be read are logged and skipped, their files stay in the index.
+func (ix *Index) Build(ctx context.Context, dir string) (BuildStats, error) {
+	var stats BuildStats
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return stats, err
+	}
+
+	known, err := ix.entriesIn(dir)
+	if err != nil {
+		return stats, err
+	}
+	type job struct {
+		entry  Entry
+		update bool
+	}
+	var jobs []job
+	seen := map[string]bool{}
+	unreadable := []string{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			ix.log.Warn("can't read dir, skipping it", "path", path, "error", err)
+			unreadable = append(unreadable, path+string(filepath.Separator))
+			return fs.SkipDir
+		}
+		if d.IsDir() || !fonts.HasFontExtension(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			ix.log.Warn("can't stat file, skipping it", "path", path, "error", err)
+			return nil
+		}
+		seen[path] = true
+		old, ok := known[path]
+		if ok && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+			stats.Unchanged++
+			return nil
+		}
+		jobs = append(jobs, job{entry: Entry{Path: path, Size: info.Size(), ModTime: info.ModTime()}, update: ok})
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, dir, fonts.WithAccessDenied(err))
+	}
+
+	var removed []string
+	for path := range known {
+		if !seen[path] && !hasAnyPrefix(path, unreadable) {
+			removed = append(removed, path)
+		}
+	}
+	if len(removed) > 0 {
+		err := ix.db.Update(func(tx *bolt.Tx) error {
+			for _, path := range removed {
+				if err := deleteEntry(tx, path); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, fmt.Errorf("can't update index (%w)", err)
+		}
+		stats.Removed = len(removed)
+		for _, path := range removed {
+			ix.log.Debug("removed from index", "path", path)
+		}
+	}
+
+	// parse in parallel, write from here in batches
+	todo := make(chan job)
+	done := make(chan job)
+	var wg sync.WaitGroup
+	for range ix.opts.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range todo {
+				j.entry = parseEntry(j.entry)
+				done <- j
+			}
+		}()
+	}
+	go func() {
+		defer close(todo)
+		for _, j := range jobs {
+			select {
+			case todo <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var batch []job
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := ix.db.Update(func(tx *bolt.Tx) error {
+			for _, j := range batch {
+				if err := putEntry(tx, j.entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("can't update index (%w)", err)
+		}
+		for _, j := range batch {
+			switch {
+			case j.entry.Error != "":
+				stats.Failed++
+				ix.log.Warn("can't parse font file", "path", j.entry.Path, "error", j.entry.Error)
+			case j.update:
+				stats.Updated++
+			default:
+				stats.Added++
+			}
+			ix.log.Debug("indexed", "path", j.entry.Path, "faces", len(j.entry.Faces))
+		}
+		batch = batch[:0]
+		return nil
+	}
+	var flushErr error
+	for j := range done {
+		if flushErr != nil {
+			continue // let the workers finish
+		}
+		batch = append(batch, j)
+		if len(batch) >= batchSize {
+			flushErr = flush()
+		}
+	}
+	if flushErr == nil {
+		flushErr = flush()
+	}
+	if flushErr != nil {
+		return stats, flushErr
+	}
+	return stats, ctx.Err()
+}
+
+// parseEntry hashes the file and reads its faces. Errors are recorded in the entry.
+func parseEntry(e Entry) Entry {
+	hash, err := fonts.HashFile(e.Path)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	e.Hash = fonts.FormatHash(hash)
+	faces, err := fonts.ReadFacesWithCoverage(e.Path)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	e.Faces = faces
+	return e
+}
+
+// entriesIn returns the indexed files in dir and its subdirs by path.
+func (ix *Index) entriesIn(dir string) (map[string]Entry, error) {
+	entries := map[string]Entry{}
+	prefix := []byte(strings.TrimSuffix(dir, string(filepath.Separator)) + string(filepath.Separator))
+	err := ix.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(filesBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			e, _, err := getEntry(tx, string(k))
+			if err != nil {
+				// a size that never matches gets the file parsed again
+				ix.log.Warn("invalid index entry", "path", string(k), "error", err)
+				e = Entry{Path: string(k), Size: -1}
+			}
+			entries[string(k)] = e
+		}
+		return nil
+	})
+	return entries, err
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// testFont returns a TrueType font with only an OS/2 and a name table.
+func testFont(family, subfamily string, weight int, italic bool) []byte {
+	os2 := make([]byte, 64)
+	binary.BigEndian.PutUint16(os2[4:], uint16(weight))
+	if italic {
+		binary.BigEndian.PutUint16(os2[62:], 0x01)
+	}
+
+	names := []struct {
+		id    uint16
+		value string
+	}{{1, family}, {2, subfamily}, {4, family + " " + subfamily}, {6, strings.ReplaceAll(family+"-"+subfamily, " ", "")}}
+	var storage []byte
+	name := binary.BigEndian.AppendUint16(nil, 0)
+	name = binary.BigEndian.AppendUint16(name, uint16(len(names)))
+	name = binary.BigEndian.AppendUint16(name, uint16(6+12*len(names)))
+	for _, n := range names {
+		var value []byte
+		for _, u := range utf16.Encode([]rune(n.value)) {
+			value = binary.BigEndian.AppendUint16(value, u)
+		}
+		name = binary.BigEndian.AppendUint16(name, 3)      // Windows
+		name = binary.BigEndian.AppendUint16(name, 1)      // Unicode BMP
+		name = binary.BigEndian.AppendUint16(name, 0x0409) // English (US)
+		name = binary.BigEndian.AppendUint16(name, n.id)
+		name = binary.BigEndian.AppendUint16(name, uint16(len(value)))
+		name = binary.BigEndian.AppendUint16(name, uint16(len(storage)))
+		storage = append(storage, value...)
+	}
+	name = append(name, storage...)
+
+	data := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	data = binary.BigEndian.AppendUint16(data, 2) // numTables
+	data = append(data, make([]byte, 6)...)
+	offset := uint32(12 + 2*16)
+	for _, table := range []struct {
+		tag  string
+		data []byte
+	}{{"OS/2", os2}, {"name", name}} {
+		data = append(data, table.tag...)
+		data = binary.BigEndian.AppendUint32(data, 0)
+		data = binary.BigEndian.AppendUint32(data, offset)
+		data = binary.BigEndian.AppendUint32(data, uint32(len(table.data)))
+		offset += uint32(len(table.data))
+	}
+	data = append(data, os2...)
+	return append(data, name...)
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func openTestIndex(t *testing.T) *Index {
+	t.Helper()
+	ix, err := Open(filepath.Join(t.TempDir(), "index.db"), Options{Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ix.Close() })
+	return ix
+}
+
+func TestBuildIncremental(t *testing.T) {
+	ix := openTestIndex(t)
+	dir := t.TempDir()
+	a, b, broken := filepath.Join(dir, "A.ttf"), filepath.Join(dir, "sub", "B.otf"), filepath.Join(dir, "broken.ttf")
+	writeTestFile(t, a, testFont("A", "Regular", 400, false))
+	writeTestFile(t, b, testFont("B", "Bold", 700, false))
+	writeTestFile(t, broken, []byte("not a font"))
+	writeTestFile(t, filepath.Join(dir, "readme.txt"), []byte("not indexed"))
+
+	ctx := context.Background()
+	build := func(want BuildStats) {
+		t.Helper()
+		stats, err := ix.Build(ctx, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats != want {
+			t.Errorf("Build() = %+v, want %+v", stats, want)
+		}
+	}
+	build(BuildStats{Added: 2, Failed: 1})
+	if n, _ := ix.Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3 with the broken file", n)
+	}
+	build(BuildStats{Unchanged: 3})
+
+	// a file with the same size and modification time isn't parsed again
+	old, _, err := ix.Entry(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, a, testFont("C", "Regular", 400, false))
+	if err := os.Chtimes(a, time.Time{}, old.ModTime); err != nil {
+		t.Fatal(err)
+	}
+	build(BuildStats{Unchanged: 3})
+	if e, _, _ := ix.Entry(a); e.Hash != old.Hash {
+		t.Errorf("Build() parsed the unchanged file again, hash %s, want %s", e.Hash, old.Hash)
+	}
+
+	// a newer file is parsed again, a deleted one removed
+	if err := os.Chtimes(a, time.Time{}, old.ModTime.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	build(BuildStats{Updated: 1, Unchanged: 1, Removed: 1})
+	e, ok, err := ix.Entry(a)
+	if err != nil || !ok || len(e.Faces) != 1 || e.Faces[0].Family != "C" {
+		t.Errorf("Entry() = %+v, %t, %v, want the updated font C", e, ok, err)
+	}
+	if _, ok, _ := ix.Entry(b); ok {
+		t.Errorf("Entry() found the deleted file")
+	}
+}
@@ -0,0 +1,7 @@
+// Package index keeps a local database of the fonts in a font library, so fonts can be found by name
+// without parsing thousands of files on a network share every time. Every file is parsed once: its
+// faces (names, weight, italic, character coverage) and its hash are stored in a bbolt database, and
+// rebuilding the index only parses the files whose size or modification time changed.
+//
+// The package is operating system independent.
+package index
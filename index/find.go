@@ -0,0 +1,143 @@
+package index
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fontctl/fonts"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNoMatch is returned by Find if there is no font with the name in the index.
+var ErrNoMatch = errors.New("no font with this name in the index")
+
+// Match is a face found by Find.
+type Match struct {
+	fonts.Face
+	Hash string `json:"hash"`
+	// Score is how far the face is from the requested style, lower is better and 0 is an exact match.
+	Score int `json:"score"`
+}
+
+type weightName struct {
+	name   string
+	weight int
+}
+
+// weightNames are the style words for the weight classes, compound words first so "semibold" isn't
+// taken for "bold".
+var weightNames = []weightName{
+	{"hairline", 100}, {"thin", 100},
+	{"extralight", 200}, {"ultralight", 200},
+	{"semilight", 350}, {"demilight", 350},
+	{"light", 300},
+	{"regular", 400}, {"normal", 400}, {"book", 400}, {"roman", 400},
+	{"medium", 500},
+	{"semibold", 600}, {"demibold", 600},
+	{"extrabold", 800}, {"ultrabold", 800},
+	{"bold", 700},
+	{"black", 900}, {"heavy", 900},
+}
+
+// ParseStyle returns the weight class and italic flag of a style name like "Bold Italic", "SemiBold" or
+// "Light Oblique". An empty or unknown style is regular (400, not italic).
+func ParseStyle(style string) (weight int, italic bool) {
+	s := normalizeStyle(style)
+	italic = strings.Contains(s, "italic") || strings.Contains(s, "oblique")
+	for _, w := range weightNames {
+		if strings.Contains(s, w.name) {
+			return w.weight, italic
+		}
+	}
+	return 400, italic
+}
+
+func normalizeStyle(style string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(style))
+}
+
+// Find returns the faces with a family, full or PostScript name equal to name (case-insensitive),
+// ordered by how well they match the style (see ParseStyle), best first. If nothing matches, trailing
+// style words of name are tried as style, so "Helvetica Neue Bold Italic" finds the Bold Italic face
+// of "Helvetica Neue" even if there is no face with that full name. If nothing matches at all, the
+// error is ErrNoMatch.
+func (ix *Index) Find(name, style string) ([]Match, error) {
+	words := strings.Fields(name)
+	for n := len(words); n > 0; n-- {
+		if n < len(words) && !isStyleWord(words[n]) {
+			break
+		}
+		s := strings.TrimSpace(strings.Join(words[n:], " ") + " " + style)
+		matches, err := ix.find(strings.Join(words[:n], " "), s)
+		if err != nil || len(matches) > 0 {
+			return matches, err
+		}
+	}
+	return nil, fmt.Errorf("%w: '%s'", ErrNoMatch, name)
+}
+
+// isStyleWord reports whether the word is part of a style name, i.e. "Semi" and "Bold" of "Semi Bold".
+func isStyleWord(word string) bool {
+	w := normalizeStyle(word)
+	if slices.Contains([]string{"italic", "oblique", "semi", "demi", "extra", "ultra"}, w) {
+		return true
+	}
+	return slices.ContainsFunc(weightNames, func(n weightName) bool { return n.name == w })
+}
+
+func (ix *Index) find(name, style string) ([]Match, error) {
+	var matches []Match
+	err := ix.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(nameKeyPrefix(name))
+		entries := map[string]Entry{}
+		c := tx.Bucket(namesBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			path, faceIndex, ok := parseNameKey(k)
+			if !ok {
+				continue
+			}
+			e, ok := entries[path]
+			if !ok {
+				var err error
+				if e, _, err = getEntry(tx, path); err != nil {
+					return err
+				}
+				entries[path] = e
+			}
+			if i := slices.IndexFunc(e.Faces, func(f fonts.Face) bool { return f.Index == faceIndex }); i >= 0 {
+				face := e.Faces[i]
+				matches = append(matches, Match{Face: face, Hash: e.Hash, Score: styleScore(face, name, style)})
+			}
+		}
+		return nil
+	})
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		if a.Score != b.Score {
+			return a.Score - b.Score
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return matches, err
+}
+
+// styleScore rates how well a face matches the style: 0 if its style name is the requested one, else
+// the weight distance, with a penalty for the wrong slant that outweighs any weight difference.
+func styleScore(face fonts.Face, name, style string) int {
+	if style == "" && !slices.ContainsFunc(face.Families, func(f string) bool { return strings.EqualFold(f, name) }) {
+		return 0 // found by its full or PostScript name, i.e. "Helvetica Neue Bold"
+	}
+	if normalizeStyle(face.Subfamily) == normalizeStyle(style) ||
+		style == "" && slices.Contains([]string{"regular", "normal", "book", "roman"}, normalizeStyle(face.Subfamily)) {
+		return 0
+	}
+	weight, italic := ParseStyle(style)
+	score := 1 + max(weight-face.Weight, face.Weight-weight)
+	if italic != face.Italic {
+		score += 1000
+	}
+	return score
+}
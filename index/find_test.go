@@ -0,0 +1,85 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		style      string
+		wantWeight int
+		wantItalic bool
+	}{
+		{"", 400, false},
+		{"Regular", 400, false},
+		{"Bold Italic", 700, true},
+		{"SemiBold", 600, false},
+		{"Semi Bold", 600, false},
+		{"extra-bold", 800, false},
+		{"Light Oblique", 300, true},
+		{"Italic", 400, true},
+		{"Condensed", 400, false},
+	}
+	for _, tt := range tests {
+		weight, italic := ParseStyle(tt.style)
+		if weight != tt.wantWeight || italic != tt.wantItalic {
+			t.Errorf("ParseStyle(%q) = %d, %t, want %d, %t", tt.style, weight, italic, tt.wantWeight, tt.wantItalic)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	ix := openTestIndex(t)
+	dir := t.TempDir()
+	for _, f := range []struct {
+		file, subfamily string
+		weight          int
+		italic          bool
+	}{
+		{"Foo-Regular.ttf", "Regular", 400, false},
+		{"Foo-Italic.ttf", "Italic", 400, true},
+		{"Foo-Bold.ttf", "Bold", 700, false},
+		{"Foo-BoldItalic.ttf", "Bold Italic", 700, true},
+		{"Foo-Black.ttf", "Black", 900, false},
+	} {
+		writeTestFile(t, filepath.Join(dir, f.file), testFont("Foo Sans", f.subfamily, f.weight, f.italic))
+	}
+	if _, err := ix.Build(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, style string
+		want        string // subfamily of the best match
+		wantCount   int
+	}{
+		{"Foo Sans", "", "Regular", 5},
+		{"foo sans", "bold", "Bold", 5},
+		{"Foo Sans", "Semibold", "Bold", 5},
+		{"Foo Sans", "Light Italic", "Italic", 5},
+		{"Foo Sans", "Heavy", "Black", 5},
+		{"Foo Sans Bold Italic", "", "Bold Italic", 1},      // the full name
+		{"Foo Sans Semibold Oblique", "", "Bold Italic", 5}, // no such full name, the style words are tried
+		{"Foo Sans Black Italic", "", "Black", 1},           // the full name of Black, Italic is the style
+		{"FooSans-Black", "", "Black", 1},                   // the PostScript name
+	}
+	for _, tt := range tests {
+		matches, err := ix.Find(tt.name, tt.style)
+		if err != nil {
+			t.Errorf("Find(%q, %q) error = %v", tt.name, tt.style, err)
+			continue
+		}
+		if len(matches) != tt.wantCount || matches[0].Subfamily != tt.want {
+			t.Errorf("Find(%q, %q) = %d matches, best %q, want %d, best %q", tt.name, tt.style, len(matches), matches[0].Subfamily, tt.wantCount, tt.want)
+		}
+	}
+
+	for _, name := range []string{"Bar", "Foo", "Bold", "Foo Sans Condensed"} {
+		if matches, err := ix.Find(name, ""); !errors.Is(err, ErrNoMatch) {
+			t.Errorf("Find(%q) = %v, %v, want %v", name, matches, err, ErrNoMatch)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fontctl/fonts"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	filesBucket = []byte("files") // path -> Entry as JSON
+	namesBucket = []byte("names") // lowercased name \x00 path \x00 face index -> nothing
+)
+
+// Entry is an indexed font file.
+type Entry struct {
+	Path    string       `json:"path"`
+	Size    int64        `json:"size"`
+	ModTime time.Time    `json:"mod_time"`
+	Hash    string       `json:"hash,omitempty"`
+	Faces   []fonts.Face `json:"faces,omitempty"`
+	// Error is why the file couldn't be parsed. It is parsed again when it changes.
+	Error string `json:"error,omitempty"`
+}
+
+// Options are the settings of an Index.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// Workers is the number of files Build parses in parallel. Defaults to 8, which keeps a network
+	// share busy without overloading it.
+	Workers int
+}
+
+// Index is a font index database. It is safe for concurrent use, but only one process can open the
+// database at a time.
+type Index struct {
+	db   *bolt.DB
+	opts Options
+	log  *slog.Logger
+}
+
+// DefaultPath returns the path of the index in the user's cache dir.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("can't find cache dir (%w)", err)
+	}
+	return filepath.Join(dir, "fontctl", "index.db"), nil
+}
+
+// Open opens the index database at path and creates it if it doesn't exist.
+func Open(path string, opts Options) (*Index, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	log := opts.Logger
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("can't create index dir '%s' (%w)", filepath.Dir(path), fonts.WithAccessDenied(err))
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("index '%s' is in use by another fontctl process", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't open index '%s' (%w)", path, fonts.WithAccessDenied(err))
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{filesBucket, namesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("can't initialize index '%s' (%w)", path, err)
+	}
+	log.Debug("opened index", "path", path)
+	return &Index{db: db, opts: opts, log: log}, nil
+}
+
+// Close closes the database.
+func (ix *Index) Close() error {
+	return ix.db.Close()
+}
+
+// Entry returns the indexed file at path, ok is false if it is not in the index.
+func (ix *Index) Entry(path string) (e Entry, ok bool, err error) {
+	err = ix.db.View(func(tx *bolt.Tx) error {
+		e, ok, err = getEntry(tx, path)
+		return err
+	})
+	return e, ok, err
+}
+
+// Len returns the number of indexed files.
+func (ix *Index) Len() (int, error) {
+	n := 0
+	err := ix.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(filesBucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+func getEntry(tx *bolt.Tx, path string) (Entry, bool, error) {
+	var e Entry
+	data := tx.Bucket(filesBucket).Get([]byte(path))
+	if data == nil {
+		return e, false, nil
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, false, fmt.Errorf("invalid index entry '%s' (%w)", path, err)
+	}
+	return e, true, nil
+}
+
+// putEntry stores the entry and its name keys, replacing the previous entry of the file.
+func putEntry(tx *bolt.Tx, e Entry) error {
+	if err := deleteEntry(tx, e.Path); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(filesBucket).Put([]byte(e.Path), data); err != nil {
+		return err
+	}
+	names := tx.Bucket(namesBucket)
+	for _, key := range nameKeys(e) {
+		if err := names.Put(key, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteEntry removes the file and its name keys from the index, if it is there.
+func deleteEntry(tx *bolt.Tx, path string) error {
+	// the name keys of a broken entry can't be found, they are ignored by Find as their face is gone
+	if old, ok, _ := getEntry(tx, path); ok {
+		names := tx.Bucket(namesBucket)
+		for _, key := range nameKeys(old) {
+			if err := names.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Bucket(filesBucket).Delete([]byte(path))
+}
+
+// nameKeys returns the keys of the names bucket for all names of all faces of the entry.
+func nameKeys(e Entry) [][]byte {
+	var keys [][]byte
+	seen := map[string]bool{}
+	for _, f := range e.Faces {
+		for _, name := range append([]string{f.FullName, f.PostScriptName}, f.Families...) {
+			if name == "" {
+				continue
+			}
+			key := nameKeyPrefix(name) + e.Path + "\x00" + strconv.Itoa(f.Index)
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, []byte(key))
+			}
+		}
+	}
+	return keys
+}
+
+func nameKeyPrefix(name string) string {
+	return strings.ToLower(strings.TrimSpace(name)) + "\x00"
+}
+
+// parseNameKey returns the path and face index of a names bucket key.
+func parseNameKey(key []byte) (path string, faceIndex int, ok bool) {
+	parts := strings.Split(string(key), "\x00")
+	if len(parts) != 3 {
+		return "", 0, false
+	}
+	faceIndex, err := strconv.Atoi(parts[2])
+	return parts[1], faceIndex, err == nil
+}
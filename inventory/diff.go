@@ -0,0 +1,197 @@
+package inventory
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Diff are the differences between two inventories a and b.
+type Diff struct {
+	Added   []Font   `json:"added"`   // only in b
+	Removed []Font   `json:"removed"` // only in a
+	Changed []Change `json:"changed"`
+	// Collisions are names that more than one different font file provides in one of the inventories,
+	// unless the other inventory has the same collision. Which of these files an application gets
+	// depends on the load order, a common reason for documents that render differently.
+	Collisions []Collision `json:"collisions"`
+}
+
+// Change is a font that is installed on both machines, but not the same way.
+type Change struct {
+	Name    string   `json:"name"`
+	Old     Font     `json:"old"`
+	New     Font     `json:"new"`
+	Changes []string `json:"changes"` // i.e. "Version 2.37 -> Version 2.40"
+}
+
+// Collision is a face name provided by several font files.
+type Collision struct {
+	Name  string `json:"name"`
+	In    string `json:"in"` // "a" or "b"
+	Fonts []Font `json:"fonts"`
+}
+
+// Empty reports whether the inventories have no differences.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Collisions) == 0
+}
+
+// Compare compares the fonts of a and b. Fonts are matched by registry name and scope first, then by
+// registry name only, so a font moved from the user to the system fonts is a change and not a removal
+// and an addition.
+func Compare(a, b Inventory) Diff {
+	d := Diff{Added: []Font{}, Removed: []Font{}, Changed: []Change{}, Collisions: []Collision{}}
+	matched := make([]bool, len(b.Fonts))
+	var unmatched []Font
+	for _, f := range a.Fonts {
+		i := slices.IndexFunc(b.Fonts, func(o Font) bool { return sameName(f, o) && f.Scope == o.Scope })
+		if i < 0 || matched[i] {
+			unmatched = append(unmatched, f)
+			continue
+		}
+		matched[i] = true
+		d.addChange(f, b.Fonts[i])
+	}
+	for _, f := range unmatched {
+		i := -1
+		for j, o := range b.Fonts {
+			if !matched[j] && sameName(f, o) {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			d.Removed = append(d.Removed, f)
+			continue
+		}
+		matched[i] = true
+		d.addChange(f, b.Fonts[i])
+	}
+	for i, f := range b.Fonts {
+		if !matched[i] {
+			d.Added = append(d.Added, f)
+		}
+	}
+	byName := func(x, y Font) int { return strings.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name)) }
+	slices.SortStableFunc(d.Added, byName)
+	slices.SortStableFunc(d.Removed, byName)
+	slices.SortStableFunc(d.Changed, func(x, y Change) int { return strings.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name)) })
+
+	ca, cb := collisions(a), collisions(b)
+	for _, c := range ca {
+		if !slices.ContainsFunc(cb, c.sameAs) {
+			c.In = "a"
+			d.Collisions = append(d.Collisions, c)
+		}
+	}
+	for _, c := range cb {
+		if !slices.ContainsFunc(ca, c.sameAs) {
+			c.In = "b"
+			d.Collisions = append(d.Collisions, c)
+		}
+	}
+	return d
+}
+
+func sameName(a, b Font) bool {
+	return strings.EqualFold(a.Name, b.Name)
+}
+
+func (d *Diff) addChange(before, after Font) {
+	var changes []string
+	if before.Hash != after.Hash {
+		if before.Version != after.Version {
+			changes = append(changes, fmt.Sprintf("%s -> %s", orUnknown(before.Version), orUnknown(after.Version)))
+		} else if before.Hash != "" && after.Hash != "" {
+			changes = append(changes, fmt.Sprintf("content changed, same %s", orUnknown(after.Version)))
+		}
+	}
+	if before.Error != after.Error {
+		switch {
+		case after.Error == "":
+			changes = append(changes, "file readable again")
+		default:
+			changes = append(changes, "file not readable: "+after.Error)
+		}
+	}
+	if before.Scope != after.Scope {
+		changes = append(changes, fmt.Sprintf("scope %s -> %s", before.Scope, after.Scope))
+	}
+	// the full paths of user fonts differ by user name, only a renamed file is a change
+	if !strings.EqualFold(BaseName(before.File), BaseName(after.File)) {
+		changes = append(changes, fmt.Sprintf("file %s -> %s", BaseName(before.File), BaseName(after.File)))
+	}
+	if len(changes) > 0 {
+		d.Changed = append(d.Changed, Change{Name: after.Name, Old: before, New: after, Changes: changes})
+	}
+}
+
+// BaseName returns the file name of a path written on any OS, so the paths of an inventory of a
+// Windows machine work on Linux too.
+func BaseName(p string) string {
+	return filepath.Base(filepath.FromSlash(strings.ReplaceAll(p, `\`, "/")))
+}
+
+func orUnknown(version string) string {
+	if version == "" {
+		return "unknown version"
+	}
+	return version
+}
+
+// collisions returns the face names that are provided by font files with different content.
+func collisions(inv Inventory) []Collision {
+	byName := map[string]*Collision{}
+	var keys []string
+	for _, f := range inv.Fonts {
+		if f.Hash == "" {
+			continue
+		}
+		for _, face := range f.Faces {
+			name := faceName(face)
+			key := strings.ToLower(name)
+			if key == "" {
+				continue
+			}
+			c, ok := byName[key]
+			if !ok {
+				c = &Collision{Name: name}
+				byName[key] = c
+				keys = append(keys, key)
+			}
+			if !slices.ContainsFunc(c.Fonts, func(o Font) bool { return o.Hash == f.Hash }) {
+				c.Fonts = append(c.Fonts, f)
+			}
+		}
+	}
+	slices.Sort(keys)
+	var list []Collision
+	for _, k := range keys {
+		if c := byName[k]; len(c.Fonts) > 1 {
+			list = append(list, *c)
+		}
+	}
+	return list
+}
+
+func faceName(face Face) string {
+	if face.FullName != "" {
+		return face.FullName
+	}
+	return strings.TrimSpace(face.Family + " " + face.Subfamily)
+}
+
+// sameAs reports whether two collisions are between the same font files.
+func (c Collision) sameAs(o Collision) bool {
+	if !strings.EqualFold(c.Name, o.Name) || len(c.Fonts) != len(o.Fonts) {
+		return false
+	}
+	for _, f := range c.Fonts {
+		if !slices.ContainsFunc(o.Fonts, func(g Font) bool { return g.Hash == f.Hash }) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package inventory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBaseName(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{`C:\Windows\Fonts\arial.ttf`, "arial.ttf"},
+		{`C:\Users\jane\AppData\Local\Microsoft\Windows\Fonts\Foo.otf`, "Foo.otf"},
+		{"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf", "DejaVuSans.ttf"},
+		{"arial.ttf", "arial.ttf"},
+	}
+	for _, tt := range tests {
+		if got := BaseName(tt.path); got != tt.want {
+			t.Errorf("BaseName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	arial := Font{Name: "Arial (TrueType)", Scope: "system", File: `C:\Windows\Fonts\arial.ttf`, Hash: "sha256:a1", Version: "Version 7.00"}
+	foo := Font{Name: "Foo (TrueType)", Scope: "user", File: `C:\Users\jane\AppData\Local\Microsoft\Windows\Fonts\Foo.otf`, Hash: "sha256:f"}
+	a := Inventory{Fonts: []Font{
+		arial,
+		foo,
+		{Name: "Old (TrueType)", Scope: "system", File: `C:\Windows\Fonts\old.ttf`, Hash: "sha256:o"},
+	}}
+
+	newArial := arial
+	newArial.Hash, newArial.Version = "sha256:a2", "Version 7.01"
+	// another user and a different spelling of the name, but the same file
+	sameFoo := foo
+	sameFoo.Name, sameFoo.File = "foo (truetype)", `C:\Users\john\AppData\Local\Microsoft\Windows\Fonts\foo.otf`
+	b := Inventory{Fonts: []Font{
+		{Name: "New (TrueType)", Scope: "system", File: `C:\Windows\Fonts\new.ttf`, Hash: "sha256:n"},
+		sameFoo,
+		newArial,
+	}}
+
+	d := Compare(a, b)
+	if len(d.Added) != 1 || d.Added[0].Name != "New (TrueType)" {
+		t.Errorf("Compare() added %+v, want New", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Name != "Old (TrueType)" {
+		t.Errorf("Compare() removed %+v, want Old", d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Name != "Arial (TrueType)" {
+		t.Fatalf("Compare() changed %+v, want only Arial", d.Changed)
+	}
+	if want := []string{"Version 7.00 -> Version 7.01"}; !slices.Equal(d.Changed[0].Changes, want) {
+		t.Errorf("Compare() changes of Arial = %q, want %q", d.Changed[0].Changes, want)
+	}
+	if !Compare(a, a).Empty() {
+		t.Errorf("Compare() of an inventory with itself = %+v, want no differences", Compare(a, a))
+	}
+}
+
+func TestCompareChanges(t *testing.T) {
+	base := Font{Name: "Foo (TrueType)", Scope: "user", File: "/home/jane/.local/share/fonts/Foo.ttf", Hash: "sha256:1", Version: "Version 1.0"}
+	tests := []struct {
+		name   string
+		change func(f *Font)
+		want   []string
+	}{
+		{"moved to the system fonts", func(f *Font) { f.Scope = "system"; f.File = "/usr/share/fonts/Foo.ttf" }, []string{"scope user -> system"}},
+		{"renamed", func(f *Font) { f.File = "/home/jane/.local/share/fonts/Foo-Regular.ttf" }, []string{"file Foo.ttf -> Foo-Regular.ttf"}},
+		{"same version", func(f *Font) { f.Hash = "sha256:2" }, []string{"content changed, same Version 1.0"}},
+		{"no version", func(f *Font) { f.Hash = "sha256:2"; f.Version = "" }, []string{"Version 1.0 -> unknown version"}},
+		{"not readable", func(f *Font) { f.Hash = ""; f.Error = "permission denied" }, []string{"file not readable: permission denied"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			tt.change(&after)
+			d := Compare(Inventory{Fonts: []Font{base}}, Inventory{Fonts: []Font{after}})
+			if len(d.Added) != 0 || len(d.Removed) != 0 || len(d.Changed) != 1 {
+				t.Fatalf("Compare() = %+v, want one change", d)
+			}
+			if !slices.Equal(d.Changed[0].Changes, tt.want) {
+				t.Errorf("Compare() changes = %q, want %q", d.Changed[0].Changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareMatchesScopeFirst(t *testing.T) {
+	user := Font{Name: "Foo (TrueType)", Scope: "user", File: "Foo.ttf", Hash: "sha256:1"}
+	system := Font{Name: "Foo (TrueType)", Scope: "system", File: "Foo.ttf", Hash: "sha256:1"}
+	d := Compare(Inventory{Fonts: []Font{user, system}}, Inventory{Fonts: []Font{system, user}})
+	if !d.Empty() {
+		t.Errorf("Compare() = %+v, want fonts with the same name matched by scope", d)
+	}
+	d = Compare(Inventory{Fonts: []Font{user, system}}, Inventory{Fonts: []Font{system}})
+	if len(d.Removed) != 1 || d.Removed[0].Scope != "user" || len(d.Changed) != 0 {
+		t.Errorf("Compare() = %+v, want the user font removed", d)
+	}
+}
+
+func TestCompareCollisions(t *testing.T) {
+	face := []Face{{Family: "Foo", Subfamily: "Regular"}}
+	one := Font{Name: "Foo (TrueType)", Scope: "system", File: "Foo.ttf", Hash: "sha256:1", Faces: face}
+	two := Font{Name: "Foo (OpenType)", Scope: "user", File: "Foo.otf", Hash: "sha256:2", Faces: face}
+	copyOfOne := Font{Name: "Foo copy (TrueType)", Scope: "user", File: "Foo copy.ttf", Hash: "sha256:1", Faces: face}
+
+	d := Compare(Inventory{Fonts: []Font{one, copyOfOne}}, Inventory{Fonts: []Font{one, copyOfOne, two}})
+	if len(d.Collisions) != 1 {
+		t.Fatalf("Compare() collisions = %+v, want Foo Regular in b", d.Collisions)
+	}
+	if c := d.Collisions[0]; c.Name != "Foo Regular" || c.In != "b" || len(c.Fonts) != 2 {
+		t.Errorf("Compare() collision = %+v, want Foo Regular in b with 2 files", c)
+	}
+	// the same collision on both machines isn't a difference
+	d = Compare(Inventory{Fonts: []Font{one, two}}, Inventory{Fonts: []Font{two, one}})
+	if len(d.Collisions) != 0 {
+		t.Errorf("Compare() collisions = %+v, want none", d.Collisions)
+	}
+}
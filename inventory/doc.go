@@ -0,0 +1,6 @@
+// Package inventory records the installed fonts of a machine, with their registry names, files,
+// hashes and versions, and compares the inventories of two machines. The differences explain why a
+// document renders differently on one machine than on another.
+//
+// The package is operating system independent, inventories can be compared on any OS.
+package inventory
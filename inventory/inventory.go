@@ -0,0 +1,140 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"fontctl/fonts"
+)
+
+// FormatVersion is the version of the inventory format. Inventories with a newer version can't be read.
+const FormatVersion = 1
+
+// ErrInvalidInventory is returned for files that are not an inventory written by fontctl.
+var ErrInvalidInventory = errors.New("invalid font inventory")
+
+// Inventory is the list of fonts installed on a machine.
+type Inventory struct {
+	Format  int       `json:"format"`
+	Created time.Time `json:"created"`
+	Host    string    `json:"host,omitempty"`
+	OS      string    `json:"os"`
+	Fonts   []Font    `json:"fonts"`
+}
+
+// Font is an installed font file.
+type Font struct {
+	Name    string `json:"name"`  // registry value name, i.e. "Arial (TrueType)", or the file name
+	Scope   string `json:"scope"` // "system", "user" or "dir"
+	File    string `json:"file"`
+	Hash    string `json:"hash,omitempty"`
+	Version string `json:"version,omitempty"` // version of the first face
+	Faces   []Face `json:"faces,omitempty"`
+	Error   string `json:"error,omitempty"` // why the file couldn't be read
+}
+
+// Face are the names of a face in a font file.
+type Face struct {
+	Family         string `json:"family"`
+	Subfamily      string `json:"subfamily,omitempty"`
+	FullName       string `json:"full_name,omitempty"`
+	PostScriptName string `json:"postscript_name,omitempty"`
+}
+
+// Source is an installed font file to record.
+type Source struct {
+	Name  string // registry value name, the file name is used if empty
+	Scope string
+	Path  string
+}
+
+// Options are the settings of Collect.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// Now returns the creation time of the inventory. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return o.Logger
+}
+
+// Collect reads the hashes and faces of the source files. Files that can't be read are recorded with
+// the error, a missing font file is a difference worth reporting too.
+func Collect(sources []Source, opts Options) Inventory {
+	log := opts.log()
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	host, _ := os.Hostname()
+	inv := Inventory{Format: FormatVersion, Created: now().UTC(), Host: host, OS: runtime.GOOS, Fonts: []Font{}}
+	for _, src := range sources {
+		f := Font{Name: src.Name, Scope: src.Scope, File: src.Path}
+		if f.Name == "" {
+			f.Name = filepath.Base(src.Path)
+		}
+		if err := f.read(); err != nil {
+			log.Warn("can't read font file", "path", src.Path, "error", err)
+			f.Error = err.Error()
+		}
+		inv.Fonts = append(inv.Fonts, f)
+	}
+	return inv
+}
+
+func (f *Font) read() error {
+	hash, err := fonts.HashFile(f.File)
+	if err != nil {
+		return fonts.WithAccessDenied(err)
+	}
+	f.Hash = fonts.FormatHash(hash)
+	faces, err := fonts.ReadFaces(f.File)
+	if err != nil {
+		return err
+	}
+	for _, face := range faces {
+		f.Faces = append(f.Faces, Face{Family: face.Family, Subfamily: face.Subfamily, FullName: face.FullName, PostScriptName: face.PostScriptName})
+	}
+	if len(faces) > 0 {
+		f.Version = faces[0].Version
+	}
+	return nil
+}
+
+// Write writes the inventory as JSON to w.
+func Write(w io.Writer, inv Inventory) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inv)
+}
+
+// Load reads an inventory file.
+func Load(path string) (Inventory, error) {
+	var inv Inventory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return inv, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, path, fonts.WithAccessDenied(err))
+	}
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return inv, fmt.Errorf("%w '%s' (%w)", ErrInvalidInventory, path, err)
+	}
+	if inv.Format == 0 {
+		return inv, fmt.Errorf("%w '%s': no format version", ErrInvalidInventory, path)
+	}
+	if inv.Format > FormatVersion {
+		return inv, fmt.Errorf("%w '%s': format %d is newer than this fontctl supports (%d)", ErrInvalidInventory, path, inv.Format, FormatVersion)
+	}
+	return inv, nil
+}
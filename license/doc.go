@@ -0,0 +1,6 @@
+// Package license classifies the licenses of fonts by the license description and URL of the name
+// table (name IDs 13 and 14) and the embedding permissions (OS/2 fsType), and maps them to SPDX
+// license identifiers where they are recognized.
+//
+// The package is operating system independent.
+package license
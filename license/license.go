@@ -0,0 +1,143 @@
+package license
+
+import (
+	"fmt"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Class is the kind of license of a font.
+type Class string
+
+const (
+	ClassOFL         Class = "ofl"         // SIL Open Font License
+	ClassApache      Class = "apache"      // Apache License 2.0
+	ClassUFL         Class = "ufl"         // Ubuntu Font Licence
+	ClassPermissive  Class = "permissive"  // other recognized open licenses, i.e. Bitstream Vera or MIT
+	ClassProprietary Class = "proprietary" // commercial licenses and fonts with restricted embedding
+	ClassUnknown     Class = "unknown"
+)
+
+// Classification is the license of a font.
+type Classification struct {
+	Class Class  `json:"class"`
+	SPDX  string `json:"spdx,omitempty"` // SPDX license identifier, "" if not recognized
+	// Reason is the fingerprint or flag the class was derived from.
+	Reason string `json:"reason"`
+}
+
+// fingerprint is a phrase of a license text or URL that identifies a license.
+type fingerprint struct {
+	class  Class
+	spdx   string
+	phrase string
+}
+
+// openFingerprints identify open licenses. They are checked in order, the first match wins, so the
+// more specific phrases come first.
+var openFingerprints = []fingerprint{
+	{ClassOFL, "OFL-1.0", "open font license, version 1.0"},
+	{ClassOFL, "OFL-1.0", "open font license version 1.0"},
+	{ClassOFL, "OFL-1.1", "sil open font license"},
+	{ClassOFL, "OFL-1.1", "open font license"},
+	{ClassOFL, "OFL-1.1", "scripts.sil.org/ofl"},
+	{ClassOFL, "OFL-1.1", "openfontlicense.org"},
+	{ClassApache, "Apache-2.0", "apache license"},
+	{ClassApache, "Apache-2.0", "apache.org/licenses/license-2.0"},
+	{ClassUFL, "Ubuntu-font-1.0", "ubuntu font licence"},
+	{ClassUFL, "Ubuntu-font-1.0", "ubuntu font license"},
+	{ClassUFL, "Ubuntu-font-1.0", "font.ubuntu.com/ufl"},
+	{ClassPermissive, "Bitstream-Vera", "bitstream vera"},
+	{ClassPermissive, "MIT", "mit license"},
+	{ClassPermissive, "MIT", "opensource.org/licenses/mit"},
+}
+
+// proprietaryFingerprints identify commercial license texts.
+var proprietaryFingerprints = []string{
+	"end user license agreement",
+	"eula",
+	"may not be copied",
+	"may not be distributed",
+	"may not be redistributed",
+	"not be reproduced",
+	"licensed, not sold",
+	"commercial license",
+	"purchase a license",
+	"microsoft.com/typography/fonts",
+	"monotype.com/legal",
+	"fonts.com/info/legal",
+	"adobe.com/type/legal",
+	"linotype.com/license",
+}
+
+// Classify returns the license class of a font by its license description (name ID 13), license URL
+// (name ID 14) and embedding permissions (OS/2 fsType). Open license fingerprints win over the
+// embedding permissions, as some open fonts have them set wrong.
+func Classify(text, url string, fsType uint16) Classification {
+	s := normalize(text + "\n" + url)
+	for _, f := range openFingerprints {
+		if containsPhrase(s, f.phrase) {
+			return Classification{Class: f.class, SPDX: f.spdx, Reason: fmt.Sprintf("license text contains '%s'", f.phrase)}
+		}
+	}
+	for _, phrase := range proprietaryFingerprints {
+		if containsPhrase(s, phrase) {
+			return Classification{Class: ClassProprietary, Reason: fmt.Sprintf("license text contains '%s'", phrase)}
+		}
+	}
+	if e := fonts.EmbeddingPermissions(fsType); e != "installable" {
+		return Classification{Class: ClassProprietary, Reason: fmt.Sprintf("embedding is %s (fsType 0x%04x)", e, fsType)}
+	}
+	if strings.TrimSpace(text+url) == "" {
+		return Classification{Class: ClassUnknown, Reason: "no license in the name table"}
+	}
+	return Classification{Class: ClassUnknown, Reason: "license text not recognized"}
+}
+
+// SPDXID returns the SPDX license identifier of a license description and URL, or "" if the license
+// isn't recognized.
+func SPDXID(text, url string) string {
+	return Classify(text, url, 0).SPDX
+}
+
+// normalize lower cases s and collapses white space, license texts are wrapped differently by every
+// vendor.
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// containsPhrase reports whether s contains phrase as whole words, so "mit license" doesn't match
+// "submit license" and "eula" doesn't match "eulalia".
+func containsPhrase(s, phrase string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(phrase)
+		if !isWordByte(s, start-1) && !isWordByte(s, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// isWordByte reports whether s has a letter or digit at i. Non-ASCII bytes count as letters.
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// ParseClass parses the name of a class.
+func ParseClass(s string) (Class, error) {
+	c := Class(strings.ToLower(strings.TrimSpace(s)))
+	switch c {
+	case ClassOFL, ClassApache, ClassUFL, ClassPermissive, ClassProprietary, ClassUnknown:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown license class '%s'", s)
+}
@@ -0,0 +1,59 @@
+package license
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		url    string
+		fsType uint16
+		want   Class
+		spdx   string
+	}{
+		{"OFL", "This Font Software is licensed under the SIL Open Font License, Version 1.1.", "https://openfontlicense.org", 0, ClassOFL, "OFL-1.1"},
+		{"OFL 1.0", "Licensed under the Open Font License, Version 1.0", "", 0, ClassOFL, "OFL-1.0"},
+		{"OFL wrapped", "Licensed under the SIL Open\nFont   License", "", 8, ClassOFL, "OFL-1.1"},
+		{"Apache", "Licensed under the Apache License, Version 2.0", "http://www.apache.org/licenses/LICENSE-2.0", 0, ClassApache, "Apache-2.0"},
+		{"MIT", "Released under the MIT License.", "", 0, ClassPermissive, "MIT"},
+		{"MIT URL", "", "https://opensource.org/licenses/MIT", 0, ClassPermissive, "MIT"},
+		{"MIT at the start", "MIT license, see LICENSE.txt", "", 0, ClassPermissive, "MIT"},
+		{"submit license", "Please submit license requests to sales.", "", 2, ClassProprietary, ""},
+		{"admit license", "Use of this font doesn't admit license to redistribute it.", "", 0, ClassUnknown, ""},
+		{"EULA", "See the EULA for the terms of use.", "", 0, ClassProprietary, ""},
+		{"EULA in a word", "Designed by Eulalia Smith.", "", 0, ClassUnknown, ""},
+		{"restricted embedding", "", "", 2, ClassProprietary, ""},
+		{"no license", "", "", 0, ClassUnknown, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.text, tt.url, tt.fsType)
+			if got.Class != tt.want || got.SPDX != tt.spdx {
+				t.Errorf("Classify() = %+v, want class %s and SPDX %q", got, tt.want, tt.spdx)
+			}
+		})
+	}
+}
+
+func TestContainsPhrase(t *testing.T) {
+	tests := []struct {
+		s, phrase string
+		want      bool
+	}{
+		{"mit license", "mit license", true},
+		{"the mit license.", "mit license", true},
+		{"(mit license)", "mit license", true},
+		{"submit license", "mit license", false},
+		{"mit licenses", "mit license", false},
+		{"submit license or mit license", "mit license", true},
+		{"eula", "eula", true},
+		{"eulalia", "eula", false},
+		{"http://scripts.sil.org/ofl", "scripts.sil.org/ofl", true},
+		{"", "eula", false},
+	}
+	for _, tt := range tests {
+		if got := containsPhrase(tt.s, tt.phrase); got != tt.want {
+			t.Errorf("containsPhrase(%q, %q) = %t, want %t", tt.s, tt.phrase, got, tt.want)
+		}
+	}
+}
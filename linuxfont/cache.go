@@ -0,0 +1,52 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RefreshCache updates the fontconfig cache of the given font dirs with fc-cache, so applications find
+// fonts that were added or removed. Without dirs, all font dirs fontconfig knows are refreshed. Without
+// fc-cache (i.e. in a minimal container) the dirs are touched instead: fontconfig compares the
+// modification time of a dir with its cache and rescans changed dirs. Does nothing with
+// opts.DeferRefresh.
+func RefreshCache(ctx context.Context, opts Options, dirs ...string) error {
+	if opts.DeferRefresh {
+		return nil
+	}
+	log := opts.log()
+	path, err := exec.LookPath("fc-cache")
+	if errors.Is(err, exec.ErrNotFound) {
+		if len(dirs) == 0 {
+			dirs = append(FontDirs(), filepath.Join(runtimeDir(), "fonts"))
+		}
+		log.Warn("fc-cache not found, applications rescan the font dirs when they start", "dirs", dirs)
+		now := time.Now()
+		for _, dir := range dirs {
+			if err := os.Chtimes(dir, now, now); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Debug("can't touch font dir", "dir", dir, "error", err)
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, dirs...)
+	cmd.Stderr = &stderr
+	log.Debug("refreshing fontconfig cache", "command", cmd.String())
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("fc-cache failed (%w): %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
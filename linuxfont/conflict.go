@@ -0,0 +1,50 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"fontctl/fonts"
+)
+
+// resolveConflict decides, according to opts.OnConflict, whether the installed file dst gets replaced
+// by src. It returns false without error if the installed file should be kept.
+func resolveConflict(src, dst string, opts InstallOptions) (bool, error) {
+	return fonts.ResolveConflict(opts.OnConflict, src, dst, opts.log())
+}
+
+// replaceFile replaces dst with a copy of src. The copy is staged next to dst and renamed over it, so
+// dst is never left half written. Applications that have the old file open keep reading it until they
+// reopen the font.
+func replaceFile(src, dst string, opts Options) error {
+	staged := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".fontctl-new")
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file '%s' (%w)", src, fonts.WithAccessDenied(err))
+	}
+	defer srcFile.Close()
+	dstFile, err := os.OpenFile(staged, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create staging file '%s' (%w)", staged, fonts.WithAccessDenied(err))
+	}
+	_, err = io.Copy(dstFile, srcFile)
+	if err == nil {
+		err = dstFile.Sync()
+	}
+	if cerr := dstFile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(staged, dst)
+	}
+	if err != nil {
+		os.Remove(staged)
+		return fmt.Errorf("failed to replace file '%s' (%w)", dst, fonts.WithAccessDenied(err))
+	}
+	opts.log().Debug("file replaced", "source", src, "dest", dst)
+	return nil
+}
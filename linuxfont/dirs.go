@@ -0,0 +1,73 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SystemFontDir is the font dir fonts are installed into for all users. /usr/share/fonts belongs to
+// the package manager.
+const SystemFontDir = "/usr/local/share/fonts"
+
+// fontDir returns the dir fonts are installed into: $XDG_DATA_HOME/fonts (~/.local/share/fonts) for the
+// user, SystemFontDir for all users.
+func fontDir(systemWide bool) (string, error) {
+	if systemWide {
+		return SystemFontDir, nil
+	}
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" || !filepath.IsAbs(dataHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("can't find the user's home dir (%w)", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "fonts"), nil
+}
+
+// systemFontDirs returns the fonts dirs of $XDG_DATA_DIRS (default /usr/local/share and /usr/share),
+// which fontconfig searches for the fonts of all users, and SystemFontDir.
+func systemFontDirs() []string {
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	var dirs []string
+	for _, d := range filepath.SplitList(dataDirs) {
+		if d != "" && filepath.IsAbs(d) && !slices.Contains(dirs, filepath.Join(d, "fonts")) {
+			dirs = append(dirs, filepath.Join(d, "fonts"))
+		}
+	}
+	if !slices.Contains(dirs, SystemFontDir) {
+		dirs = append(dirs, SystemFontDir)
+	}
+	return dirs
+}
+
+// FontDirs returns the system font dirs and the user's font dir, as far as they exist.
+func FontDirs() []string {
+	var dirs []string
+	for _, dir := range systemFontDirs() {
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	if dir, err := fontDir(false); err == nil && !slices.Contains(dirs, dir) {
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// isInDir reports whether path is in dir or one of its subdirs, fontconfig scans font dirs recursively.
+func isInDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
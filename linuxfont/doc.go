@@ -0,0 +1,10 @@
+// Package linuxfont installs, uninstalls and loads fonts on Linux the freedesktop way: fonts are
+// installed by copying them into an XDG font dir (~/.local/share/fonts for the user,
+// /usr/local/share/fonts for all users) and refreshing the fontconfig cache with fc-cache. Loading a font
+// links it into a staging dir in the user's runtime dir, which a fontconfig conf file adds to the font
+// dirs until the last font is unloaded or the user logs out.
+//
+// The API follows winfont, so the fontctl commands work the same on both OSes. All functions that touch
+// the system take a context.Context and an options struct. The zero value of the options is a sensible
+// default (user scope, no logging).
+package linuxfont
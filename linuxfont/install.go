@@ -0,0 +1,138 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"fontctl/fonts"
+)
+
+// InstallFontFromFile copies a font into the user's or the system font dir and refreshes the fontconfig
+// cache. Type 1 fonts are installed with their .pfm and .pfb file. The files are copied to a staging dir
+// first, which is verified and installed, so the installed files are the verified ones.
+func InstallFontFromFile(ctx context.Context, fontPath string, opts InstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	log.Debug("using font file", "path", fontPath)
+
+	srcFiles, err := fontFiles(fontPath)
+	if err != nil {
+		return err
+	}
+	stageDir, staged, err := fonts.StageFiles(srcFiles...)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+	if err := opts.verify(srcFiles[0], staged[0]); err != nil {
+		return err
+	}
+
+	destPath, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+	log.Debug("using destination font dir", "dir", destPath)
+	if fi, err := os.Stat(destPath); err == nil && !fi.IsDir() {
+		return fmt.Errorf("font dir path '%s' exists but is not a directory", destPath)
+	}
+	if err := os.MkdirAll(destPath, 0755); err != nil {
+		return fmt.Errorf("font dir '%s' does not exist and trying to create it failed (%w)", destPath, fonts.WithAccessDenied(err))
+	}
+
+	for _, src := range staged {
+		if err = fonts.InstallFile(src, destPath, opts.log()); err != nil {
+			break
+		}
+	}
+	if errors.Is(err, fonts.ErrFileExistsAndIsDifferent) {
+		var replace bool
+		if replace, err = resolveConflict(staged[0], filepath.Join(destPath, filepath.Base(staged[0])), opts); err != nil || !replace {
+			return err
+		}
+		for _, src := range staged {
+			if err = replaceFile(src, filepath.Join(destPath, filepath.Base(src)), opts.Options); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return RefreshCache(ctx, opts.Options, destPath)
+}
+
+// UninstallFontFromFile deletes the installed copy of a font file and refreshes the fontconfig cache.
+// The installed file has to be identical to fontPath.
+func UninstallFontFromFile(ctx context.Context, fontPath string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	opts.log().Debug("using font file", "path", fontPath)
+
+	srcFiles, err := fontFiles(fontPath)
+	if err != nil {
+		return err
+	}
+	destPath, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+
+	var installed []string
+	for _, src := range srcFiles {
+		dst := filepath.Join(destPath, filepath.Base(src))
+		// never delete a different font that just happens to have the same file name
+		if err := fonts.VerifyInstalledCopy(src, dst); err != nil {
+			return err
+		}
+		installed = append(installed, dst)
+	}
+	return uninstallFiles(ctx, installed, opts)
+}
+
+// uninstallFiles deletes installed font files and refreshes the fontconfig cache of their dirs.
+func uninstallFiles(ctx context.Context, installed []string, opts UninstallOptions) error {
+	var dirs []string
+	for _, f := range installed {
+		if err := os.Remove(f); err != nil {
+			return fmt.Errorf("failed to remove file '%s' (%w), uninstall incomplete", f, fonts.WithAccessDenied(err))
+		}
+		opts.log().Debug("file removed", "path", f)
+		if dir := filepath.Dir(f); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return RefreshCache(ctx, opts.Options, dirs...)
+}
+
+// fontFiles checks that a font file exists and is a font, and returns the files that make up the font:
+// the .pfm and the .pfb file for Type 1 fonts, otherwise the file itself.
+func fontFiles(fontPath string) ([]string, error) {
+	if fonts.IsType1Path(fontPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fonts.ParseType1(pfm, pfb); err != nil {
+			return nil, err
+		}
+		return []string{pfm, pfb}, nil
+	}
+	if info, err := os.Stat(fontPath); err != nil || info.IsDir() {
+		return nil, fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	}
+	if _, err := fonts.DetectFormat(fontPath); err != nil {
+		return nil, err
+	}
+	return []string{fontPath}, nil
+}
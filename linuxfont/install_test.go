@@ -0,0 +1,191 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"fontctl/fonts"
+)
+
+// testFont returns a TrueType font with only a name table with the family, full and PostScript name.
+// padding makes fonts with the same names different.
+func testFont(family, fullName, postScriptName, padding string) []byte {
+	names := []struct {
+		id    uint16
+		value string
+	}{{1, family}, {4, fullName}, {6, postScriptName}}
+	var storage []byte
+	name := binary.BigEndian.AppendUint16(nil, 0)
+	name = binary.BigEndian.AppendUint16(name, uint16(len(names)))
+	name = binary.BigEndian.AppendUint16(name, uint16(6+12*len(names)))
+	for _, n := range names {
+		var value []byte
+		for _, u := range utf16.Encode([]rune(n.value)) {
+			value = binary.BigEndian.AppendUint16(value, u)
+		}
+		name = binary.BigEndian.AppendUint16(name, 3)      // Windows
+		name = binary.BigEndian.AppendUint16(name, 1)      // Unicode BMP
+		name = binary.BigEndian.AppendUint16(name, 0x0409) // English (US)
+		name = binary.BigEndian.AppendUint16(name, n.id)
+		name = binary.BigEndian.AppendUint16(name, uint16(len(value)))
+		name = binary.BigEndian.AppendUint16(name, uint16(len(storage)))
+		storage = append(storage, value...)
+	}
+	name = append(name, storage...)
+
+	data := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	data = binary.BigEndian.AppendUint16(data, 1) // numTables
+	data = append(data, make([]byte, 6)...)
+	data = append(data, "name"...)
+	data = binary.BigEndian.AppendUint32(data, 0)
+	data = binary.BigEndian.AppendUint32(data, 28)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(name)))
+	data = append(data, name...)
+	return append(data, padding...)
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testEnv points the user font dir, the runtime dir and the fontconfig conf dir to temp dirs and returns
+// the user font dir and options that don't run fc-cache.
+func testEnv(t *testing.T) (string, Options) {
+	t.Helper()
+	dataHome, runtime, configHome := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.Chmod(runtime, 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	return filepath.Join(dataHome, "fonts"), Options{DeferRefresh: true}
+}
+
+func TestInstallFontFromFile(t *testing.T) {
+	fontDir, opts := testEnv(t)
+	src := t.TempDir()
+	font := writeTestFile(t, filepath.Join(src, "Foo.ttf"), testFont("Foo", "Foo Regular", "Foo-Regular", "1"))
+	other := writeTestFile(t, filepath.Join(src, "other", "Foo.ttf"), testFont("Foo", "Foo Regular", "Foo-Regular", "2"))
+	installed := filepath.Join(fontDir, "Foo.ttf")
+
+	ctx := context.Background()
+	if err := InstallFontFromFile(ctx, font, InstallOptions{Options: opts}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fonts.VerifyInstalledCopy(font, installed); err != nil {
+		t.Fatalf("installed file: %v", err)
+	}
+	// installing the same font again changes nothing
+	info, _ := os.Stat(installed)
+	if err := InstallFontFromFile(ctx, font, InstallOptions{Options: opts}); err != nil {
+		t.Fatalf("reinstall error = %v", err)
+	}
+	if again, _ := os.Stat(installed); !again.ModTime().Equal(info.ModTime()) {
+		t.Errorf("reinstall wrote the installed file again")
+	}
+
+	if err := InstallFontFromFile(ctx, other, InstallOptions{Options: opts}); !errors.Is(err, fonts.ErrFileExistsAndIsDifferent) {
+		t.Errorf("install of a different file with the same name error = %v, want %v", err, fonts.ErrFileExistsAndIsDifferent)
+	}
+	if err := InstallFontFromFile(ctx, other, InstallOptions{Options: opts, OnConflict: fonts.ConflictOverwrite}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fonts.VerifyInstalledCopy(other, installed); err != nil {
+		t.Errorf("overwritten file: %v", err)
+	}
+
+	// only the identical file is uninstalled
+	if err := UninstallFontFromFile(ctx, font, UninstallOptions{Options: opts}); !errors.Is(err, fonts.ErrFileExistsAndIsDifferent) {
+		t.Errorf("uninstall of a different file error = %v, want %v", err, fonts.ErrFileExistsAndIsDifferent)
+	}
+	if err := UninstallFontFromFile(ctx, other, UninstallOptions{Options: opts}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(installed); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("installed file still there after uninstall (%v)", err)
+	}
+}
+
+func TestInstallRejected(t *testing.T) {
+	fontDir, opts := testEnv(t)
+	font := writeTestFile(t, filepath.Join(t.TempDir(), "Foo.ttf"), testFont("Foo", "Foo Regular", "Foo-Regular", ""))
+	var checked string
+	opts.Verify = func(fontPath, contentPath string) error {
+		checked = contentPath
+		return errors.New("not allowed")
+	}
+	if err := InstallFontFromFile(context.Background(), font, InstallOptions{Options: opts}); err == nil {
+		t.Fatal("InstallFontFromFile() succeeded with a rejected font")
+	}
+	if checked == font || filepath.Base(checked) != "Foo.ttf" {
+		t.Errorf("Verify got the content path '%s', want the staged copy of the font", checked)
+	}
+	if _, err := os.Stat(filepath.Join(fontDir, "Foo.ttf")); err == nil {
+		t.Errorf("rejected font was installed")
+	}
+}
+
+func TestUninstallFontByNameAndHash(t *testing.T) {
+	fontDir, opts := testEnv(t)
+	src := t.TempDir()
+	foo := writeTestFile(t, filepath.Join(src, "Foo.ttf"), testFont("Foo", "Foo Bold", "Foo-Bold", ""))
+	bar := writeTestFile(t, filepath.Join(src, "Bar.ttf"), testFont("Bar", "Bar Regular", "Bar-Regular", ""))
+	ctx := context.Background()
+	for _, f := range []string{foo, bar} {
+		if err := InstallFontFromFile(ctx, f, InstallOptions{Options: opts}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		uninstall func() error
+		removed   string
+	}{
+		{"by full name", func() error { return UninstallFontByName(ctx, "foo bold", UninstallOptions{Options: opts}) }, "Foo.ttf"},
+		{"not installed name", func() error { return UninstallFontByName(ctx, "Foo Bold", UninstallOptions{Options: opts}) }, ""},
+		{"by hash", func() error {
+			hash, err := fonts.HashFile(bar)
+			if err != nil {
+				return err
+			}
+			return UninstallFontByHash(ctx, fonts.FormatHash(hash), UninstallOptions{Options: opts})
+		}, "Bar.ttf"},
+		{"not installed hash", func() error {
+			return UninstallFontByHash(ctx, "sha256:"+strings.Repeat("0", 64), UninstallOptions{Options: opts})
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.uninstall()
+			if tt.removed == "" {
+				if !errors.Is(err, fonts.ErrNotInstalled) {
+					t.Errorf("uninstall of a font that isn't installed error = %v, want %v", err, fonts.ErrNotInstalled)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Join(fontDir, tt.removed)); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%s still installed (%v)", tt.removed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+
+	"fontctl/fonts"
+)
+
+// InstalledFont is a font file in one of the font dirs.
+type InstalledFont struct {
+	Name    string // full name of the first face, i.e. "Foo Bold"
+	Path    string // absolute path of the font file, the .pfm file for Type 1 fonts
+	PFBPath string // absolute path of the .pfb file of Type 1 fonts
+	User    bool   // in the user's font dir
+}
+
+// InstalledFonts returns the fonts in the system font dirs and the user's font dir, in that order.
+// Missing dirs are skipped. Only fonts fontctl can read are returned, see fonts.FindFontFiles.
+func InstalledFonts(opts Options) ([]InstalledFont, error) {
+	log := opts.log()
+	userDir, err := fontDir(false)
+	if err != nil {
+		return nil, err
+	}
+	dirs := slices.DeleteFunc(systemFontDirs(), func(d string) bool { return d == userDir })
+	dirs = append(dirs, userDir)
+
+	var list []InstalledFont
+	for i, dir := range dirs {
+		files, err := fonts.FindFontFiles(dir)
+		if err != nil {
+			log.Debug("can't read font dir, skipping it", "dir", dir, "error", err)
+			continue
+		}
+		for _, path := range files {
+			path, _ = filepath.Abs(path)
+			f := InstalledFont{Path: path, User: i == len(dirs)-1}
+			if fonts.IsType1Path(path) {
+				_, f.PFBPath, _ = fonts.ResolveType1Files(path)
+			}
+			if faces, err := fonts.ReadFaces(path); err == nil && len(faces) > 0 {
+				f.Name = faces[0].FullName
+				if f.Name == "" {
+					f.Name = faces[0].Family
+				}
+			}
+			if f.Name == "" {
+				f.Name = filepath.Base(path)
+			}
+			list = append(list, f)
+		}
+	}
+	return list, nil
+}
+
+// IsInstalled reports whether a font file is installed: a file with the same name and content is in
+// the user's or the system font dir. Type 1 fonts are checked by their .pfm file.
+func IsInstalled(fontPath string, systemWide bool, opts Options) (bool, error) {
+	if fonts.IsType1Path(fontPath) {
+		pfm, _, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return false, err
+		}
+		fontPath = pfm
+	}
+	dir, err := fontDir(systemWide)
+	if err != nil {
+		return false, err
+	}
+	hash, err := fonts.HashFile(fontPath)
+	if err != nil {
+		return false, err
+	}
+	installedHash, err := fonts.HashFile(filepath.Join(dir, filepath.Base(fontPath)))
+	return err == nil && bytes.Equal(hash, installedHash), nil
+}
@@ -0,0 +1,272 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"fontctl/fonts"
+)
+
+// ConfFileName is the fontconfig conf file that adds the staging dir of the loaded fonts to the font
+// dirs. It is written to $XDG_CONFIG_HOME/fontconfig/conf.d, which the default fontconfig configuration
+// includes, and removed when the last font is unloaded.
+const ConfFileName = "90-fontctl-loaded.conf"
+
+// LoadFontFromFile makes a font temporarily available to applications, until it gets unloaded or the
+// user logs out. Applications that already run see the font when fontconfig rescans the font dirs.
+func LoadFontFromFile(ctx context.Context, fontPath string, opts Options) error {
+	return LoadFonts(ctx, []string{fontPath}, opts)
+}
+
+// UnloadFontFromFile removes a font that was loaded with LoadFontFromFile.
+func UnloadFontFromFile(ctx context.Context, fontPath string, opts Options) error {
+	return UnloadFonts(ctx, []string{fontPath}, opts)
+}
+
+// LoadFonts loads several fonts with a single fontconfig cache refresh. If one of them fails to load,
+// the fonts loaded before are unloaded again.
+func LoadFonts(ctx context.Context, fontPaths []string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	dir, err := loadedDir()
+	if err != nil {
+		return err
+	}
+	var loaded, linked []string
+	rollback := func() {
+		for _, link := range linked {
+			os.Remove(link)
+		}
+	}
+	for _, fontPath := range fontPaths {
+		log.Debug("using font file", "path", fontPath)
+		files, err := fontFiles(fontPath)
+		if err == nil {
+			// Type 1 fonts are checked as "<pfm>|<pfb>" pair like on Windows, fontconfig reads the .pfb
+			verifyPath := strings.Join(files, "|")
+			err = opts.verify(verifyPath, verifyPath)
+		}
+		var path string
+		if err == nil {
+			path, err = filepath.Abs(files[len(files)-1])
+		}
+		var created bool
+		if err == nil {
+			created, err = linkFont(dir, path)
+		}
+		if err != nil {
+			rollback()
+			return err
+		}
+		loaded = append(loaded, path)
+		if created {
+			linked = append(linked, linkPath(dir, path))
+		}
+	}
+	if err := writeConf(dir); err != nil {
+		rollback()
+		return err
+	}
+	opts.trackLoad(loaded...)
+	return RefreshCache(ctx, opts, dir)
+}
+
+// UnloadFonts unloads several fonts with a single fontconfig cache refresh. It tries all fonts, even if
+// some fail, and returns the first error. Font files don't need to exist anymore. A font that was loaded
+// more than once stays loaded until it was unloaded as often, if opts.State tracks the loads.
+func UnloadFonts(ctx context.Context, fontPaths []string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir, err := loadedDir()
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	var unloaded []string
+	for _, fontPath := range fontPaths {
+		path, err := resolveLoadPath(fontPath)
+		if errors.Is(err, fonts.ErrFileNotFound) {
+			// the link still knows a deleted (or moved) font by its path
+			path, err = filepath.Abs(fontPath)
+		}
+		if err == nil {
+			if _, err = os.Lstat(linkPath(dir, path)); err != nil {
+				err = fmt.Errorf("font '%s' is not loaded", fontPath)
+			}
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		unloaded = append(unloaded, path)
+	}
+	for _, path := range opts.trackUnload(unloaded...) {
+		if err := os.Remove(linkPath(dir, path)); err != nil && !errors.Is(err, fs.ErrNotExist) && firstErr == nil {
+			firstErr = fmt.Errorf("failed to unload '%s' (%w)", path, err)
+		}
+	}
+	if err := removeConfIfEmpty(dir); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := RefreshCache(ctx, opts, dir); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// resolveLoadPath checks that the font file exists and returns the absolute path of the file fontconfig
+// reads, the .pfb file for Type 1 fonts.
+func resolveLoadPath(fontPath string) (string, error) {
+	files, err := fontFiles(fontPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(files[len(files)-1])
+}
+
+// loadedDir returns the staging dir for loaded fonts, $XDG_RUNTIME_DIR/fontctl/fonts, and creates it.
+// The runtime dir is removed when the user logs out, which unloads the fonts like a reboot does on
+// Windows. Without a runtime dir, a private dir in the temp dir is used.
+func loadedDir() (string, error) {
+	runtime, err := privateRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(runtime, "fonts")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("can't create dir for loaded fonts '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// privateRuntimeDir returns runtimeDir and creates it. The temp dir is shared, so it fails if somebody
+// else prepared the dir for us, who could swap the loaded fonts or the state file.
+func privateRuntimeDir() (string, error) {
+	dir := runtimeDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("can't create runtime dir '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+	if err := checkPrivateDir(dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// checkPrivateDir checks that dir is a directory (not a link to one) of the current user, which other
+// users can't write to.
+func checkPrivateDir(dir string) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("'%s' is not a directory owned by the current user", dir)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("'%s' is writable by other users (mode %s)", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// runtimeDir returns fontctl's dir in $XDG_RUNTIME_DIR, or in the temp dir if it isn't set.
+func runtimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, "fontctl")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("fontctl-%d", os.Getuid()))
+}
+
+// linkPath returns the path of the link to a loaded font in the staging dir. The name starts with a
+// hash of the font path, so different fonts with the same file name can be loaded at the same time.
+func linkPath(dir, fontPath string) string {
+	sum := sha256.Sum256([]byte(fontPath))
+	return filepath.Join(dir, hex.EncodeToString(sum[:6])+"-"+filepath.Base(fontPath))
+}
+
+// linkFont links a font into the staging dir. created is false if the font was already linked.
+func linkFont(dir, fontPath string) (created bool, err error) {
+	link := linkPath(dir, fontPath)
+	if target, err := os.Readlink(link); err == nil && target == fontPath {
+		return false, nil
+	}
+	os.Remove(link)
+	if err := os.Symlink(fontPath, link); err != nil {
+		return false, fmt.Errorf("failed to load '%s' (%w)", fontPath, fonts.WithAccessDenied(err))
+	}
+	return true, nil
+}
+
+// confPath returns the path of the fontconfig conf file, see ConfFileName.
+func confPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" || !filepath.IsAbs(configHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("can't find the user's home dir (%w)", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "fontconfig", "conf.d", ConfFileName), nil
+}
+
+// writeConf writes the fontconfig conf file for the staging dir, unless it is up to date.
+func writeConf(dir string) error {
+	path, err := confPath()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString("<!DOCTYPE fontconfig SYSTEM \"urn:fontconfig:fonts.dtd\">\n")
+	buf.WriteString("<!-- Written by fontctl load, removed when the last font is unloaded. -->\n")
+	buf.WriteString("<fontconfig>\n  <dir>")
+	xml.EscapeText(&buf, []byte(dir))
+	buf.WriteString("</dir>\n</fontconfig>\n")
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, buf.Bytes()) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("can't create fontconfig conf dir '%s' (%w)", filepath.Dir(path), fonts.WithAccessDenied(err))
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("can't write fontconfig conf file '%s' (%w)", path, fonts.WithAccessDenied(err))
+	}
+	return nil
+}
+
+// removeConfIfEmpty removes the fontconfig conf file once no font is loaded anymore.
+func removeConfIfEmpty(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) > 0 {
+		return err
+	}
+	path, err := confPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("can't remove fontconfig conf file '%s' (%w)", path, fonts.WithAccessDenied(err))
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadRefCount(t *testing.T) {
+	_, opts := testEnv(t)
+	store, err := DefaultStateStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.State = store
+	src := t.TempDir()
+	a := writeTestFile(t, filepath.Join(src, "A.ttf"), testFont("A", "A Regular", "A-Regular", ""))
+	b := writeTestFile(t, filepath.Join(src, "B.ttf"), testFont("B", "B Regular", "B-Regular", ""))
+	dir := filepath.Join(runtimeDir(), "fonts")
+	conf, err := confPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	isLoaded := func(path string) bool {
+		_, err := os.Lstat(linkPath(dir, path))
+		return err == nil
+	}
+	// loadCount returns the number of loads without unload
+	loadCount := func() int {
+		t.Helper()
+		loaded, err := LoadedFonts(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, e := range loaded {
+			n += e.RefCount
+		}
+		return n
+	}
+
+	ctx := context.Background()
+	// two jobs load A, one of them B too
+	if err := LoadFonts(ctx, []string{a}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFonts(ctx, []string{a, b}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !isLoaded(a) || !isLoaded(b) || loadCount() != 3 {
+		t.Fatalf("after loading, A loaded %t, B loaded %t, %d loads tracked, want both loaded and 3 loads", isLoaded(a), isLoaded(b), loadCount())
+	}
+	if _, err := os.Stat(conf); err != nil {
+		t.Errorf("no fontconfig conf file for the loaded fonts (%v)", err)
+	}
+
+	if err := UnloadFonts(ctx, []string{a, b}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !isLoaded(a) || isLoaded(b) {
+		t.Errorf("after the first unload, A loaded %t, B loaded %t, want A still loaded for the other job", isLoaded(a), isLoaded(b))
+	}
+	if err := UnloadFonts(ctx, []string{b}, opts); err == nil {
+		t.Error("UnloadFonts() of a font that isn't loaded succeeded")
+	}
+	if err := UnloadFonts(ctx, []string{a}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if isLoaded(a) || loadCount() != 0 {
+		t.Errorf("after the last unload, A loaded %t, %d loads tracked, want none", isLoaded(a), loadCount())
+	}
+	if _, err := os.Stat(conf); !os.IsNotExist(err) {
+		t.Errorf("fontconfig conf file left after the last unload (%v)", err)
+	}
+
+	// unload --all drops every load
+	if err := LoadFonts(ctx, []string{a, a, b}, opts); err != nil {
+		t.Fatal(err)
+	}
+	unloaded, err := UnloadAllFonts(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unloaded) != 2 || isLoaded(a) || isLoaded(b) || loadCount() != 0 {
+		t.Errorf("UnloadAllFonts() unloaded %d fonts, A loaded %t, B loaded %t, want both unloaded", len(unloaded), isLoaded(a), isLoaded(b))
+	}
+	if _, err := os.Stat(conf); !os.IsNotExist(err) {
+		t.Errorf("fontconfig conf file left after unloading all fonts (%v)", err)
+	}
+}
+
+// testType1 writes a minimal .pfm and .pfb pair with the face name Foo and returns the paths.
+func testType1(t *testing.T, dir string) (pfm, pfb string) {
+	t.Helper()
+	data := make([]byte, 160)
+	binary.LittleEndian.PutUint16(data[0:], 0x0100) // dfVersion
+	binary.LittleEndian.PutUint32(data[105:], 147)  // dfFace
+	copy(data[147:], "Foo\x00")
+	pfm = writeTestFile(t, filepath.Join(dir, "Foo.pfm"), data)
+	pfb = writeTestFile(t, filepath.Join(dir, "Foo.pfb"), []byte{0x80, 0x01})
+	return pfm, pfb
+}
+
+func TestLoadVerifiesType1Pair(t *testing.T) {
+	_, opts := testEnv(t)
+	pfm, pfb := testType1(t, t.TempDir())
+	var checked []string
+	opts.Verify = func(fontPath, contentPath string) error {
+		checked = append(checked, fontPath)
+		return errors.New("not allowed")
+	}
+	if err := LoadFonts(context.Background(), []string{pfb}, opts); err == nil {
+		t.Fatal("LoadFonts() succeeded with a rejected font")
+	}
+	if want := []string{pfm + "|" + pfb}; !slices.Equal(checked, want) {
+		t.Errorf("Verify got %q, want the <pfm>|<pfb> pair %q", checked, want)
+	}
+	if _, err := os.Lstat(linkPath(filepath.Join(runtimeDir(), "fonts"), pfb)); err == nil {
+		t.Error("rejected font was loaded")
+	}
+}
+
+func TestCheckPrivateDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    os.FileMode
+		wantErr bool
+	}{
+		{"private", 0700, false},
+		{"readable by others", 0755, false},
+		{"writable by the group", 0770, true},
+		{"writable by others", 0703, true},
+		{"world writable", 0777, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "fontctl")
+			if err := os.Mkdir(dir, 0700); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(dir, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := checkPrivateDir(dir); (err != nil) != tt.wantErr {
+				t.Errorf("checkPrivateDir() of mode %s error = %v, want error %t", tt.mode, err, tt.wantErr)
+			}
+		})
+	}
+
+	target := t.TempDir()
+	link := filepath.Join(t.TempDir(), "fontctl")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkPrivateDir(link); err == nil {
+		t.Error("checkPrivateDir() accepted a link to a dir")
+	}
+
+	// a runtime dir somebody else prepared is refused
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	if err := os.Mkdir(filepath.Join(runtime, "fontctl"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(runtime, "fontctl"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadedDir(); err == nil {
+		t.Error("loadedDir() accepted a world writable runtime dir")
+	}
+}
@@ -0,0 +1,65 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"io"
+	"log/slog"
+
+	"fontctl/fonts"
+	"fontctl/state"
+)
+
+// Options are the settings shared by all linuxfont operations.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// State records the fonts loaded and unloaded by LoadFontFromFile, LoadFonts and their unload
+	// counterparts, see DefaultStateStore. Can be nil.
+	State *state.Store
+	// DeferRefresh skips the fontconfig cache refreshes, for callers that call RefreshCache (without
+	// DeferRefresh) once after a batch of operations.
+	DeferRefresh bool
+	// Verify, if set, is called for every font file before it is installed or loaded. An error rejects
+	// the font, see trust.Verifier.VerifyCopy. contentPath is the file whose content is checked: the
+	// staged copy that gets installed, or fontPath itself for loads.
+	Verify func(fontPath, contentPath string) error
+}
+
+// InstallOptions are the settings for InstallFontFromFile.
+type InstallOptions struct {
+	Options
+	// SystemWide installs into /usr/local/share/fonts instead of the user's font dir. Requires root.
+	SystemWide bool
+	// OnConflict decides what happens if a different file with the same name is already installed.
+	// The zero value is fonts.ConflictFail.
+	OnConflict fonts.ConflictPolicy
+}
+
+// UninstallOptions are the settings for UninstallFontFromFile.
+type UninstallOptions struct {
+	Options
+	// SystemWide uninstalls from /usr/local/share/fonts instead of the user's font dir. Requires root.
+	SystemWide bool
+}
+
+// verify calls the Verify hook for a font file, or for the staged copy of it at contentPath.
+func (o Options) verify(fontPath, contentPath string) error {
+	if o.Verify == nil {
+		return nil
+	}
+	if err := o.Verify(fontPath, contentPath); err != nil {
+		o.log().Error("font file rejected", "path", fontPath, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return discardLogger
+	}
+	return o.Logger
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
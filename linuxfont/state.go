@@ -0,0 +1,162 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fontctl/fonts"
+	"fontctl/state"
+)
+
+// BootTime returns when the system was started, read from /proc/stat. It is the zero time if /proc
+// isn't available.
+func BootTime() time.Time {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			if sec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return time.Unix(sec, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// DefaultStateStore returns the state file for the fonts loaded in the current login session,
+// loaded.json next to the staging dir of the loaded fonts (see LoadFontFromFile). It fails if the dir
+// they are in belongs to another user or other users can write to it.
+func DefaultStateStore() (*state.Store, error) {
+	dir, err := privateRuntimeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &state.Store{
+		Path:     filepath.Join(dir, "loaded.json"),
+		BootTime: BootTime(),
+	}, nil
+}
+
+// LoadedFonts returns the fonts loaded by fontctl that are still loaded. Requires opts.State.
+func LoadedFonts(opts Options) ([]state.Entry, error) {
+	if opts.State == nil {
+		return nil, fmt.Errorf("no state file configured")
+	}
+	st, err := opts.State.Load()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(runtimeDir(), "fonts")
+	// the runtime dir is gone after a logout, and with it the fonts
+	loaded := slices.DeleteFunc(st.Fonts, func(e state.Entry) bool {
+		_, err := os.Lstat(linkPath(dir, e.Path))
+		return err != nil
+	})
+	if loaded == nil {
+		return []state.Entry{}, nil
+	}
+	return loaded, nil
+}
+
+// UnloadAllFonts unloads all fonts loaded by fontctl and returns them. Requires opts.State.
+func UnloadAllFonts(ctx context.Context, opts Options) ([]state.Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.State == nil {
+		return nil, fmt.Errorf("no state file configured")
+	}
+	dir, err := loadedDir()
+	if err != nil {
+		return nil, err
+	}
+	log := opts.log()
+	var unloaded []state.Entry
+	err = opts.State.Update(func(st *state.State) error {
+		for _, e := range st.Fonts {
+			if err := os.Remove(linkPath(dir, e.Path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Warn("failed to unload tracked font, dropping it", "path", e.Path, "error", err)
+			}
+			unloaded = append(unloaded, e)
+		}
+		st.Fonts = st.Fonts[:0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := removeConfIfEmpty(dir); err != nil {
+		return unloaded, err
+	}
+	if len(unloaded) > 0 {
+		if err := RefreshCache(ctx, opts, dir); err != nil {
+			return unloaded, err
+		}
+	}
+	return unloaded, nil
+}
+
+// trackLoad records fonts loaded by fontctl in the state file, if there is one. Failing to track
+// a font shouldn't fail the load, so errors are only logged.
+func (o Options) trackLoad(paths ...string) {
+	if o.State == nil {
+		return
+	}
+	now := time.Now()
+	err := o.State.Update(func(st *state.State) error {
+		for _, p := range paths {
+			st.Add(p, fileHash(p), now)
+		}
+		return nil
+	})
+	if err != nil {
+		o.log().Warn("failed to record loaded fonts in state file", "path", o.State.Path, "error", err)
+	}
+}
+
+// trackUnload records fonts unloaded by fontctl in the state file and returns the ones that are not
+// loaded anymore, whose links can be removed. Without a state file, that's all of them.
+func (o Options) trackUnload(paths ...string) []string {
+	if o.State == nil {
+		return paths
+	}
+	var released []string
+	err := o.State.Update(func(st *state.State) error {
+		for _, p := range paths {
+			st.Remove(p)
+			if !slices.ContainsFunc(st.Fonts, func(e state.Entry) bool { return e.Path == p }) {
+				released = append(released, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		o.log().Warn("failed to record unloaded fonts in state file", "path", o.State.Path, "error", err)
+		return paths
+	}
+	return released
+}
+
+// fileHash returns the hash of a font file for the state file.
+func fileHash(path string) string {
+	hash, err := fonts.HashFile(path)
+	if err != nil {
+		return ""
+	}
+	return fonts.FormatHash(hash)
+}
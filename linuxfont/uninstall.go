@@ -0,0 +1,131 @@
+//go:build linux
+
+package linuxfont
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// UninstallFontByName uninstalls the font file in the font dir that has a face with the given full name
+// or PostScript name (i.e. "Foo Bold" or "Foo-Bold"), without needing the original font file. Names are
+// compared case-insensitively.
+func UninstallFontByName(ctx context.Context, fontName string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	dir, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+	files, err := fonts.FindFontFiles(dir)
+	if err != nil {
+		return fmt.Errorf("%w: no font named '%s' is installed (%w)", fonts.ErrNotInstalled, fontName, err)
+	}
+	for _, path := range files {
+		faces, err := fonts.ReadFaces(path)
+		if err != nil {
+			log.Debug("can't read font names, skipping file", "path", path, "error", err)
+			continue
+		}
+		for _, f := range faces {
+			if !strings.EqualFold(f.FullName, fontName) && !strings.EqualFold(f.PostScriptName, fontName) {
+				continue
+			}
+			log.Debug("found installed font", "name", fontName, "path", path)
+			return UninstallFontByPath(ctx, path, opts)
+		}
+	}
+	return fmt.Errorf("%w: no font named '%s' is installed", fonts.ErrNotInstalled, fontName)
+}
+
+// UninstallFontByPath uninstalls an installed font file. The file has to be in the font dir or one of
+// its subdirs.
+func UninstallFontByPath(ctx context.Context, installedPath string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+	installedPath, err = filepath.Abs(installedPath)
+	if err != nil {
+		return err
+	}
+	if !isInDir(dir, installedPath) {
+		return fmt.Errorf("'%s' is not in the font dir '%s', uninstall aborted", installedPath, dir)
+	}
+
+	if fonts.IsType1Path(installedPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(installedPath)
+		if err != nil {
+			return err
+		}
+		return uninstallFiles(ctx, []string{pfm, pfb}, opts)
+	}
+	if info, err := os.Stat(installedPath); err != nil || info.IsDir() {
+		return fmt.Errorf("%w '%s'", fonts.ErrNotInstalled, installedPath)
+	}
+	return uninstallFiles(ctx, []string{installedPath}, opts)
+}
+
+// UninstallFontByHash uninstalls all font files in the font dir with the given content hash
+// ("sha256:<hex>", see fonts.ParseHash).
+func UninstallFontByHash(ctx context.Context, hash string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	want, err := fonts.ParseHash(hash)
+	if err != nil {
+		return err
+	}
+	dir, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+
+	var matches []string
+	err = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".fontctl-new") {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, err := fonts.HashFile(path)
+		if err != nil {
+			log.Debug("can't hash file, skipping it", "path", path, "error", err)
+			return nil
+		}
+		if bytes.Equal(h, want) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("can't read font dir '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("%w: no file with hash %s in '%s'", fonts.ErrNotInstalled, fonts.FormatHash(want), dir)
+	}
+
+	for _, path := range matches {
+		log.Debug("found installed file with matching hash", "path", path, "hash", fonts.FormatHash(want))
+		if err := UninstallFontByPath(ctx, path, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
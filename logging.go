@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// logFlags are the global flags that configure logging.
+var logFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:    "debug",
+		Aliases: []string{"d"},
+		Usage:   "enable verbose debug logging (same as --log-level debug)",
+	},
+	&cli.StringFlag{
+		Name:  "log-level",
+		Usage: "log level: debug, info, warn, error or none",
+		Value: "none",
+	},
+	&cli.StringFlag{
+		Name:  "log-format",
+		Usage: "log format: text or json",
+		Value: "text",
+	},
+	&cli.StringFlag{
+		Name:  "log-file",
+		Usage: "append log messages to this file instead of writing them to stderr",
+	},
+}
+
+// newLogger builds the logger for the log flags. The returned closer must be closed when done
+// (it's a no-op when logging to stderr). Logging is disabled unless a log level is set.
+func newLogger(c *cli.Command) (*slog.Logger, io.Closer, error) {
+	level := strings.ToLower(c.String("log-level"))
+	if c.Bool("debug") {
+		level = "debug"
+	}
+
+	var slogLevel slog.Level
+	switch level {
+	case "none":
+		return slog.New(slog.NewTextHandler(io.Discard, nil)), io.NopCloser(nil), nil
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "info":
+		slogLevel = slog.LevelInfo
+	case "warn":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	default:
+		return nil, nil, cli.Exit(fmt.Sprintf("invalid log level: %s (must be one of debug, info, warn, error, none)", level), exitUsage)
+	}
+
+	var out io.Writer = os.Stderr
+	var closer io.Closer = io.NopCloser(nil)
+	if path := c.String("log-file"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, cli.Exit(fmt.Sprintf("Error - can't open log file '%s' (%s)", path, err), exitError)
+		}
+		out, closer = f, f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: slogLevel}
+	switch strings.ToLower(c.String("log-format")) {
+	case "text":
+		return slog.New(slog.NewTextHandler(out, handlerOpts)), closer, nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(out, handlerOpts)), closer, nil
+	}
+	closer.Close()
+	return nil, nil, cli.Exit(fmt.Sprintf("invalid log format: %s (must be one of text, json)", c.String("log-format")), exitUsage)
+}
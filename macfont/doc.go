@@ -0,0 +1,11 @@
+// Package macfont stages fonts in the font dir layout of macOS under a root dir, to prepare font payloads
+// for Macs on other systems: /Library/Fonts for all users and /Users/<name>/Library/Fonts for a user.
+// Staging is a pure file operation, nothing is installed on the running system.
+//
+// Besides TrueType and OpenType fonts, the package handles the font suitcases of classic Mac OS: .dfont
+// files, which keep the suitcase resources in the data fork, are staged as they are. Suitcases that lost
+// their resource fork on the way (the data fork is empty) are rebuilt as .dfont files from their AppleDouble
+// "._" file if there is one.
+//
+// The package is operating system independent.
+package macfont
@@ -0,0 +1,43 @@
+package macfont
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Layout is a macOS font dir layout under a root dir, which stands for the boot volume of the Mac.
+type Layout struct {
+	// Root is the dir the layout is created in.
+	Root string
+	// SystemWide stages into Library/Fonts for all users instead of the user's font dir.
+	SystemWide bool
+	// User is the name of the Mac user whose font dir Users/<User>/Library/Fonts is used. Empty is the
+	// name of the current user.
+	User string
+}
+
+// FontDir returns the font dir of the layout.
+func (l Layout) FontDir() (string, error) {
+	if l.Root == "" {
+		return "", errors.New("no root dir for the macOS layout")
+	}
+	if l.SystemWide {
+		return filepath.Join(l.Root, "Library", "Fonts"), nil
+	}
+	name := l.User
+	if name == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("can't get the current user name (%w)", err)
+		}
+		// Windows user names include the domain
+		name = u.Username[strings.LastIndexAny(u.Username, `\`)+1:]
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid Mac user name '%s'", name)
+	}
+	return filepath.Join(l.Root, "Users", name, "Library", "Fonts"), nil
+}
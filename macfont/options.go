@@ -0,0 +1,43 @@
+package macfont
+
+import (
+	"io"
+	"log/slog"
+
+	"fontctl/fonts"
+)
+
+// Options are the settings for StageFontFromFile.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// Layout is where fonts are staged.
+	Layout Layout
+	// OnConflict decides what happens if a different file with the same name is already staged.
+	// The zero value is fonts.ConflictFail.
+	OnConflict fonts.ConflictPolicy
+	// Verify, if set, is called for every font file before it is staged. An error rejects the font, see
+	// trust.Verifier.
+	Verify func(fontPath string) error
+}
+
+// verify calls the Verify hook for a font file.
+func (o Options) verify(fontPath string) error {
+	if o.Verify == nil {
+		return nil
+	}
+	if err := o.Verify(fontPath); err != nil {
+		o.log().Error("font file rejected", "path", fontPath, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return discardLogger
+	}
+	return o.Logger
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
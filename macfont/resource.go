@@ -0,0 +1,89 @@
+package macfont
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// AppleSingle/AppleDouble magic numbers and entry IDs (RFC 1740).
+const (
+	appleSingleMagic  = 0x00051600
+	appleDoubleMagic  = 0x00051607
+	entryResourceFork = 2
+)
+
+var errNotResourceFork = errors.New("not a resource fork")
+
+// resourceTypes parses a resource fork and returns how many resources of each type it has, i.e.
+// {"sfnt": 4, "FOND": 1}. Font suitcases keep TrueType fonts in "sfnt", bitmap fonts in "NFNT" and the
+// family tables in "FOND" resources.
+func resourceTypes(data []byte) (map[string]int, error) {
+	if len(data) < 16 {
+		return nil, errNotResourceFork
+	}
+	dataOffset, mapOffset := uint64(be32(data[0:])), uint64(be32(data[4:]))
+	dataLen, mapLen := uint64(be32(data[8:])), uint64(be32(data[12:]))
+	size := uint64(len(data))
+	if dataOffset < 16 || mapOffset < 16 || dataOffset+dataLen > size || mapOffset+mapLen > size || mapLen < 30 {
+		return nil, errNotResourceFork
+	}
+	resMap := data[mapOffset : mapOffset+mapLen]
+	typeListOffset := int(binary.BigEndian.Uint16(resMap[24:]))
+	if typeListOffset+2 > len(resMap) {
+		return nil, errNotResourceFork
+	}
+	typeList := resMap[typeListOffset:]
+	// counts are stored minus one, an empty type list has 0xFFFF
+	numTypes := int(int16(binary.BigEndian.Uint16(typeList))) + 1
+	if 2+8*numTypes > len(typeList) {
+		return nil, errNotResourceFork
+	}
+	types := make(map[string]int, numTypes)
+	for i := 0; i < numTypes; i++ {
+		entry := typeList[2+8*i:]
+		types[string(entry[:4])] += int(binary.BigEndian.Uint16(entry[4:])) + 1
+	}
+	return types, nil
+}
+
+// appleDoubleResourceFork returns the resource fork stored in an AppleDouble or AppleSingle file, or nil
+// if there is none. ok is false if data isn't an AppleDouble or AppleSingle file.
+func appleDoubleResourceFork(data []byte) (fork []byte, ok bool) {
+	if len(data) < 26 {
+		return nil, false
+	}
+	if magic := be32(data); magic != appleDoubleMagic && magic != appleSingleMagic {
+		return nil, false
+	}
+	numEntries := int(binary.BigEndian.Uint16(data[24:]))
+	for i := 0; i < numEntries; i++ {
+		pos := 26 + 12*i
+		if pos+12 > len(data) {
+			break
+		}
+		id, offset, length := be32(data[pos:]), uint64(be32(data[pos+4:])), uint64(be32(data[pos+8:]))
+		if id == entryResourceFork && offset+length <= uint64(len(data)) && length > 0 {
+			return data[offset : offset+length], true
+		}
+	}
+	return nil, true
+}
+
+// typeSummary lists resource types for messages, i.e. "FOND, NFNT".
+func typeSummary(types map[string]int) string {
+	names := make([]string, 0, len(types))
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "no resources"
+	}
+	return strings.Join(names, ", ")
+}
+
+func be32(b []byte) uint32 {
+	return binary.BigEndian.Uint32(b)
+}
@@ -0,0 +1,131 @@
+package macfont
+
+import (
+	"encoding/binary"
+	"maps"
+	"testing"
+)
+
+type resourceType struct {
+	name  string
+	count int
+}
+
+// resourceFork returns a resource fork with an empty data section and a map that lists the types.
+func resourceFork(types ...resourceType) []byte {
+	resMap := make([]byte, 28, 30+8*len(types))
+	binary.BigEndian.PutUint16(resMap[24:], 28) // type list offset
+	binary.BigEndian.PutUint16(resMap[26:], uint16(30+8*len(types)))
+	resMap = binary.BigEndian.AppendUint16(resMap, uint16(len(types)-1))
+	for i, t := range types {
+		resMap = append(resMap, t.name...)
+		resMap = binary.BigEndian.AppendUint16(resMap, uint16(t.count-1))
+		resMap = binary.BigEndian.AppendUint16(resMap, uint16(2+8*len(types)+i)) // reference list, unused
+	}
+	header := make([]byte, 16)
+	binary.BigEndian.PutUint32(header[0:], 16)
+	binary.BigEndian.PutUint32(header[4:], 16)
+	binary.BigEndian.PutUint32(header[12:], uint32(len(resMap)))
+	return append(header, resMap...)
+}
+
+// appleDouble returns an AppleDouble file with a Finder info entry and, if fork isn't nil, a resource fork
+// entry.
+func appleDouble(magic uint32, fork []byte) []byte {
+	finderInfo := make([]byte, 32)
+	type entry struct {
+		id   uint32
+		data []byte
+	}
+	entries := []entry{{9, finderInfo}}
+	if fork != nil {
+		entries = append(entries, entry{entryResourceFork, fork})
+	}
+	data := binary.BigEndian.AppendUint32(nil, magic)
+	data = binary.BigEndian.AppendUint32(data, 0x00020000)
+	data = append(data, make([]byte, 16)...) // filler
+	data = binary.BigEndian.AppendUint16(data, uint16(len(entries)))
+	offset := len(data) + 12*len(entries)
+	for _, e := range entries {
+		data = binary.BigEndian.AppendUint32(data, e.id)
+		data = binary.BigEndian.AppendUint32(data, uint32(offset))
+		data = binary.BigEndian.AppendUint32(data, uint32(len(e.data)))
+		offset += len(e.data)
+	}
+	for _, e := range entries {
+		data = append(data, e.data...)
+	}
+	return data
+}
+
+func TestResourceTypes(t *testing.T) {
+	valid := resourceFork(resourceType{"sfnt", 2})
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]int
+	}{
+		{"TrueType suitcase", resourceFork(resourceType{"FOND", 1}, resourceType{"sfnt", 4}), map[string]int{"FOND": 1, "sfnt": 4}},
+		{"bitmap suitcase", resourceFork(resourceType{"FOND", 1}, resourceType{"NFNT", 3}), map[string]int{"FOND": 1, "NFNT": 3}},
+		{"no resources", resourceFork(), map[string]int{}},
+		{"too short", valid[:15], nil},
+		{"truncated map", valid[:len(valid)-1], nil},
+		{"data offset in header", append([]byte{0, 0, 0, 8}, valid[4:]...), nil},
+		{"map too short", append(append([]byte{}, valid[:12]...), 0, 0, 0, 29), nil},
+		{"type list out of map", func() []byte {
+			data := append([]byte{}, valid...)
+			binary.BigEndian.PutUint16(data[16+24:], 0xFFF0)
+			return data
+		}(), nil},
+		{"too many types", func() []byte {
+			data := append([]byte{}, valid...)
+			binary.BigEndian.PutUint16(data[16+28:], 10)
+			return data
+		}(), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resourceTypes(tt.data)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("resourceTypes() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resourceTypes() failed: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("resourceTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppleDoubleResourceFork(t *testing.T) {
+	fork := resourceFork(resourceType{"sfnt", 1})
+	tests := []struct {
+		name     string
+		data     []byte
+		wantFork bool
+		wantOK   bool
+	}{
+		{"AppleDouble", appleDouble(appleDoubleMagic, fork), true, true},
+		{"AppleSingle", appleDouble(appleSingleMagic, fork), true, true},
+		{"without resource fork", appleDouble(appleDoubleMagic, nil), false, true},
+		{"wrong magic", appleDouble(0x00051608, fork), false, false},
+		{"too short", appleDouble(appleDoubleMagic, fork)[:25], false, false},
+		{"fork out of file", appleDouble(appleDoubleMagic, fork)[:80], false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := appleDoubleResourceFork(tt.data)
+			if ok != tt.wantOK || (got != nil) != tt.wantFork {
+				t.Fatalf("appleDoubleResourceFork() = %d bytes, %t, want fork %t, %t", len(got), ok, tt.wantFork, tt.wantOK)
+			}
+			if tt.wantFork && string(got) != string(fork) {
+				t.Errorf("appleDoubleResourceFork() returned the wrong data")
+			}
+		})
+	}
+}
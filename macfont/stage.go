@@ -0,0 +1,188 @@
+package macfont
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// DfontExt is the file extension of data fork suitcases.
+const DfontExt = ".dfont"
+
+// source is a font prepared for staging.
+type source struct {
+	path string // file the font is read from, the AppleDouble file for rebuilt suitcases
+	name string // file name in the font dir
+	data []byte // content of rebuilt suitcases, nil to copy path
+	sfnt bool   // TrueType/OpenType file, which has a version to compare
+}
+
+// StageFontFromFile copies a font into the font dir of opts.Layout and returns the staged path. TrueType
+// and OpenType fonts and .dfont files are copied as they are, a suitcase without its resource fork is
+// rebuilt as .dfont file from its AppleDouble file (see the package doc). Windows bitmap fonts and Type 1
+// fonts in .pfm/.pfb files can't be used on a Mac and are rejected. If the font was skipped because of
+// opts.OnConflict, the staged path is empty.
+func StageFontFromFile(ctx context.Context, fontPath string, opts Options) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	log := opts.log()
+	log.Debug("using font file", "path", fontPath)
+
+	src, err := prepare(fontPath)
+	if err != nil {
+		return "", err
+	}
+	if err := opts.verify(src.path); err != nil {
+		return "", err
+	}
+	data := src.data
+	if data == nil {
+		if data, err = os.ReadFile(src.path); err != nil {
+			return "", fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, src.path, fonts.WithAccessDenied(err))
+		}
+	}
+
+	dir, err := opts.Layout.FontDir()
+	if err != nil {
+		return "", err
+	}
+	log.Debug("using destination font dir", "dir", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("can't create font dir '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+
+	dst := filepath.Join(dir, src.name)
+	if existing, err := os.ReadFile(dst); err == nil {
+		if sha256.Sum256(existing) == sha256.Sum256(data) {
+			log.Debug("destination file is identical, skipping copy", "source", src.path, "dest", dst)
+			return dst, nil
+		}
+		replace, err := resolveConflict(src, dst, opts)
+		if err != nil || !replace {
+			return "", err
+		}
+	}
+	if err := writeFile(dst, data); err != nil {
+		return "", err
+	}
+	log.Debug("file staged", "source", src.path, "dest", dst)
+	return dst, nil
+}
+
+// prepare finds out what kind of font fontPath is and how it gets staged.
+func prepare(fontPath string) (source, error) {
+	info, err := os.Stat(fontPath)
+	if err != nil || info.IsDir() {
+		return source{}, fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	}
+	base := filepath.Base(fontPath)
+
+	if name, ok := strings.CutPrefix(base, "._"); ok && name != "" {
+		return rebuildSuitcase(fontPath, name)
+	}
+	if info.Size() == 0 {
+		// a suitcase copied by a tool that doesn't know resource forks
+		appleDouble := filepath.Join(filepath.Dir(fontPath), "._"+base)
+		if _, err := os.Stat(appleDouble); err != nil {
+			return source{}, fmt.Errorf("file '%s' is empty, probably a font suitcase that lost its resource fork "+
+				"(copy it with its ._ file or as .dfont): %w", fontPath, fonts.ErrNotAFont)
+		}
+		return rebuildSuitcase(appleDouble, base)
+	}
+	if fonts.IsType1Path(fontPath) {
+		return source{}, fmt.Errorf("file '%s' is a Windows Type 1 font, which macOS doesn't support: %w", fontPath, fonts.ErrNotAFont)
+	}
+
+	format, err := fonts.DetectFormat(fontPath)
+	switch format {
+	case fonts.FormatTrueType, fonts.FormatOpenType, fonts.FormatTrueTypeCollection:
+		return source{path: fontPath, name: base, sfnt: true}, nil
+	case fonts.FormatBitmap, fonts.FormatRawBitmap:
+		return source{}, fmt.Errorf("file '%s' is a Windows %s font, which macOS doesn't support: %w", fontPath, format, fonts.ErrNotAFont)
+	}
+	if !errors.Is(err, fonts.ErrNotAFont) {
+		return source{}, err
+	}
+
+	data, err := os.ReadFile(fontPath)
+	if err != nil {
+		return source{}, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, fontPath, fonts.WithAccessDenied(err))
+	}
+	if _, ok := appleDoubleResourceFork(data); ok {
+		// an AppleSingle file, or an AppleDouble file that was renamed
+		return rebuildSuitcase(fontPath, strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+	if err := checkSuitcase(fontPath, data); err != nil {
+		return source{}, err
+	}
+	return source{path: fontPath, name: dfontName(base)}, nil
+}
+
+// rebuildSuitcase returns the resource fork in an AppleDouble file as a .dfont file.
+func rebuildSuitcase(appleDouble, name string) (source, error) {
+	data, err := os.ReadFile(appleDouble)
+	if err != nil {
+		return source{}, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, appleDouble, fonts.WithAccessDenied(err))
+	}
+	fork, ok := appleDoubleResourceFork(data)
+	if !ok {
+		return source{}, fmt.Errorf("file '%s' is not an AppleDouble file: %w", appleDouble, fonts.ErrNotAFont)
+	}
+	if fork == nil {
+		return source{}, fmt.Errorf("AppleDouble file '%s' has no resource fork: %w", appleDouble, fonts.ErrNotAFont)
+	}
+	if err := checkSuitcase(appleDouble, fork); err != nil {
+		return source{}, err
+	}
+	return source{path: appleDouble, name: dfontName(name), data: fork}, nil
+}
+
+// checkSuitcase checks that a resource fork is a suitcase with TrueType or OpenType fonts. Bitmap (NFNT)
+// and PostScript (LWFN) suitcases are not supported by current macOS versions.
+func checkSuitcase(path string, fork []byte) error {
+	types, err := resourceTypes(fork)
+	if err != nil {
+		return fmt.Errorf("file '%s' is %w", path, fonts.ErrNotAFont)
+	}
+	if types["sfnt"] == 0 {
+		return fmt.Errorf("suitcase '%s' has no TrueType or OpenType fonts (%s): %w", path, typeSummary(types), fonts.ErrNotAFont)
+	}
+	return nil
+}
+
+// dfontName returns the file name of a suitcase in the font dir, which gets the .dfont extension.
+func dfontName(name string) string {
+	if strings.EqualFold(filepath.Ext(name), DfontExt) {
+		return name
+	}
+	return name + DfontExt
+}
+
+// resolveConflict decides, according to opts.OnConflict, whether the staged file dst gets replaced by
+// src. It returns false without error if the staged file should be kept. Suitcases have no version, so
+// fonts.ConflictNewer fails for them.
+func resolveConflict(src source, dst string, opts Options) (bool, error) {
+	return fonts.ResolveConflict(opts.OnConflict, src.path, dst, opts.log())
+}
+
+// writeFile writes data to a staging file next to dst and renames it over dst, so dst is never left half
+// written.
+func writeFile(dst string, data []byte) error {
+	staged := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".fontctl-new")
+	err := os.WriteFile(staged, data, 0644)
+	if err == nil {
+		err = os.Rename(staged, dst)
+	}
+	if err != nil {
+		os.Remove(staged)
+		return fmt.Errorf("failed to write file '%s' (%w)", dst, fonts.WithAccessDenied(err))
+	}
+	return nil
+}
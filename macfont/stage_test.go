@@ -0,0 +1,253 @@
+package macfont
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fontctl/fonts"
+)
+
+// trueTypeFont returns a TrueType font with only a head table, which is all DetectFormat and the version
+// comparison read.
+func trueTypeFont(revision float64) []byte {
+	data := binary.BigEndian.AppendUint32(nil, 0x00010000)
+	data = binary.BigEndian.AppendUint16(data, 1) // numTables
+	data = append(data, make([]byte, 6)...)
+	data = append(data, "head"...)
+	data = binary.BigEndian.AppendUint32(data, 0)
+	data = binary.BigEndian.AppendUint32(data, 28)
+	data = binary.BigEndian.AppendUint32(data, 54)
+	head := make([]byte, 54)
+	binary.BigEndian.PutUint32(head[0:], 0x00010000)
+	binary.BigEndian.PutUint32(head[4:], uint32(revision*65536))
+	return append(data, head...)
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPrepare(t *testing.T) {
+	suitcase := resourceFork(resourceType{"FOND", 1}, resourceType{"sfnt", 2})
+	bitmapSuitcase := resourceFork(resourceType{"FOND", 1}, resourceType{"NFNT", 2})
+
+	tests := []struct {
+		name     string
+		files    map[string][]byte // files created in the test dir
+		path     string            // file passed to prepare
+		wantName string
+		wantData []byte // nil if the file gets copied
+		wantSFNT bool
+		wantErr  error
+	}{
+		{
+			name:     "TrueType",
+			files:    map[string][]byte{"Font.ttf": trueTypeFont(1)},
+			path:     "Font.ttf",
+			wantName: "Font.ttf",
+			wantSFNT: true,
+		},
+		{
+			name:     "dfont",
+			files:    map[string][]byte{"Font.dfont": suitcase},
+			path:     "Font.dfont",
+			wantName: "Font.dfont",
+		},
+		{
+			name:     "data fork suitcase without extension",
+			files:    map[string][]byte{"Font": suitcase},
+			path:     "Font",
+			wantName: "Font.dfont",
+		},
+		{
+			name:     "AppleDouble file",
+			files:    map[string][]byte{"Font": nil, "._Font": appleDouble(appleDoubleMagic, suitcase)},
+			path:     "._Font",
+			wantName: "Font.dfont",
+			wantData: suitcase,
+		},
+		{
+			name:     "empty file with AppleDouble file",
+			files:    map[string][]byte{"Font": nil, "._Font": appleDouble(appleDoubleMagic, suitcase)},
+			path:     "Font",
+			wantName: "Font.dfont",
+			wantData: suitcase,
+		},
+		{
+			name:     "AppleSingle file",
+			files:    map[string][]byte{"Font.as": appleDouble(appleSingleMagic, suitcase)},
+			path:     "Font.as",
+			wantName: "Font.dfont",
+			wantData: suitcase,
+		},
+		{
+			name:    "empty file without AppleDouble file",
+			files:   map[string][]byte{"Font": nil},
+			path:    "Font",
+			wantErr: fonts.ErrNotAFont,
+		},
+		{
+			name:    "AppleDouble file without resource fork",
+			files:   map[string][]byte{"._Font": appleDouble(appleDoubleMagic, nil)},
+			path:    "._Font",
+			wantErr: fonts.ErrNotAFont,
+		},
+		{
+			name:    "bitmap suitcase",
+			files:   map[string][]byte{"Font.dfont": bitmapSuitcase},
+			path:    "Font.dfont",
+			wantErr: fonts.ErrNotAFont,
+		},
+		{
+			name:    "Type 1",
+			files:   map[string][]byte{"Font.pfb": {0x80, 0x01}},
+			path:    "Font.pfb",
+			wantErr: fonts.ErrNotAFont,
+		},
+		{
+			name:    "Windows bitmap font",
+			files:   map[string][]byte{"Font.fon": []byte("MZ\x90\x00")},
+			path:    "Font.fon",
+			wantErr: fonts.ErrNotAFont,
+		},
+		{
+			name:    "no font",
+			files:   map[string][]byte{"Font.txt": []byte("hello world")},
+			path:    "Font.txt",
+			wantErr: fonts.ErrNotAFont,
+		},
+		{
+			name:    "missing",
+			path:    "Font.ttf",
+			wantErr: fonts.ErrFileNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				writeTestFile(t, filepath.Join(dir, name), data)
+			}
+			src, err := prepare(filepath.Join(dir, tt.path))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("prepare() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepare() failed: %v", err)
+			}
+			if src.name != tt.wantName || src.sfnt != tt.wantSFNT || string(src.data) != string(tt.wantData) {
+				t.Errorf("prepare() = name %q, sfnt %t, %d bytes, want name %q, sfnt %t, %d bytes",
+					src.name, src.sfnt, len(src.data), tt.wantName, tt.wantSFNT, len(tt.wantData))
+			}
+		})
+	}
+}
+
+func TestRebuildSuitcase(t *testing.T) {
+	suitcase := resourceFork(resourceType{"sfnt", 1})
+	tests := []struct {
+		name     string
+		data     []byte
+		fontName string
+		wantName string
+		wantErr  bool
+	}{
+		{"suitcase", appleDouble(appleDoubleMagic, suitcase), "Font", "Font.dfont", false},
+		{"keeps .dfont extension", appleDouble(appleDoubleMagic, suitcase), "Font.DFONT", "Font.DFONT", false},
+		{"no AppleDouble file", suitcase, "Font", "", true},
+		{"no resource fork", appleDouble(appleDoubleMagic, nil), "Font", "", true},
+		{"no TrueType fonts", appleDouble(appleDoubleMagic, resourceFork(resourceType{"NFNT", 1})), "Font", "", true},
+		{"broken resource fork", appleDouble(appleDoubleMagic, []byte("not a resource fork")), "Font", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, filepath.Join(t.TempDir(), "._"+tt.fontName), tt.data)
+			src, err := rebuildSuitcase(path, tt.fontName)
+			if tt.wantErr {
+				if !errors.Is(err, fonts.ErrNotAFont) {
+					t.Fatalf("rebuildSuitcase() error = %v, want %v", err, fonts.ErrNotAFont)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rebuildSuitcase() failed: %v", err)
+			}
+			if src.path != path || src.name != tt.wantName || string(src.data) != string(suitcase) {
+				t.Errorf("rebuildSuitcase() = %q, %q, %d bytes, want %q, %q, %d bytes",
+					src.path, src.name, len(src.data), path, tt.wantName, len(suitcase))
+			}
+		})
+	}
+}
+
+func TestStageFontFromFileConflict(t *testing.T) {
+	older, newer := trueTypeFont(1), trueTypeFont(2)
+	suitcase := resourceFork(resourceType{"sfnt", 1})
+	otherSuitcase := resourceFork(resourceType{"FOND", 1}, resourceType{"sfnt", 1})
+
+	tests := []struct {
+		name       string
+		file       string
+		src, dst   []byte
+		policy     fonts.ConflictPolicy
+		wantStaged []byte // content of the staged file afterwards
+		wantSkip   bool
+		wantErr    error
+	}{
+		{"identical", "Font.ttf", older, older, fonts.ConflictFail, older, false, nil},
+		{"fail", "Font.ttf", newer, older, fonts.ConflictFail, older, false, fonts.ErrFileExistsAndIsDifferent},
+		{"skip", "Font.ttf", newer, older, fonts.ConflictSkip, older, true, nil},
+		{"overwrite", "Font.ttf", older, newer, fonts.ConflictOverwrite, older, false, nil},
+		{"newer replaces older", "Font.ttf", newer, older, fonts.ConflictNewer, newer, false, nil},
+		{"newer keeps newer", "Font.ttf", older, newer, fonts.ConflictNewer, newer, true, nil},
+		{"newer fails for suitcases", "Font.dfont", suitcase, otherSuitcase, fonts.ConflictNewer, otherSuitcase, false, fonts.ErrFileExistsAndIsDifferent},
+		{"overwrite suitcase", "Font.dfont", suitcase, otherSuitcase, fonts.ConflictOverwrite, suitcase, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			src := writeTestFile(t, filepath.Join(t.TempDir(), tt.file), tt.src)
+			dst := writeTestFile(t, filepath.Join(root, "Library", "Fonts", tt.file), tt.dst)
+
+			opts := Options{Layout: Layout{Root: root, SystemWide: true}, OnConflict: tt.policy}
+			staged, err := StageFontFromFile(context.Background(), src, opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("StageFontFromFile() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("StageFontFromFile() failed: %v", err)
+			} else {
+				want := dst
+				if tt.wantSkip {
+					want = ""
+				}
+				if staged != want {
+					t.Errorf("StageFontFromFile() = %q, want %q", staged, want)
+				}
+			}
+
+			data, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != string(tt.wantStaged) {
+				t.Errorf("staged file has the wrong content")
+			}
+		})
+	}
+}
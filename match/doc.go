@@ -0,0 +1,12 @@
+// Package match simulates how GDI picks a font for a LOGFONT request (CreateFontIndirect), so it can be
+// checked offline which font file an application would get and whether GDI would synthesize bold or
+// italic, instead of silently falling back to another font.
+//
+// GDI's font mapper gives every font penalties for the ways it differs from the request and takes the
+// font with the lowest total. The simulation implements the penalties that matter for picking a font
+// file: character set, face name (family names in all languages and full names), weight and slant.
+// FontSubstitutes rules are applied to face names that no font has. Pitch and family flags, sizes, font
+// linking and the output device are not simulated.
+//
+// The package is operating system independent.
+package match
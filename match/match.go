@@ -0,0 +1,192 @@
+package match
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fontctl/fonts"
+	"fontctl/substitutes"
+)
+
+// ErrNoFonts is returned by Match if there are no fonts to pick from.
+var ErrNoFonts = errors.New("no fonts to match against")
+
+// Request is the part of a LOGFONT that selects the font.
+type Request struct {
+	FaceName string // lfFaceName: family or full name
+	Weight   int    // lfWeight: 100 to 900, 0 (FW_DONTCARE) is regular
+	Italic   bool   // lfItalic
+	Charset  int    // lfCharSet, fonts.CharsetDefault for any
+}
+
+// How a face was matched by name.
+const (
+	ByFamily   = "family"
+	ByFullName = "full name"
+	Fallback   = "fallback" // no face has the requested name, GDI takes another family
+)
+
+// Candidate is a face with its penalty for a request.
+type Candidate struct {
+	fonts.Face
+	Penalty           int    `json:"penalty"`
+	MatchedBy         string `json:"matched_by"`
+	CharsetSupported  bool   `json:"charset_supported"`
+	SynthesizedBold   bool   `json:"synthesized_bold"`
+	SynthesizedItalic bool   `json:"synthesized_italic"`
+}
+
+// Result is the face GDI would use for a request.
+type Result struct {
+	Candidate
+	// Substitute is the FontSubstitutes rule the face name was replaced with, i.e. "Arial,186".
+	Substitute string `json:"substitute,omitempty"`
+	// Candidates are all faces that were considered, best first.
+	Candidates []Candidate `json:"candidates"`
+}
+
+// Penalties of the font mapper. A wrong character set outweighs everything, so GDI rather takes another
+// family than one that can't display the text. The style penalties only decide between the faces of the
+// family.
+const (
+	penaltyCharset  = 65000
+	penaltyFaceName = 10000
+	// penaltyNotDefault ranks the default families of the character set first when falling back.
+	penaltyNotDefault = 1000
+	penaltyWeight     = 3 // per 10 weight units
+	penaltyItalic     = 4 // italic face for an upright request, can't be undone
+	penaltyItalicSim  = 1 // upright face for an italic request, GDI slants it
+)
+
+// defaultFamilies are the families GDI falls back to for a character set when no font has the requested
+// face name. Character sets that are missing use the ANSI families, which cover most European scripts.
+var defaultFamilies = map[int][]string{
+	fonts.CharsetANSI:        {"Arial", "Microsoft Sans Serif"},
+	fonts.CharsetShiftJIS:    {"MS UI Gothic", "MS Gothic"},
+	fonts.CharsetHangul:      {"Gulim", "Malgun Gothic"},
+	fonts.CharsetGB2312:      {"SimSun", "Microsoft YaHei"},
+	fonts.CharsetChineseBig5: {"PMingLiU", "MingLiU", "Microsoft JhengHei"},
+	fonts.CharsetThai:        {"Tahoma"},
+	fonts.CharsetHebrew:      {"Arial", "David"},
+	fonts.CharsetArabic:      {"Arial", "Tahoma"},
+	fonts.CharsetSymbol:      {"Symbol"},
+}
+
+// Match returns the face GDI would pick for the request. If no face has the requested name, the face
+// name is replaced by its FontSubstitutes rule from subs, if there is one.
+func Match(faces []fonts.Face, req Request, subs []substitutes.Substitute) (Result, error) {
+	if len(faces) == 0 {
+		return Result{}, ErrNoFonts
+	}
+	if req.Weight <= 0 {
+		req.Weight = 400
+	}
+
+	hasName := func(name string) bool {
+		return slices.ContainsFunc(faces, func(f fonts.Face) bool { return matchName(f, name) != "" })
+	}
+	var substitute string
+	if !hasName(req.FaceName) {
+		if rule, ok := findSubstitute(subs, req.FaceName, req.Charset); ok {
+			substitute = rule
+			name, charset, hasCharset := strings.Cut(rule, ",")
+			req.FaceName = strings.TrimSpace(name)
+			if n, err := strconv.Atoi(strings.TrimSpace(charset)); hasCharset && err == nil {
+				req.Charset = n
+			}
+		}
+	}
+	nameMatches := hasName(req.FaceName)
+	defaults := defaultFamilies[req.Charset]
+	if defaults == nil {
+		defaults = defaultFamilies[fonts.CharsetANSI]
+	}
+
+	candidates := make([]Candidate, 0, len(faces))
+	for _, f := range faces {
+		c := Candidate{Face: f, MatchedBy: matchName(f, req.FaceName)}
+		c.CharsetSupported = req.Charset == fonts.CharsetDefault || slices.Contains(f.Charsets, req.Charset)
+		if !c.CharsetSupported {
+			c.Penalty += penaltyCharset
+		}
+		if c.MatchedBy == "" {
+			c.Penalty += penaltyFaceName
+			c.MatchedBy = Fallback
+			if !nameMatches && !slices.ContainsFunc(defaults, func(d string) bool { return matchName(f, d) == ByFamily }) {
+				c.Penalty += penaltyNotDefault
+			}
+		}
+		c.Penalty += penaltyWeight * abs(req.Weight-f.Weight) / 10
+		switch {
+		case f.Italic && !req.Italic:
+			c.Penalty += penaltyItalic
+		case !f.Italic && req.Italic:
+			c.Penalty += penaltyItalicSim
+			c.SynthesizedItalic = true
+		}
+		// GDI emboldens a face that is at least two weight classes lighter than requested
+		c.SynthesizedBold = req.Weight-f.Weight >= 200
+		candidates = append(candidates, c)
+	}
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		if a.Penalty != b.Penalty {
+			return a.Penalty - b.Penalty
+		}
+		if a.Path != b.Path {
+			return strings.Compare(a.Path, b.Path)
+		}
+		return a.Index - b.Index
+	})
+	return Result{Candidate: candidates[0], Candidates: candidates, Substitute: substitute}, nil
+}
+
+// findSubstitute returns the substitute of the FontSubstitutes rule for the face name. A rule for the
+// face name with the requested character set ("Arial Baltic,186") is preferred to one without.
+func findSubstitute(subs []substitutes.Substitute, faceName string, charset int) (string, bool) {
+	faceName = strings.TrimSpace(faceName)
+	if faceName == "" {
+		return "", false
+	}
+	var fallback string
+	found := false
+	for _, s := range subs {
+		name, cs, hasCharset := strings.Cut(s.Font, ",")
+		if !strings.EqualFold(strings.TrimSpace(name), faceName) {
+			continue
+		}
+		if !hasCharset {
+			if !found {
+				fallback, found = s.Substitute, true
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(cs)); err == nil && n == charset {
+			return s.Substitute, true
+		}
+	}
+	return fallback, found
+}
+
+// matchName returns how the face is known by name, or "" if it isn't.
+func matchName(f fonts.Face, name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	if slices.ContainsFunc(f.GDIFamilies, func(s string) bool { return strings.EqualFold(s, name) }) {
+		return ByFamily
+	}
+	if strings.EqualFold(f.FullName, name) {
+		return ByFullName
+	}
+	return ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package match
+
+import (
+	"errors"
+	"testing"
+
+	"fontctl/fonts"
+	"fontctl/substitutes"
+)
+
+func testFaces() []fonts.Face {
+	latin := []int{fonts.CharsetANSI, fonts.CharsetBaltic, fonts.CharsetRussian}
+	return []fonts.Face{
+		{Path: "arial.ttf", GDIFamilies: []string{"Arial"}, FullName: "Arial", Weight: 400, Charsets: latin},
+		{Path: "arialbd.ttf", GDIFamilies: []string{"Arial"}, FullName: "Arial Bold", Weight: 700, Charsets: latin},
+		{Path: "ariali.ttf", GDIFamilies: []string{"Arial"}, FullName: "Arial Italic", Weight: 400, Italic: true, Charsets: latin},
+		{Path: "segoeuil.ttf", GDIFamilies: []string{"Segoe UI Light"}, FullName: "Segoe UI Light", Weight: 300, Charsets: latin},
+		{Path: "Foo-Italic.otf", GDIFamilies: []string{"Foo"}, FullName: "Foo Italic", Weight: 400, Italic: true, Charsets: []int{fonts.CharsetANSI}},
+		{Path: "micross.ttf", GDIFamilies: []string{"Microsoft Sans Serif"}, FullName: "Microsoft Sans Serif", Weight: 400, Charsets: latin},
+		{Path: "msgothic.ttc", Index: 0, GDIFamilies: []string{"MS Gothic", "ＭＳ ゴシック"}, FullName: "MS Gothic", Weight: 400, Charsets: []int{fonts.CharsetANSI, fonts.CharsetShiftJIS}},
+		{Path: "msgothic.ttc", Index: 1, GDIFamilies: []string{"MS UI Gothic"}, FullName: "MS UI Gothic", Weight: 400, Charsets: []int{fonts.CharsetANSI, fonts.CharsetShiftJIS}},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	subs := []substitutes.Substitute{
+		{Font: "Helvetica", Substitute: "Microsoft Sans Serif"},
+		{Font: "Arial", Substitute: "Microsoft Sans Serif"}, // Arial is installed, so this doesn't apply
+		{Font: "Arial Baltic,186", Substitute: "Arial,186"},
+		{Font: "MS Shell Dlg", Substitute: "MS UI Gothic,128"},
+		{Font: "MS Shell Dlg,0", Substitute: "Microsoft Sans Serif,0"},
+	}
+	tests := []struct {
+		name           string
+		req            Request
+		wantPath       string
+		wantIndex      int
+		wantBy         string
+		wantSubstitute string
+		wantCharset    bool
+		wantBold       bool
+		wantItalic     bool
+	}{
+		// weight and slant
+		{"regular", Request{FaceName: "Arial"}, "arial.ttf", 0, ByFamily, "", true, false, false},
+		{"family name ignores case", Request{FaceName: "  arial "}, "arial.ttf", 0, ByFamily, "", true, false, false},
+		{"bold", Request{FaceName: "Arial", Weight: 700}, "arialbd.ttf", 0, ByFamily, "", true, false, false},
+		{"semibold is closer to bold", Request{FaceName: "Arial", Weight: 600}, "arialbd.ttf", 0, ByFamily, "", true, false, false},
+		{"medium is closer to regular", Request{FaceName: "Arial", Weight: 500}, "arial.ttf", 0, ByFamily, "", true, false, false},
+		{"black emboldens bold", Request{FaceName: "Arial", Weight: 900}, "arialbd.ttf", 0, ByFamily, "", true, true, false},
+		{"italic", Request{FaceName: "Arial", Italic: true}, "ariali.ttf", 0, ByFamily, "", true, false, false},
+		{"bold italic slants bold", Request{FaceName: "Arial", Weight: 700, Italic: true}, "arialbd.ttf", 0, ByFamily, "", true, false, true},
+		{"bold of a light family", Request{FaceName: "Segoe UI Light", Weight: 700}, "segoeuil.ttf", 0, ByFamily, "", true, true, false},
+		{"only italic face", Request{FaceName: "Foo"}, "Foo-Italic.otf", 0, ByFamily, "", true, false, false},
+		{"full name", Request{FaceName: "Arial Bold"}, "arialbd.ttf", 0, ByFullName, "", true, false, false},
+		{"localized family", Request{FaceName: "ＭＳ ゴシック"}, "msgothic.ttc", 0, ByFamily, "", true, false, false},
+
+		// character sets
+		{"supported charset", Request{FaceName: "Arial", Charset: fonts.CharsetRussian}, "arial.ttf", 0, ByFamily, "", true, false, false},
+		{"any charset", Request{FaceName: "Arial", Charset: fonts.CharsetDefault}, "arial.ttf", 0, ByFamily, "", true, false, false},
+		{"unsupported charset picks another family", Request{FaceName: "Arial", Charset: fonts.CharsetShiftJIS}, "msgothic.ttc", 0, Fallback, "", true, false, false},
+		{"unsupported charset without alternative", Request{FaceName: "Arial", Charset: fonts.CharsetThai}, "arial.ttf", 0, ByFamily, "", false, false, false},
+
+		// fallbacks for names no font has
+		{"fallback to Arial", Request{FaceName: "Times New Roman"}, "arial.ttf", 0, Fallback, "", true, false, false},
+		{"fallback keeps the style", Request{FaceName: "Times New Roman", Weight: 700, Italic: true}, "arialbd.ttf", 0, Fallback, "", true, false, true},
+		{"fallback for Shift-JIS", Request{FaceName: "Meiryo", Charset: fonts.CharsetShiftJIS}, "msgothic.ttc", 0, Fallback, "", true, false, false},
+		{"no name", Request{}, "arial.ttf", 0, Fallback, "", true, false, false},
+
+		// substitutes
+		{"substitute", Request{FaceName: "helvetica", Weight: 700}, "micross.ttf", 0, ByFamily, "Microsoft Sans Serif", true, true, false},
+		{"no substitute for an installed font", Request{FaceName: "Arial"}, "arial.ttf", 0, ByFamily, "", true, false, false},
+		{"substitute for the charset", Request{FaceName: "Arial Baltic", Charset: fonts.CharsetBaltic}, "arial.ttf", 0, ByFamily, "Arial,186", true, false, false},
+		{"no substitute for another charset", Request{FaceName: "Arial Baltic", Charset: fonts.CharsetRussian}, "arial.ttf", 0, Fallback, "", true, false, false},
+		{"charset rule first", Request{FaceName: "MS Shell Dlg", Charset: fonts.CharsetANSI}, "micross.ttf", 0, ByFamily, "Microsoft Sans Serif,0", true, false, false},
+		{"rule without charset sets the charset", Request{FaceName: "MS Shell Dlg", Charset: fonts.CharsetDefault}, "msgothic.ttc", 1, ByFamily, "MS UI Gothic,128", true, false, false},
+	}
+	faces := testFaces()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(faces, tt.req, subs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Path != tt.wantPath || got.Index != tt.wantIndex || got.MatchedBy != tt.wantBy || got.Substitute != tt.wantSubstitute {
+				t.Errorf("Match() = %s#%d by %s (substitute %q), want %s#%d by %s (substitute %q)",
+					got.Path, got.Index, got.MatchedBy, got.Substitute, tt.wantPath, tt.wantIndex, tt.wantBy, tt.wantSubstitute)
+			}
+			if got.CharsetSupported != tt.wantCharset || got.SynthesizedBold != tt.wantBold || got.SynthesizedItalic != tt.wantItalic {
+				t.Errorf("Match() charset supported %t, synthesized bold %t, italic %t, want %t, %t, %t",
+					got.CharsetSupported, got.SynthesizedBold, got.SynthesizedItalic, tt.wantCharset, tt.wantBold, tt.wantItalic)
+			}
+			if len(got.Candidates) != len(faces) || got.Candidates[0].Path != got.Path {
+				t.Errorf("Match() has %d candidates, best %s, want all %d, best first", len(got.Candidates), got.Candidates[0].Path, len(faces))
+			}
+		})
+	}
+}
+
+func TestMatchNoFonts(t *testing.T) {
+	if _, err := Match(nil, Request{FaceName: "Arial"}, nil); !errors.Is(err, ErrNoFonts) {
+		t.Errorf("Match() error = %v, want %v", err, ErrNoFonts)
+	}
+}
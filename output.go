@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"fontctl/scan"
+	"fontctl/watch"
+)
+
+// printJSON prints v as indented JSON to stdout, for the --json output of the commands.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printWatchChanges prints the changes fontctl watch applied, one file per line.
+func printWatchChanges(applied watch.Changes, err error) {
+	for _, p := range applied.Added {
+		fmt.Println("+ " + p)
+	}
+	for _, p := range applied.Modified {
+		fmt.Println("~ " + p)
+	}
+	for _, p := range applied.Removed {
+		fmt.Println("- " + p)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error - %s\n", err)
+	}
+}
+
+// printScanResults prints the fonts fontctl scan found as a table, with the matching font files or,
+// for missing and embedded fonts, where the document refers to them.
+func printScanResults(results []scan.Result) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FAMILY\tSTATUS\tFILES / SOURCES")
+	for _, r := range results {
+		details := strings.Join(r.Files, ", ")
+		if len(r.Files) == 0 {
+			details = strings.Join(r.Sources, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Family, r.Status, details)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,117 @@
+package sbom
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// CycloneDX 1.5 JSON document, only the fields fontctl writes.
+type cdxDoc struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string   `json:"timestamp"`
+	Tools     cdxTools `json:"tools"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string           `json:"type"`
+	BOMRef     string           `json:"bom-ref,omitempty"`
+	Name       string           `json:"name"`
+	Version    string           `json:"version,omitempty"`
+	Supplier   *cdxOrganization `json:"supplier,omitempty"`
+	Publisher  string           `json:"publisher,omitempty"`
+	Author     string           `json:"author,omitempty"`
+	Copyright  string           `json:"copyright,omitempty"`
+	Hashes     []cdxHash        `json:"hashes,omitempty"`
+	Licenses   []cdxLicenseItem `json:"licenses,omitempty"`
+	Properties []cdxProperty    `json:"properties,omitempty"`
+}
+
+type cdxOrganization struct {
+	Name string `json:"name"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicenseItem struct {
+	License cdxLicense `json:"license"`
+}
+
+type cdxLicense struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name,omitempty"`
+	Text *cdxLicenseText `json:"text,omitempty"`
+	URL  string          `json:"url,omitempty"`
+}
+
+type cdxLicenseText struct {
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func cycloneDXDocument(components []Component, created time.Time) cdxDoc {
+	doc := cdxDoc{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + uuid(documentID(components, created)),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: created.Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "fontctl"}}},
+		},
+		Components: []cdxComponent{},
+	}
+	for i, c := range components {
+		comp := cdxComponent{
+			Type:      "file",
+			BOMRef:    fmt.Sprintf("font-%d", i+1),
+			Name:      c.Name,
+			Version:   c.Version,
+			Publisher: c.Manufacturer,
+			Author:    c.Designer,
+			Copyright: c.Copyright,
+			Hashes:    []cdxHash{{Alg: "SHA-256", Content: c.Hash}},
+			Properties: []cdxProperty{
+				{Name: "fontctl:path", Value: c.Path},
+				{Name: "fontctl:file", Value: filepath.Base(c.Path)},
+			},
+		}
+		if s := c.Supplier(); s != "" {
+			comp.Supplier = &cdxOrganization{Name: s}
+		}
+		if c.VendorID != "" {
+			comp.Properties = append(comp.Properties, cdxProperty{Name: "fontctl:vendorId", Value: c.VendorID})
+		}
+		switch {
+		case c.License != "":
+			comp.Licenses = []cdxLicenseItem{{License: cdxLicense{ID: c.License, URL: c.LicenseURL}}}
+		case c.LicenseText != "" || c.LicenseURL != "":
+			l := cdxLicense{Name: c.Name + " license", URL: c.LicenseURL}
+			if c.LicenseText != "" {
+				l.Text = &cdxLicenseText{Content: c.LicenseText}
+			}
+			comp.Licenses = []cdxLicenseItem{{License: l}}
+		}
+		doc.Components = append(doc.Components, comp)
+	}
+	return doc
+}
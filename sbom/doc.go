@@ -0,0 +1,5 @@
+// Package sbom describes font files as components of a software bill of materials, with their names,
+// versions, vendors, licenses and hashes, and writes them as SPDX or CycloneDX JSON documents.
+//
+// The package is operating system independent.
+package sbom
@@ -0,0 +1,174 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fontctl/fonts"
+	"fontctl/license"
+)
+
+// Formats of the documents Write writes.
+const (
+	FormatSPDX      = "spdx-json"
+	FormatCycloneDX = "cyclonedx-json"
+)
+
+// Component is a font file in the bill of materials.
+type Component struct {
+	Name         string `json:"name"` // full name of the first face, the file name if the file can't be parsed
+	Version      string `json:"version,omitempty"`
+	Path         string `json:"path"`
+	Hash         string `json:"hash"`                // SHA-256, hex encoded
+	VendorID     string `json:"vendor_id,omitempty"` // OS/2 achVendID
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Designer     string `json:"designer,omitempty"`
+	Copyright    string `json:"copyright,omitempty"`
+	// License is the SPDX identifier of the license, "" if the license isn't recognized. LicenseText and
+	// LicenseURL are the license description and URL of the name table.
+	License     string `json:"license,omitempty"`
+	LicenseText string `json:"license_text,omitempty"`
+	LicenseURL  string `json:"license_url,omitempty"`
+	FsType      uint16 `json:"fs_type"` // OS/2 fsType, the embedding permissions
+}
+
+// Supplier returns the manufacturer of the font, or its vendor ID if the font doesn't name one.
+func (c Component) Supplier() string {
+	if c.Manufacturer != "" {
+		return c.Manufacturer
+	}
+	return c.VendorID
+}
+
+// Options are the settings of Read and Write.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// Now returns the creation time of the document. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o Options) log() *slog.Logger {
+	if o.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return o.Logger
+}
+
+// Read returns a component for each font file. Files that exist but can't be parsed are components
+// with only their file name and hash, a bill of materials must not leave out files.
+func Read(paths []string, opts Options) ([]Component, error) {
+	log := opts.log()
+	list := make([]Component, 0, len(paths))
+	for _, p := range paths {
+		hash, err := fonts.HashFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, p, fonts.WithAccessDenied(err))
+		}
+		c := Component{Name: filepath.Base(p), Path: p, Hash: hex.EncodeToString(hash)}
+		if err := c.readNames(); err != nil {
+			log.Warn("can't parse font file, only its hash is recorded", "path", p, "error", err)
+		}
+		c.License = license.SPDXID(c.LicenseText, c.LicenseURL)
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+func (c *Component) readNames() error {
+	format, err := fonts.DetectFormat(c.Path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case fonts.FormatTrueType, fonts.FormatOpenType, fonts.FormatTrueTypeCollection:
+		list, err := fonts.ParseSFNT(c.Path)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		f := list[0]
+		if name := f.Name(fonts.NameFull); name != "" {
+			c.Name = name
+		} else if name := f.Name(fonts.NameFamily); name != "" {
+			c.Name = name
+		}
+		c.Version = CleanVersion(f.Version())
+		c.VendorID = f.VendorID
+		c.Manufacturer = f.Name(fonts.NameManufacturer)
+		c.Designer = f.Name(fonts.NameDesigner)
+		c.Copyright = f.Name(fonts.NameCopyright)
+		c.LicenseText = f.Name(fonts.NameLicense)
+		c.LicenseURL = f.Name(fonts.NameLicenseURL)
+		c.FsType = f.FsType
+	default:
+		faces, err := fonts.ReadFaces(c.Path)
+		if err != nil {
+			return err
+		}
+		if len(faces) == 0 {
+			return nil
+		}
+		c.Name = faces[0].FullName
+		if c.Name == "" {
+			c.Name = faces[0].Family
+		}
+		c.Version = CleanVersion(faces[0].Version)
+	}
+	return nil
+}
+
+// CleanVersion returns the version number of a version string of the name table, i.e. "1.00" for
+// "Version 1.00;PS 1.0;hotconv 1.0.88".
+func CleanVersion(v string) string {
+	v, _, _ = strings.Cut(v, ";")
+	v = strings.TrimSpace(v)
+	if len(v) > 8 && strings.EqualFold(v[:8], "version ") {
+		v = strings.TrimSpace(v[8:])
+	}
+	return v
+}
+
+// Write writes the components as a document in format to w.
+func Write(w io.Writer, format string, components []Component, opts Options) error {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	created := now().UTC().Truncate(time.Second)
+	switch format {
+	case FormatSPDX:
+		return writeJSON(w, spdxDocument(components, created))
+	case FormatCycloneDX:
+		return writeJSON(w, cycloneDXDocument(components, created))
+	}
+	return fmt.Errorf("unknown SBOM format '%s', use %s or %s", format, FormatSPDX, FormatCycloneDX)
+}
+
+// documentID derives a unique ID from the components and the creation time, the same fonts at the same
+// time are the same document.
+func documentID(components []Component, created time.Time) [16]byte {
+	h := sha256.New()
+	io.WriteString(h, created.Format(time.RFC3339))
+	for _, c := range components {
+		io.WriteString(h, c.Path+"\x00"+c.Hash+"\x00")
+	}
+	var id [16]byte
+	copy(id[:], h.Sum(nil))
+	return id
+}
+
+// uuid formats an ID as a name based UUID (version 8).
+func uuid(id [16]byte) string {
+	id[6] = id[6]&0x0f | 0x80
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
@@ -0,0 +1,129 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SPDX 2.3 JSON document, only the fields fontctl writes.
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+	// ExtractedLicenses are the licenses that have no SPDX identifier.
+	ExtractedLicenses []spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string         `json:"name"`
+	SPDXID           string         `json:"SPDXID"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	PackageFileName  string         `json:"packageFileName"`
+	Supplier         string         `json:"supplier"`
+	Originator       string         `json:"originator,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum `json:"checksums"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	LicenseDeclared  string         `json:"licenseDeclared"`
+	CopyrightText    string         `json:"copyrightText"`
+	Comment          string         `json:"comment,omitempty"`
+	PrimaryPurpose   string         `json:"primaryPackagePurpose"`
+}
+
+type spdxChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string   `json:"licenseId"`
+	Name          string   `json:"name,omitempty"`
+	ExtractedText string   `json:"extractedText"`
+	SeeAlsos      []string `json:"seeAlsos,omitempty"`
+}
+
+const spdxNoAssertion = "NOASSERTION"
+
+func spdxDocument(components []Component, created time.Time) spdxDoc {
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "fonts",
+		DocumentNamespace: "https://spdx.org/spdxdocs/fontctl-" + uuid(documentID(components, created)),
+		CreationInfo:      spdxCreationInfo{Created: created.Format(time.RFC3339), Creators: []string{"Tool: fontctl"}},
+		Packages:          []spdxPackage{},
+		Relationships:     []spdxRelationship{},
+	}
+	for i, c := range components {
+		p := spdxPackage{
+			Name:             c.Name,
+			SPDXID:           fmt.Sprintf("SPDXRef-Font-%d", i+1),
+			VersionInfo:      c.Version,
+			PackageFileName:  c.Path,
+			Supplier:         spdxNoAssertion,
+			DownloadLocation: spdxNoAssertion,
+			Checksums:        []spdxChecksum{{Algorithm: "SHA256", Value: c.Hash}},
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+			PrimaryPurpose:   "OTHER",
+		}
+		if s := c.Supplier(); s != "" {
+			p.Supplier = "Organization: " + s
+		}
+		if c.Designer != "" {
+			p.Originator = "Person: " + c.Designer
+		}
+		if c.Copyright != "" {
+			p.CopyrightText = c.Copyright
+		}
+		if c.VendorID != "" {
+			p.Comment = "OS/2 vendor ID: " + c.VendorID
+		}
+		switch {
+		case c.License != "":
+			p.LicenseDeclared = c.License
+		case c.LicenseText != "" || c.LicenseURL != "":
+			// unknown licenses are declared by reference to their text
+			id := fmt.Sprintf("LicenseRef-Font-%d", i+1)
+			l := spdxExtractedLicense{LicenseID: id, Name: c.Name + " license", ExtractedText: c.LicenseText}
+			if l.ExtractedText == "" {
+				l.ExtractedText = "See " + c.LicenseURL
+			}
+			if c.LicenseURL != "" {
+				l.SeeAlsos = []string{c.LicenseURL}
+			}
+			doc.ExtractedLicenses = append(doc.ExtractedLicenses, l)
+			p.LicenseDeclared = id
+		}
+		doc.Packages = append(doc.Packages, p)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: doc.SPDXID, Type: "DESCRIBES", Related: p.SPDXID})
+	}
+	return doc
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,89 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// assFnRe finds font name override tags, i.e. {\fnArial} or {\b1\fnOpen Sans}.
+var assFnRe = regexp.MustCompile(`\\fn([^\\}]*)`)
+
+// extractASS finds the fonts of the styles and the \fn overrides in the dialogue lines.
+func extractASS(path string, refs *refList) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileError(path, err)
+	}
+	data = decodeText(data)
+
+	section := ""
+	fontnameField := -1
+	numFields := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasSuffix(section, "styles]") && key == "Format":
+			fields := strings.Split(value, ",")
+			numFields = len(fields)
+			fontnameField = slices.IndexFunc(fields, func(f string) bool { return strings.EqualFold(strings.TrimSpace(f), "Fontname") })
+		case strings.HasSuffix(section, "styles]") && key == "Style":
+			if fontnameField < 0 {
+				fontnameField, numFields = 1, -1 // Name, Fontname is the order of all ASS/SSA versions
+			}
+			fields := strings.SplitN(value, ",", numFields)
+			if fontnameField < len(fields) {
+				refs.add(assFontName(fields[fontnameField]), "Style", false)
+			}
+		case section == "[events]" && key == "Dialogue":
+			for _, m := range assFnRe.FindAllStringSubmatch(value, -1) {
+				refs.add(assFontName(m[1]), `\fn`, false)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("can't read subtitle file '%s' (%w)", path, err)
+	}
+	return nil
+}
+
+// assFontName strips the "@" prefix that selects the vertical variant of a font.
+func assFontName(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), "@")
+}
+
+// decodeText strips a UTF-8 byte order mark and converts UTF-16 text (with byte order mark) to UTF-8.
+func decodeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		bigEndian := data[0] == 0xFE
+		u := make([]uint16, (len(data)-2)/2)
+		for i := range u {
+			lo, hi := data[2+2*i], data[3+2*i]
+			if bigEndian {
+				lo, hi = hi, lo
+			}
+			u[i] = uint16(lo) | uint16(hi)<<8
+		}
+		return []byte(string(utf16.Decode(u)))
+	}
+	return data
+}
@@ -0,0 +1,67 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+const testASS = "[Script Info]\r\nTitle: Test\r\nScriptType: v4.00+\r\n\r\n" +
+	"[V4+ Styles]\r\n" +
+	"Format: Name, Fontname, Fontsize, PrimaryColour, Bold\r\n" +
+	"Style: Default,Arial,20,&H00FFFFFF,0\r\n" +
+	"Style: Sign, @MS Gothic ,24,&H00FFFFFF,-1\r\n" +
+	"Style: Comma,Font, with comma,20,&H00FFFFFF,0\r\n" +
+	"\r\n[Events]\r\n" +
+	"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\r\n" +
+	"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\b1\\fnOpen Sans}Hello {\\fnarial}world\r\n" +
+	"Comment: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\fnComic Sans MS}not shown\r\n"
+
+func TestExtractASS(t *testing.T) {
+	want := []Reference{
+		{Family: "Arial", Sources: []string{"Style", `\fn`}},
+		{Family: "Font", Sources: []string{"Style"}},
+		{Family: "MS Gothic", Sources: []string{"Style"}},
+		{Family: "Open Sans", Sources: []string{`\fn`}},
+	}
+	if got := extractFile(t, "subs.ass", []byte(testASS)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractASSFormats(t *testing.T) {
+	ssa := "[V4 Styles]\nStyle: Default,Tahoma,20\n[Events]\nDialogue: Marked=0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Hi\n"
+	reordered := "[V4+ Styles]\nFormat: Fontname, Name, Fontsize\nStyle: Verdana,Default,20\n"
+	tests := []struct {
+		name string
+		file string
+		data []byte
+		want []Reference
+	}{
+		{"SSA without Format line", "subs.ssa", []byte(ssa), []Reference{{Family: "Tahoma", Sources: []string{"Style"}}}},
+		{"Fontname field first", "subs.ass", []byte(reordered), []Reference{{Family: "Verdana", Sources: []string{"Style"}}}},
+		{"UTF-8 BOM", "subs.ass", append([]byte{0xEF, 0xBB, 0xBF}, reordered...), []Reference{{Family: "Verdana", Sources: []string{"Style"}}}},
+		{"UTF-16 LE", "subs.ass", utf16Bytes(reordered, false), []Reference{{Family: "Verdana", Sources: []string{"Style"}}}},
+		{"UTF-16 BE", "subs.ass", utf16Bytes(reordered, true), []Reference{{Family: "Verdana", Sources: []string{"Style"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractFile(t, tt.file, tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// utf16Bytes encodes s as UTF-16 with a byte order mark.
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var b []byte
+	for _, u := range append([]uint16{0xFEFF}, utf16.Encode([]rune(s))...) {
+		if bigEndian {
+			b = append(b, byte(u>>8), byte(u))
+		} else {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
@@ -0,0 +1,6 @@
+// Package scan finds the fonts that project and document files refer to, and checks whether they are
+// installed, available in a font library directory or missing. Supported are SVG, ASS/SSA subtitles,
+// PDF, DOCX and ODF (ODT, ODS, ODP) files.
+//
+// The package is operating system independent, the caller decides which directories count as installed.
+package scan
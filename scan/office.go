@@ -0,0 +1,107 @@
+package scan
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// extractDOCX finds the fonts in the font table and the run fonts (w:rFonts) of the styles and the
+// document parts. Theme fonts (w:asciiTheme, ...) are resolved by Word from the theme and skipped.
+func extractDOCX(docPath string, refs *refList) error {
+	return walkZipXML(docPath, func(name string) bool {
+		return name == "word/fontTable.xml" || name == "word/styles.xml" || name == "word/document.xml" ||
+			(strings.HasPrefix(name, "word/header") || strings.HasPrefix(name, "word/footer")) && path.Ext(name) == ".xml"
+	}, func(name string, el xml.StartElement) {
+		switch el.Name.Local {
+		case "font":
+			if name == "word/fontTable.xml" {
+				refs.add(xmlAttr(el, "name"), "fontTable", false)
+			}
+		case "rFonts":
+			for _, a := range []string{"ascii", "hAnsi", "eastAsia", "cs"} {
+				refs.add(xmlAttr(el, a), "rFonts", false)
+			}
+		}
+	})
+}
+
+// extractODF finds the font face declarations and fo:font-family attributes of ODF documents.
+func extractODF(docPath string, refs *refList) error {
+	return walkZipXML(docPath, func(name string) bool {
+		return name == "content.xml" || name == "styles.xml"
+	}, func(name string, el xml.StartElement) {
+		if el.Name.Local == "font-face" {
+			// svg:font-family is the actual family, style:name only an id that may have a suffix
+			families := parseFamilyList(xmlAttr(el, "font-family"))
+			if len(families) == 0 {
+				families = []string{xmlAttr(el, "name")}
+			}
+			for _, family := range families {
+				refs.add(family, "font-face", false)
+			}
+			return
+		}
+		for _, family := range parseFamilyList(xmlAttr(el, "font-family")) {
+			refs.add(family, "font-family", false)
+		}
+	})
+}
+
+// walkZipXML calls fn for every start element of the XML files in the zip archive that match.
+func walkZipXML(zipPath string, match func(name string) bool, fn func(name string, el xml.StartElement)) error {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) {
+			return fmt.Errorf("invalid document '%s' (%w)", zipPath, err)
+		}
+		return fileError(zipPath, err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if !match(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("can't read '%s' in '%s' (%w)", f.Name, zipPath, err)
+		}
+		err = walkXML(rc, func(el xml.StartElement) { fn(f.Name, el) })
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("invalid XML '%s' in '%s' (%w)", f.Name, zipPath, err)
+		}
+	}
+	return nil
+}
+
+func walkXML(r io.Reader, fn func(el xml.StartElement)) error {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			fn(el)
+		}
+	}
+}
+
+// xmlAttr returns the value of the attribute with the local name, ignoring the namespace.
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
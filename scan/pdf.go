@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// A full PDF parser is overkill for finding fonts: font dictionaries are plain objects (or objects in
+// compressed object streams), so regular expressions over the objects are enough.
+var (
+	pdfObjRe        = regexp.MustCompile(`(?s)(\d+)\s+\d+\s+obj\b(.*?)endobj`)
+	pdfFontTypeRe   = regexp.MustCompile(`/Type\s*/Font\b`)
+	pdfSubtypeRe    = regexp.MustCompile(`/Subtype\s*/(\w+)`)
+	pdfBaseFontRe   = regexp.MustCompile(`/BaseFont\s*/([^\s/<>\[\]()]+)`)
+	pdfDescriptorRe = regexp.MustCompile(`/FontDescriptor\s+(\d+)\s+\d+\s+R`)
+	pdfFontFileRe   = regexp.MustCompile(`/FontFile[23]?\b`)
+	pdfObjStmRe     = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	pdfNRe          = regexp.MustCompile(`/N\s+(\d+)`)
+	pdfFirstRe      = regexp.MustCompile(`/First\s+(\d+)`)
+	pdfSubsetRe     = regexp.MustCompile(`^[A-Z]{6}\+`)
+)
+
+// extractPDF finds the font dictionaries and checks if their font descriptors have an embedded font file.
+// The family is the PostScript name from /BaseFont, without the subset prefix.
+func extractPDF(path string, refs *refList) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileError(path, err)
+	}
+	objects := pdfObjects(data)
+
+	for _, body := range objects {
+		if !pdfFontTypeRe.Match(body) {
+			continue
+		}
+		// composite fonts are described by their descendant font, which is a font object of its own
+		if m := pdfSubtypeRe.FindSubmatch(body); m != nil && (string(m[1]) == "Type0" || string(m[1]) == "Type3") {
+			continue
+		}
+		m := pdfBaseFontRe.FindSubmatch(body)
+		if m == nil {
+			continue
+		}
+		embedded := false
+		if d := pdfDescriptorRe.FindSubmatch(body); d != nil {
+			n, _ := strconv.Atoi(string(d[1]))
+			embedded = pdfFontFileRe.Match(objects[n])
+		}
+		refs.add(pdfFontName(string(m[1])), "BaseFont", embedded)
+	}
+	return nil
+}
+
+// pdfObjects returns the dictionaries of all objects by object number, including the ones in object streams.
+func pdfObjects(data []byte) map[int][]byte {
+	objects := map[int][]byte{}
+	for _, m := range pdfObjRe.FindAllSubmatch(data, -1) {
+		n, err := strconv.Atoi(string(m[1]))
+		if err != nil {
+			continue
+		}
+		body := m[2]
+		if pdfObjStmRe.Match(body) {
+			for k, v := range pdfObjectStream(body) {
+				objects[k] = v
+			}
+		}
+		if i := bytes.Index(body, []byte("stream")); i >= 0 {
+			body = body[:i]
+		}
+		objects[n] = body
+	}
+	return objects
+}
+
+// pdfObjectStream returns the objects in a (Flate compressed) object stream.
+func pdfObjectStream(body []byte) map[int][]byte {
+	objects := map[int][]byte{}
+	dictEnd := bytes.Index(body, []byte("stream"))
+	streamEnd := bytes.LastIndex(body, []byte("endstream"))
+	if dictEnd < 0 || streamEnd < dictEnd {
+		return objects
+	}
+	dict := body[:dictEnd]
+	stream := bytes.TrimLeft(body[dictEnd+len("stream"):streamEnd], "\r\n")
+	if bytes.Contains(dict, []byte("/FlateDecode")) {
+		zr, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			return objects
+		}
+		// streams are often followed by padding, keep what could be decompressed
+		stream, _ = io.ReadAll(zr)
+	}
+
+	nm, fm := pdfNRe.FindSubmatch(dict), pdfFirstRe.FindSubmatch(dict)
+	if nm == nil || fm == nil {
+		return objects
+	}
+	count, _ := strconv.Atoi(string(nm[1]))
+	first, _ := strconv.Atoi(string(fm[1]))
+	if first > len(stream) {
+		return objects
+	}
+	header := bytes.Fields(stream[:first])
+	for i := 0; i+1 < len(header) && i/2 < count; i += 2 {
+		n, err1 := strconv.Atoi(string(header[i]))
+		start, err2 := strconv.Atoi(string(header[i+1]))
+		if err1 != nil || err2 != nil || first+start > len(stream) {
+			continue
+		}
+		end := len(stream)
+		if i+3 < len(header) {
+			if next, err := strconv.Atoi(string(header[i+3])); err == nil && first+next <= len(stream) && next >= start {
+				end = first + next
+			}
+		}
+		objects[n] = stream[first+start : end]
+	}
+	return objects
+}
+
+// pdfFontName decodes #xx escapes and strips the subset prefix of a PDF font name, i.e.
+// "ABCDEF+Open#20Sans-Bold" becomes "Open Sans-Bold". Escaped bytes are decoded together, so escaped
+// UTF-8 sequences become one character. Names that aren't UTF-8 are read as Latin-1.
+func pdfFontName(name string) string {
+	decoded := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] == '#' && i+2 < len(name) {
+			if b, err := strconv.ParseUint(name[i+1:i+3], 16, 8); err == nil {
+				decoded = append(decoded, byte(b))
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, name[i])
+	}
+	if utf8.Valid(decoded) {
+		name = string(decoded)
+	} else {
+		runes := make([]rune, len(decoded))
+		for i, b := range decoded {
+			runes[i] = rune(b)
+		}
+		name = string(runes)
+	}
+	return pdfSubsetRe.ReplaceAllString(name, "")
+}
@@ -0,0 +1,95 @@
+package scan
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Reference is a font a document refers to.
+type Reference struct {
+	Family string `json:"family"`
+	// Sources are where the document refers to the font, i.e. "font-family", "Style" or "\fn".
+	Sources []string `json:"sources"`
+	// Embedded is true if the document contains the font (PDF only), so it doesn't need to be installed.
+	Embedded bool `json:"embedded,omitempty"`
+}
+
+// refList collects references, merging references to the same family.
+type refList []Reference
+
+func (l *refList) add(family, source string, embedded bool) {
+	family = strings.TrimSpace(family)
+	if family == "" {
+		return
+	}
+	if i := slices.IndexFunc(*l, func(r Reference) bool { return strings.EqualFold(r.Family, family) }); i >= 0 {
+		r := &(*l)[i]
+		if !slices.Contains(r.Sources, source) {
+			r.Sources = append(r.Sources, source)
+		}
+		// one embedded copy is enough for the document to render
+		r.Embedded = r.Embedded || embedded
+		return
+	}
+	*l = append(*l, Reference{Family: family, Sources: []string{source}, Embedded: embedded})
+}
+
+// Extract returns the fonts the file refers to, ordered by family. The format is picked by the file
+// extension.
+func Extract(path string) ([]Reference, error) {
+	var refs refList
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".svg":
+		err = extractSVG(path, &refs)
+	case ".ass", ".ssa":
+		err = extractASS(path, &refs)
+	case ".pdf":
+		err = extractPDF(path, &refs)
+	case ".docx", ".dotx", ".docm":
+		err = extractDOCX(path, &refs)
+	case ".odt", ".ods", ".odp", ".ott":
+		err = extractODF(path, &refs)
+	default:
+		return nil, fmt.Errorf("can't scan '%s': unsupported file type '%s' (supported: svg, ass, ssa, pdf, docx, odt, ods, odp)", path, ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(refs, func(a, b Reference) int { return strings.Compare(strings.ToLower(a.Family), strings.ToLower(b.Family)) })
+	return refs, nil
+}
+
+// genericFamilies are CSS generic font families and keywords, which don't refer to a specific font.
+var genericFamilies = []string{
+	"serif", "sans-serif", "monospace", "cursive", "fantasy", "system-ui", "ui-serif", "ui-sans-serif",
+	"ui-monospace", "ui-rounded", "math", "emoji", "fangsong", "inherit", "initial", "unset", "revert",
+}
+
+// parseFamilyList splits a CSS font-family value into family names and drops the generic families,
+// i.e. `"Open Sans", Arial, sans-serif` becomes "Open Sans" and "Arial".
+func parseFamilyList(value string) []string {
+	var families []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.TrimSuffix(strings.TrimSpace(strings.TrimSuffix(part, "!important")), ";")
+		if len(part) >= 2 && (part[0] == '"' || part[0] == '\'') && part[len(part)-1] == part[0] {
+			part = part[1 : len(part)-1]
+		} else if slices.Contains(genericFamilies, strings.ToLower(part)) {
+			continue
+		}
+		if part != "" {
+			families = append(families, part)
+		}
+	}
+	return families
+}
+
+// fileError wraps errors reading a document like the fonts package does for font files.
+func fileError(path string, err error) error {
+	return fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, path, fonts.WithAccessDenied(err))
+}
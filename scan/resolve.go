@@ -0,0 +1,110 @@
+package scan
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Status is where a referenced font was found.
+type Status string
+
+const (
+	StatusInstalled Status = "installed" // installed on the system
+	StatusEmbedded  Status = "embedded"  // not installed, but embedded in the document
+	StatusLibrary   Status = "library"   // not installed, but available in the font library
+	StatusMissing   Status = "missing"
+)
+
+// Result is a reference with the fonts that match it.
+type Result struct {
+	Reference
+	Status Status   `json:"status"`
+	Files  []string `json:"files,omitempty"` // matching installed or library font files
+}
+
+// Catalog is a searchable list of font faces, i.e. of the installed fonts or of a font library.
+type Catalog []fonts.Face
+
+// BuildCatalog reads the faces of the font files in the dirs. Dirs that don't exist and files that
+// can't be parsed are skipped.
+func BuildCatalog(dirs ...string) (Catalog, error) {
+	var c Catalog
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		paths, err := fonts.FindFontFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			faces, err := fonts.ReadFaces(p)
+			if err != nil {
+				continue
+			}
+			c = append(c, faces...)
+		}
+	}
+	return c, nil
+}
+
+// psStyleRe matches the style part of PostScript names ("Arial-BoldMT", "Arial,Bold") and the "MT"/"PS"
+// vendor suffixes, to get from a PostScript name in a PDF to a family name.
+var psStyleRe = regexp.MustCompile(`([,-].*|MT|PS)$`)
+
+// Find returns the faces that match the reference, by family, full or PostScript name. References by
+// PostScript name that don't match are retried with the style suffix removed, i.e. "Arial-BoldMT" finds
+// the faces of the "Arial" family.
+func (c Catalog) Find(ref Reference) []fonts.Face {
+	names := []string{ref.Family}
+	if family := psStyleRe.ReplaceAllString(ref.Family, ""); family != ref.Family && family != "" {
+		names = append(names, family)
+	}
+	for _, name := range names {
+		var found []fonts.Face
+		for _, f := range c {
+			if f.Matches(name) {
+				found = append(found, f)
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+	}
+	return nil
+}
+
+// Resolve checks where the referenced fonts are available: installed, embedded in the document, in the
+// font library, or missing.
+func Resolve(refs []Reference, installed, library Catalog) []Result {
+	results := make([]Result, 0, len(refs))
+	for _, ref := range refs {
+		r := Result{Reference: ref, Status: StatusMissing}
+		if found := installed.Find(ref); len(found) > 0 {
+			r.Status, r.Files = StatusInstalled, facePaths(found)
+		} else if ref.Embedded {
+			r.Status = StatusEmbedded
+		} else if found := library.Find(ref); len(found) > 0 {
+			r.Status, r.Files = StatusLibrary, facePaths(found)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// facePaths returns the distinct files of the faces.
+func facePaths(faces []fonts.Face) []string {
+	var paths []string
+	for _, f := range faces {
+		if !slices.ContainsFunc(paths, func(p string) bool { return strings.EqualFold(p, f.Path) }) {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,65 @@
+package scan
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// cssFontFamilyRe finds font-family declarations in CSS, i.e. in <style> elements and style attributes.
+var cssFontFamilyRe = regexp.MustCompile(`(?i)font-family\s*:\s*([^;}]+)`)
+
+// extractSVG finds font-family attributes and CSS declarations.
+func extractSVG(path string, refs *refList) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fileError(path, err)
+	}
+	defer f.Close()
+
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	inStyle := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid SVG file '%s' (%w)", path, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			inStyle = t.Name.Local == "style"
+			for _, a := range t.Attr {
+				switch a.Name.Local {
+				case "font-family":
+					for _, family := range parseFamilyList(a.Value) {
+						refs.add(family, "font-family", false)
+					}
+				case "style":
+					addCSSFamilies(a.Value, refs)
+				}
+			}
+		case xml.EndElement:
+			inStyle = false
+		case xml.CharData:
+			if inStyle {
+				addCSSFamilies(string(t), refs)
+			}
+		}
+	}
+}
+
+func addCSSFamilies(css string, refs *refList) {
+	for _, m := range cssFontFamilyRe.FindAllStringSubmatch(css, -1) {
+		for _, family := range parseFamilyList(strings.TrimSpace(m[1])) {
+			refs.add(family, "font-family", false)
+		}
+	}
+}
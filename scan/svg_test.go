@@ -0,0 +1,61 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// extractFile writes a document with the file name and returns the references Extract finds in it.
+func extractFile(t *testing.T, name string, data []byte) []Reference {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	refs, err := Extract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return refs
+}
+
+func TestExtractSVG(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		want []Reference
+	}{
+		{"attribute", `<svg xmlns="http://www.w3.org/2000/svg"><text font-family="'Open Sans', Arial, sans-serif">Hi</text></svg>`,
+			[]Reference{{Family: "Arial", Sources: []string{"font-family"}}, {Family: "Open Sans", Sources: []string{"font-family"}}}},
+		{"style attribute", `<svg><text style="fill: red; font-family: &quot;Fira Code&quot;, monospace; font-size: 12px">x</text></svg>`,
+			[]Reference{{Family: "Fira Code", Sources: []string{"font-family"}}}},
+		{"style element", `<svg><style>text { font-family: Roboto !important } .title{FONT-FAMILY:"Roboto"}</style><text>x</text></svg>`,
+			[]Reference{{Family: "Roboto", Sources: []string{"font-family"}}}},
+		{"CDATA style", `<svg><style><![CDATA[ .a { font-family: "Noto Serif", serif; } ]]></style></svg>`,
+			[]Reference{{Family: "Noto Serif", Sources: []string{"font-family"}}}},
+		{"text isn't CSS", `<svg><text>font-family: Comic Sans MS;</text></svg>`, nil},
+		{"quoted generic name", `<svg><text font-family="'serif', cursive">x</text></svg>`,
+			[]Reference{{Family: "serif", Sources: []string{"font-family"}}}},
+		{"other charset", `<?xml version="1.0" encoding="ISO-8859-1"?><svg><text font-family="Helvetica">x</text></svg>`,
+			[]Reference{{Family: "Helvetica", Sources: []string{"font-family"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractFile(t, "drawing.svg", []byte(tt.svg)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSVGInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.svg")
+	if err := os.WriteFile(path, []byte(`<svg><text font-family="Arial">`+"\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Extract(path); err == nil {
+		t.Error("Extract() of an invalid SVG file succeeded")
+	}
+}
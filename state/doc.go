@@ -0,0 +1,7 @@
+// Package state keeps track of the fonts that fontctl loaded temporarily. The OS font APIs can't list
+// the font resources a tool added, so every successful load and unload is recorded in a state file.
+// The file remembers the boot time it was written in, entries from an earlier boot are stale (the OS
+// dropped the fonts on reboot) and get removed when the file is read.
+//
+// The package is operating system independent, the caller provides the file path and the boot time.
+package state
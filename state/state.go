@@ -0,0 +1,164 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// bootTimeTolerance is how much the boot time may differ between two reads and still be the same boot.
+// Boot times calculated from the uptime drift by a few milliseconds.
+const bootTimeTolerance = time.Minute
+
+// lock file settings: wait up to lockTimeout for another fontctl to finish, and remove locks older than
+// staleLockAge (left over by a crashed fontctl).
+const (
+	lockTimeout  = 10 * time.Second
+	lockRetry    = 50 * time.Millisecond
+	staleLockAge = time.Minute
+)
+
+// Entry is a font loaded by fontctl.
+type Entry struct {
+	Path     string    `json:"path"`           // as passed to the OS, "<pfm>|<pfb>" for Type 1 fonts
+	Hash     string    `json:"hash,omitempty"` // sha256:<hex> of the font file at load time
+	LoadedAt time.Time `json:"loaded_at"`      // first load
+	RefCount int       `json:"refcount"`       // number of loads without unload
+}
+
+// State is the content of the state file.
+type State struct {
+	BootTime time.Time `json:"boot_time"`
+	Fonts    []Entry   `json:"fonts"`
+}
+
+// Add records a load of the font. Loading a font that is already loaded increments its reference count,
+// as the OS does.
+func (s *State) Add(path, hash string, now time.Time) {
+	if i := s.index(path); i >= 0 {
+		s.Fonts[i].RefCount++
+		return
+	}
+	s.Fonts = append(s.Fonts, Entry{Path: path, Hash: hash, LoadedAt: now, RefCount: 1})
+}
+
+// Remove records an unload of the font and returns false if the font isn't tracked.
+func (s *State) Remove(path string) bool {
+	i := s.index(path)
+	if i < 0 {
+		return false
+	}
+	s.Fonts[i].RefCount--
+	if s.Fonts[i].RefCount <= 0 {
+		s.Fonts = slices.Delete(s.Fonts, i, i+1)
+	}
+	return true
+}
+
+// index returns the index of the font or -1. Paths are compared case-insensitively on Windows.
+func (s *State) index(path string) int {
+	if runtime.GOOS != "windows" {
+		return slices.IndexFunc(s.Fonts, func(e Entry) bool { return e.Path == path })
+	}
+	return slices.IndexFunc(s.Fonts, func(e Entry) bool { return strings.EqualFold(e.Path, path) })
+}
+
+// Store is a state file.
+type Store struct {
+	// Path of the state file. The directory is created when needed.
+	Path string
+	// BootTime is the boot time of the running system. Entries recorded in another boot are dropped.
+	BootTime time.Time
+}
+
+// Load reads the state file. A missing file is an empty state, a file from an earlier boot too.
+func (s Store) Load() (State, error) {
+	st := State{BootTime: s.BootTime}
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("can't read state file '%s' (%w)", s.Path, err)
+	}
+	var saved State
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return st, fmt.Errorf("invalid state file '%s' (%w)", s.Path, err)
+	}
+	if d := saved.BootTime.Sub(s.BootTime); d > bootTimeTolerance || d < -bootTimeTolerance {
+		// the system was rebooted since, so none of the fonts is loaded anymore
+		return st, nil
+	}
+	st.Fonts = saved.Fonts
+	return st, nil
+}
+
+// Update reads the state file, calls fn and writes the changed state back. Concurrent fontctl processes
+// are serialized with a lock file. If fn returns an error, nothing is written.
+func (s Store) Update(fn func(*State) error) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	st, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if err := fn(&st); err != nil {
+		return err
+	}
+	return s.write(st)
+}
+
+// write replaces the state file atomically, so a crash never leaves a half written file.
+func (s Store) write(st State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("can't write state file '%s' (%w)", tmp, err)
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("can't write state file '%s' (%w)", s.Path, err)
+	}
+	return nil
+}
+
+// lock creates the lock file next to the state file and returns a function that removes it.
+func (s Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return nil, fmt.Errorf("can't create state dir '%s' (%w)", filepath.Dir(s.Path), err)
+	}
+	lockPath := s.Path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("can't create lock file '%s' (%w)", lockPath, err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("state file '%s' is locked by another fontctl process (remove '%s' if there is none)", s.Path, lockPath)
+		}
+		time.Sleep(lockRetry)
+	}
+}
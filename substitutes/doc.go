@@ -0,0 +1,10 @@
+// Package substitutes manages the font substitution rules of MS Windows: the FontSubstitutes key, which
+// maps a font name to another font (i.e. "Helvetica" to "Arial"), and the SystemLink key, which lists the
+// fallback fonts GDI and DirectWrite use for characters a font doesn't have (i.e. CJK fonts for
+// "Segoe UI").
+//
+// The rules work on a Key, which the registry.Key of golang.org/x/sys/windows/registry implements. MemKey
+// is an in-memory Key, so the rules can be checked on any OS. winfont opens the real keys.
+//
+// The package is operating system independent.
+package substitutes
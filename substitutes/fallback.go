@@ -0,0 +1,122 @@
+package substitutes
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Fallback is a SystemLink rule: characters that Font doesn't have are taken from the linked fonts, in
+// order. A link is "<font file>,<family>", optionally followed by scaling factors, i.e.
+// "NotoSansCJK.ttc,Noto Sans CJK JP".
+type Fallback struct {
+	Font  string   `json:"font"`
+	Links []string `json:"links"`
+}
+
+// ListFallbacks returns the SystemLink rules, ordered by font name.
+func ListFallbacks(k Key) ([]Fallback, error) {
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+	list := []Fallback{}
+	for _, name := range names {
+		links, _, err := k.GetStringsValue(name)
+		if err != nil {
+			continue // not a REG_MULTI_SZ, Windows ignores it too
+		}
+		list = append(list, Fallback{Font: name, Links: links})
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Font) < strings.ToLower(list[j].Font) })
+	return list, nil
+}
+
+// AddFallback adds a fallback font to the SystemLink rule of a font, at the end or with first at the
+// start of the list. If the font file is already linked, its link is replaced and moved.
+func AddFallback(k Key, font, link string, first bool) error {
+	font = strings.TrimSpace(font)
+	if font == "" {
+		return fmt.Errorf("invalid font name '%s'", font)
+	}
+	link, err := normalizeLink(link)
+	if err != nil {
+		return err
+	}
+	links, _, err := k.GetStringsValue(font)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	links = slices.DeleteFunc(links, func(l string) bool { return sameFile(l, link) })
+	if first {
+		links = slices.Insert(links, 0, link)
+	} else {
+		links = append(links, link)
+	}
+	return k.SetStringsValue(font, links)
+}
+
+// RemoveFallback removes the link to a font file from the SystemLink rule of a font, or the whole rule
+// if link is empty. Only the file part of link is compared, case-insensitively.
+func RemoveFallback(k Key, font, link string) error {
+	font = strings.TrimSpace(font)
+	if font == "" {
+		return fmt.Errorf("invalid font name '%s'", font)
+	}
+	links, _, err := k.GetStringsValue(font)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: '%s' has no fallback fonts", ErrNotFound, font)
+		}
+		return err
+	}
+	if link != "" {
+		n := len(links)
+		links = slices.DeleteFunc(links, func(l string) bool { return sameFile(l, link) })
+		if len(links) == n {
+			return fmt.Errorf("%w: '%s' is not a fallback of '%s'", ErrNotFound, link, font)
+		}
+		if len(links) > 0 {
+			return k.SetStringsValue(font, links)
+		}
+	}
+	return k.DeleteValue(font)
+}
+
+// normalizeLink checks a link and trims its parts. The file must be a TrueType or OpenType font, GDI
+// doesn't link other formats.
+func normalizeLink(link string) (string, error) {
+	parts := strings.Split(link, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	switch strings.ToLower(fileExt(parts[0])) {
+	case ".ttf", ".ttc", ".otf", ".otc":
+	default:
+		return "", fmt.Errorf("invalid fallback '%s', expected <font file>,<family> with a .ttf, .ttc, .otf or .otc file: %w", link, fonts.ErrNotAFont)
+	}
+	if len(parts) > 1 && parts[1] == "" {
+		return "", fmt.Errorf("invalid fallback '%s', the family after the comma is empty", link)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// sameFile reports whether two links point to the same font file.
+func sameFile(a, b string) bool {
+	fileA, _, _ := strings.Cut(a, ",")
+	fileB, _, _ := strings.Cut(b, ",")
+	return strings.EqualFold(strings.TrimSpace(fileA), strings.TrimSpace(fileB))
+}
+
+// fileExt returns the extension of a file name, which can be a Windows path.
+func fileExt(file string) string {
+	if i := strings.LastIndexAny(file, `.\/`); i >= 0 && file[i] == '.' {
+		return file[i:]
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package substitutes
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"fontctl/fonts"
+)
+
+// links returns the SystemLink rule of font, nil if it has none.
+func links(t *testing.T, k *MemKey, font string) []string {
+	t.Helper()
+	list, err := ListFallbacks(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range list {
+		if f.Font == font {
+			return f.Links
+		}
+	}
+	return nil
+}
+
+func TestAddFallback(t *testing.T) {
+	k := &MemKey{}
+	for _, link := range []string{"MSGOTHIC.TTC,MS UI Gothic", " SimSun.ttc , SimSun "} {
+		if err := AddFallback(k, "Segoe UI", link, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddFallback(k, "Segoe UI", "NotoSansCJK.ttc,Noto Sans CJK JP", true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"NotoSansCJK.ttc,Noto Sans CJK JP", "MSGOTHIC.TTC,MS UI Gothic", "SimSun.ttc,SimSun"}
+	if got := links(t, k, "Segoe UI"); !slices.Equal(got, want) {
+		t.Errorf("links = %q, want %q", got, want)
+	}
+
+	// a linked file is replaced and moved, not linked twice
+	if err := AddFallback(k, "segoe ui", "msgothic.ttc,MS Gothic", false); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"NotoSansCJK.ttc,Noto Sans CJK JP", "SimSun.ttc,SimSun", "msgothic.ttc,MS Gothic"}
+	if got := links(t, k, "Segoe UI"); !slices.Equal(got, want) {
+		t.Errorf("links after adding a linked file again = %q, want %q", got, want)
+	}
+	if err := AddFallback(k, "Segoe UI", "SimSun.ttc,SimSun", true); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"SimSun.ttc,SimSun", "NotoSansCJK.ttc,Noto Sans CJK JP", "msgothic.ttc,MS Gothic"}
+	if got := links(t, k, "Segoe UI"); !slices.Equal(got, want) {
+		t.Errorf("links after moving a link first = %q, want %q", got, want)
+	}
+}
+
+func TestAddFallbackInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		font string
+		link string
+	}{
+		{"empty font", " ", "SimSun.ttc,SimSun"},
+		{"Type 1 font", "Segoe UI", "Foo.pfm,Foo"},
+		{"no extension", "Segoe UI", `C:\Fonts.d\SimSun,SimSun`},
+		{"empty family", "Segoe UI", "SimSun.ttc, "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &MemKey{}
+			if err := AddFallback(k, tt.font, tt.link, false); err == nil {
+				t.Errorf("AddFallback(%q, %q) succeeded, want an error", tt.font, tt.link)
+			}
+			if list, _ := ListFallbacks(k); len(list) != 0 {
+				t.Errorf("invalid link added %v", list)
+			}
+		})
+	}
+	if err := AddFallback(&MemKey{}, "Segoe UI", "Foo.pfb", false); !errors.Is(err, fonts.ErrNotAFont) {
+		t.Errorf("AddFallback() of a .pfb error = %v, want %v", err, fonts.ErrNotAFont)
+	}
+}
+
+func TestRemoveFallback(t *testing.T) {
+	k := &MemKey{}
+	k.SetStringsValue("Segoe UI", []string{"MSGOTHIC.TTC,MS UI Gothic", "SimSun.ttc,SimSun,128,96"})
+	k.SetStringsValue("Tahoma", []string{"SimSun.ttc,SimSun"})
+
+	// only the file is compared, case-insensitively
+	if err := RemoveFallback(k, "segoe ui", "simsun.TTC"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := links(t, k, "Segoe UI"), []string{"MSGOTHIC.TTC,MS UI Gothic"}; !slices.Equal(got, want) {
+		t.Errorf("links = %q, want %q", got, want)
+	}
+	if err := RemoveFallback(k, "Segoe UI", "SimSun.ttc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveFallback() of a removed link error = %v, want %v", err, ErrNotFound)
+	}
+	// removing the last link removes the rule
+	if err := RemoveFallback(k, "Segoe UI", "MSGOTHIC.TTC,MS Gothic"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveFallback(k, "Tahoma", ""); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := ListFallbacks(k); len(list) != 0 {
+		t.Errorf("ListFallbacks() = %v, want no rules left", list)
+	}
+	if err := RemoveFallback(k, "Tahoma", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveFallback() of a removed rule error = %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,109 @@
+package substitutes
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Registry value types, as in golang.org/x/sys/windows/registry.
+const (
+	typeString  = 1 // REG_SZ
+	typeStrings = 7 // REG_MULTI_SZ
+)
+
+// Key is the part of an open registry key the rules need. Errors for missing values match
+// fs.ErrNotExist, as the registry.ErrNotExist errno does.
+type Key interface {
+	ReadValueNames(n int) ([]string, error)
+	GetStringValue(name string) (val string, valtype uint32, err error)
+	GetStringsValue(name string) (val []string, valtype uint32, err error)
+	SetStringValue(name, value string) error
+	SetStringsValue(name string, value []string) error
+	DeleteValue(name string) error
+}
+
+// MemKey is an in-memory Key. Value names are case-insensitive, as in the registry. The zero value is an
+// empty key.
+type MemKey struct {
+	values map[string]memValue // by lower case name
+}
+
+type memValue struct {
+	name    string
+	strings []string
+	valtype uint32
+}
+
+func (k *MemKey) ReadValueNames(n int) ([]string, error) {
+	names := make([]string, 0, len(k.values))
+	for _, v := range k.values {
+		names = append(names, v.name)
+	}
+	sort.Strings(names)
+	if n > 0 && len(names) > n {
+		names = names[:n]
+	}
+	return names, nil
+}
+
+func (k *MemKey) GetStringValue(name string) (string, uint32, error) {
+	v, err := k.get(name, typeString)
+	if err != nil {
+		return "", v.valtype, err
+	}
+	return v.strings[0], v.valtype, nil
+}
+
+func (k *MemKey) GetStringsValue(name string) ([]string, uint32, error) {
+	v, err := k.get(name, typeStrings)
+	if err != nil {
+		return nil, v.valtype, err
+	}
+	return append([]string(nil), v.strings...), v.valtype, nil
+}
+
+func (k *MemKey) SetStringValue(name, value string) error {
+	return k.set(name, []string{value}, typeString)
+}
+
+func (k *MemKey) SetStringsValue(name string, value []string) error {
+	for _, s := range value {
+		if strings.ContainsRune(s, 0) {
+			return fmt.Errorf("value '%s' of '%s' contains a NUL character", s, name)
+		}
+	}
+	return k.set(name, append([]string(nil), value...), typeStrings)
+}
+
+func (k *MemKey) DeleteValue(name string) error {
+	if _, ok := k.values[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("value '%s' (%w)", name, fs.ErrNotExist)
+	}
+	delete(k.values, strings.ToLower(name))
+	return nil
+}
+
+func (k *MemKey) get(name string, valtype uint32) (memValue, error) {
+	v, ok := k.values[strings.ToLower(name)]
+	if !ok {
+		return v, fmt.Errorf("value '%s' (%w)", name, fs.ErrNotExist)
+	}
+	if v.valtype != valtype {
+		return v, fmt.Errorf("value '%s' has an unexpected type %d", name, v.valtype)
+	}
+	return v, nil
+}
+
+func (k *MemKey) set(name string, value []string, valtype uint32) error {
+	if k.values == nil {
+		k.values = map[string]memValue{}
+	}
+	// like the registry, keep the case of an existing name
+	if v, ok := k.values[strings.ToLower(name)]; ok {
+		name = v.name
+	}
+	k.values[strings.ToLower(name)] = memValue{name: name, strings: value, valtype: valtype}
+	return nil
+}
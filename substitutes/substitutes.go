@@ -0,0 +1,115 @@
+package substitutes
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Registry keys of the rules, in HKLM.
+const (
+	FontSubstitutesKeyPath = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontSubstitutes`
+	SystemLinkKeyPath      = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\FontLink\SystemLink`
+)
+
+var (
+	// ErrNotFound is returned when a rule to remove doesn't exist.
+	ErrNotFound = errors.New("no such font substitution rule")
+	// ErrExists is returned when a font already has a different substitute.
+	ErrExists = errors.New("font already has a different substitute")
+)
+
+// Substitute is a FontSubstitutes rule: applications asking for Font get Substitute. Both can have a
+// character set, i.e. "Arial Baltic,186" -> "Arial,186".
+type Substitute struct {
+	Font       string `json:"font"`
+	Substitute string `json:"substitute"`
+}
+
+// ListSubstitutes returns the FontSubstitutes rules, ordered by font name.
+func ListSubstitutes(k Key) ([]Substitute, error) {
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+	list := []Substitute{}
+	for _, name := range names {
+		val, _, err := k.GetStringValue(name)
+		if err != nil {
+			continue // not a string, Windows ignores it too
+		}
+		list = append(list, Substitute{Font: name, Substitute: val})
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Font) < strings.ToLower(list[j].Font) })
+	return list, nil
+}
+
+// AddSubstitute makes applications asking for font get substitute. Names can have a character set name
+// or number after a comma ("Helvetica,ANSI" or "Helvetica,0"), which is stored as number. If font already
+// has a different substitute, ErrExists is returned unless replace is set. Adding an existing rule does
+// nothing.
+func AddSubstitute(k Key, font, substitute string, replace bool) error {
+	font, fontCharset, err := normalizeName(font)
+	if err != nil {
+		return err
+	}
+	substitute, substituteCharset, err := normalizeName(substitute)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(font, substitute) {
+		return fmt.Errorf("font '%s' can't be its own substitute", font)
+	}
+	if fontCharset != substituteCharset && (fontCharset == "" || substituteCharset == "") {
+		return fmt.Errorf("'%s' and '%s' must both have a character set or none", font, substitute)
+	}
+
+	existing, _, err := k.GetStringValue(font)
+	switch {
+	case err == nil && strings.EqualFold(existing, substitute):
+		return nil
+	case err == nil && !replace:
+		return fmt.Errorf("%w: '%s' is substituted by '%s'", ErrExists, font, existing)
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	return k.SetStringValue(font, substitute)
+}
+
+// RemoveSubstitute removes the FontSubstitutes rule of a font.
+func RemoveSubstitute(k Key, font string) error {
+	font, _, err := normalizeName(font)
+	if err != nil {
+		return err
+	}
+	if _, _, err := k.GetStringValue(font); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: '%s' has no substitute", ErrNotFound, font)
+		}
+		return err
+	}
+	return k.DeleteValue(font)
+}
+
+// normalizeName trims a font name and replaces the character set name after a comma with its number.
+func normalizeName(name string) (normalized, charset string, err error) {
+	face, cs, hasCharset := strings.Cut(name, ",")
+	face = strings.TrimSpace(face)
+	if face == "" {
+		return "", "", fmt.Errorf("invalid font name '%s'", name)
+	}
+	if !hasCharset {
+		return face, "", nil
+	}
+	n, err := fonts.ParseCharset(cs)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid font name '%s' (%w)", name, err)
+	}
+	charset = strconv.Itoa(n)
+	return face + "," + charset, charset, nil
+}
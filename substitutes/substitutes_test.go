@@ -0,0 +1,86 @@
+package substitutes
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAddSubstitute(t *testing.T) {
+	tests := []struct {
+		name       string
+		font       string
+		substitute string
+		wantFont   string
+		want       string
+		wantErr    bool
+	}{
+		{"plain names", " Helvetica ", "Arial", "Helvetica", "Arial", false},
+		{"charset names", "Arial Baltic,BALTIC", "Arial, baltic_charset", "Arial Baltic,186", "Arial,186", false},
+		{"charset numbers", "Arial Cyr,204", "Arial,RUSSIAN", "Arial Cyr,204", "Arial,204", false},
+		{"only one charset", "Helvetica,ANSI", "Arial", "", "", true},
+		{"unknown charset", "Helvetica,KLINGON", "Arial,KLINGON", "", "", true},
+		{"own substitute", "Arial", "arial", "", "", true},
+		{"empty name", " ", "Arial", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &MemKey{}
+			err := AddSubstitute(k, tt.font, tt.substitute, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddSubstitute() error = %v, want error %t", err, tt.wantErr)
+			}
+			list, _ := ListSubstitutes(k)
+			var want []Substitute
+			if !tt.wantErr {
+				want = []Substitute{{Font: tt.wantFont, Substitute: tt.want}}
+			}
+			if !slices.Equal(list, want) {
+				t.Errorf("ListSubstitutes() = %v, want %v", list, want)
+			}
+		})
+	}
+}
+
+func TestAddSubstituteConflict(t *testing.T) {
+	k := &MemKey{}
+	if err := AddSubstitute(k, "Helvetica", "Arial", false); err != nil {
+		t.Fatal(err)
+	}
+	// the same rule again, with another case, is no conflict
+	if err := AddSubstitute(k, "HELVETICA", "arial", false); err != nil {
+		t.Errorf("AddSubstitute() of an existing rule error = %v", err)
+	}
+	if err := AddSubstitute(k, "Helvetica", "Liberation Sans", false); !errors.Is(err, ErrExists) {
+		t.Errorf("AddSubstitute() of a different substitute error = %v, want %v", err, ErrExists)
+	}
+	if err := AddSubstitute(k, "helvetica", "Liberation Sans", true); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := ListSubstitutes(k)
+	if want := []Substitute{{Font: "Helvetica", Substitute: "Liberation Sans"}}; !slices.Equal(list, want) {
+		t.Errorf("ListSubstitutes() after replace = %v, want %v", list, want)
+	}
+
+	if err := RemoveSubstitute(k, "HELVETICA"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveSubstitute(k, "Helvetica"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveSubstitute() of a removed rule error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestListSubstitutesSkipsOtherTypes(t *testing.T) {
+	k := &MemKey{}
+	k.SetStringValue("Times", "Times New Roman")
+	k.SetStringsValue("Courier", []string{"Courier New"})
+	k.SetStringValue("arial narrow", "Arial")
+	list, err := ListSubstitutes(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Substitute{{Font: "arial narrow", Substitute: "Arial"}, {Font: "Times", Substitute: "Times New Roman"}}
+	if !slices.Equal(list, want) {
+		t.Errorf("ListSubstitutes() = %v, want %v sorted by name without the REG_MULTI_SZ value", list, want)
+	}
+}
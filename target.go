@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"fontctl/fonts"
+	"fontctl/macfont"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// install targets: the running system, or a macOS font dir layout under --root
+const (
+	targetNative      = "native"
+	targetMacOSLayout = "macos-layout"
+)
+
+// targetFlags are the flags of fontctl install that stage fonts for another system instead of installing
+// them.
+var targetFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "target",
+		Value: targetNative,
+		Usage: "Where to install: native (this system) or macos-layout (stage in the macOS font dirs under --root)",
+	},
+	&cli.StringFlag{
+		Name:  "root",
+		Usage: "With --target macos-layout: dir that stands for the Mac's boot volume",
+	},
+	&cli.StringFlag{
+		Name:  "mac-user",
+		Usage: "With --target macos-layout: stage in Users/<name>/Library/Fonts (default: the current user name). Ignored with --systemwide.",
+	},
+}
+
+// stageFont installs a font for a --target other than native. It returns false if the target is native.
+func stageFont(ctx context.Context, c *cli.Command) (bool, error) {
+	switch c.String("target") {
+	case targetNative:
+		return false, nil
+	case targetMacOSLayout:
+	default:
+		return true, cli.Exit(fmt.Sprintf("Error - invalid target '%s' (must be %s or %s)", c.String("target"), targetNative, targetMacOSLayout), exitUsage)
+	}
+	if c.String("root") == "" {
+		return true, cli.Exit("Error - --target macos-layout requires --root", exitUsage)
+	}
+	onConflict, err := fonts.ParseConflictPolicy(c.String("on-conflict"))
+	if err != nil {
+		return true, cli.Exit(fmt.Sprintf("Error - %s", err), exitUsage)
+	}
+	opts := macfont.Options{
+		Logger:     logger,
+		Layout:     macfont.Layout{Root: c.String("root"), SystemWide: c.Bool("systemwide"), User: c.String("mac-user")},
+		OnConflict: onConflict,
+	}
+	if verifier.Enabled() {
+		opts.Verify = verifier.Verify
+	}
+	if _, err := macfont.StageFontFromFile(ctx, c.Args().First(), opts); err != nil {
+		return true, exitWithError(err)
+	}
+	return true, nil
+}
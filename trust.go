@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fontctl/trust"
+
+	cli "github.com/urfave/cli/v3"
+)
+
+// trustFlags are the global flags that restrict which font files may be installed or loaded.
+var trustFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "require-allowlist",
+		Usage: "only install or load font files whose SHA-256 hash is in this file (one sha256:<hex> or sha256sum line per file)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "trusted-key",
+		Usage: "only install or load font files with a valid .minisig or .sig signature by this minisign or SSH public key (can be repeated)",
+	},
+}
+
+// verifier checks font files before they are installed or loaded, built from the trust flags. It is
+// nil if no trust flag is set.
+var verifier *trust.Verifier
+
+// newVerifier builds the verifier for the trust flags.
+func newVerifier(c *cli.Command) (*trust.Verifier, error) {
+	allowlistPath, keyPaths := c.String("require-allowlist"), c.StringSlice("trusted-key")
+	if allowlistPath == "" && len(keyPaths) == 0 {
+		return nil, nil
+	}
+	v := &trust.Verifier{Logger: logger}
+	if allowlistPath != "" {
+		list, err := trust.LoadAllowlist(allowlistPath)
+		if err != nil {
+			return nil, err
+		}
+		v.Allowlist = list
+	}
+	if len(keyPaths) > 0 {
+		keys, err := trust.LoadKeys(keyPaths...)
+		if err != nil {
+			return nil, err
+		}
+		v.Keys = keys
+	}
+	return v, nil
+}
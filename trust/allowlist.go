@@ -0,0 +1,46 @@
+package trust
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// Allowlist is a set of SHA-256 hashes of vetted font files.
+type Allowlist map[string]bool
+
+// LoadAllowlist reads an allowlist file. Every line is a hash in "sha256:<hex>" or plain hex notation,
+// optionally followed by a file name, so the output of sha256sum is an allowlist too. Empty lines and
+// lines starting with # are ignored.
+func LoadAllowlist(path string) (Allowlist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, path, fonts.WithAccessDenied(err))
+	}
+	list := Allowlist{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, err := fonts.ParseHash(strings.Fields(line)[0])
+		if err != nil {
+			return nil, fmt.Errorf("allowlist '%s' line %d: %w", path, n, err)
+		}
+		list[fonts.FormatHash(hash)] = true
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("can't read allowlist '%s' (%w)", path, err)
+	}
+	return list, nil
+}
+
+// Contains reports whether the allowlist has a hash from fonts.HashFile.
+func (a Allowlist) Contains(hash []byte) bool {
+	return a[fonts.FormatHash(hash)]
+}
@@ -0,0 +1,65 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAllowlist(t *testing.T) {
+	a := sha256.Sum256([]byte("a"))
+	b := sha256.Sum256([]byte("b"))
+	c := sha256.Sum256([]byte("c"))
+	d := sha256.Sum256([]byte("d"))
+	tests := []struct {
+		name    string
+		content string
+		want    [][32]byte
+		wantErr bool
+	}{
+		{"sha256 prefix", "sha256:" + hex.EncodeToString(a[:]) + "\n", [][32]byte{a}, false},
+		{"sha256sum output", hex.EncodeToString(a[:]) + "  Foo.ttf\n" + hex.EncodeToString(b[:]) + " *Bar Bold.otf\n", [][32]byte{a, b}, false},
+		{"comments and empty lines", "# vetted fonts\n\n  # indented comment\r\n" + hex.EncodeToString(c[:]) + "\r\n\n", [][32]byte{c}, false},
+		{"upper case", "SHA256:" + strings.ToUpper(hex.EncodeToString(a[:])) + "\n" + strings.ToUpper(hex.EncodeToString(d[:])), [][32]byte{a, d}, false},
+		{"empty", "", nil, false},
+		{"short hash", "sha256:" + hex.EncodeToString(a[:16]) + "\n", nil, true},
+		{"other hash", "md5:" + hex.EncodeToString(a[:]) + "\n", nil, true},
+		{"not hex", "# header\n" + strings.Repeat("x", 64) + "\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "allowlist.txt")
+			writeFile(t, path, []byte(tt.content))
+			list, err := LoadAllowlist(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadAllowlist() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(list) != len(tt.want) {
+				t.Errorf("LoadAllowlist() = %v, want %d hashes", list, len(tt.want))
+			}
+			for _, h := range tt.want {
+				if !list.Contains(h[:]) {
+					t.Errorf("LoadAllowlist() = %v, misses %x", list, h)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadAllowlistErrorLine(t *testing.T) {
+	a := sha256.Sum256([]byte("a"))
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	writeFile(t, path, []byte("# vetted fonts\n"+hex.EncodeToString(a[:])+"\nfoo bar\n"))
+	_, err := LoadAllowlist(path)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("LoadAllowlist() error = %v, want an error for line 3", err)
+	}
+	if _, err := LoadAllowlist(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadAllowlist() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,7 @@
+// Package trust decides whether a font file may be installed or loaded: its SHA-256 hash must be in an
+// allowlist, and it must have a detached signature by a trusted key. Signatures are minisign (Ed25519)
+// signatures in a .minisig file or SSH signatures (ssh-keygen -Y sign -n file) in a .sig file next to
+// the signed file.
+//
+// The package is operating system independent.
+package trust
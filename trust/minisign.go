@@ -0,0 +1,122 @@
+package trust
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// MinisignSuffix is the file name suffix of minisign signatures.
+const MinisignSuffix = ".minisig"
+
+// minisignKey is a minisign public key.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// parseMinisignKey parses a minisign public key file, or the base64 key line alone.
+func parseMinisignKey(data []byte) (minisignKey, error) {
+	var k minisignKey
+	line := ""
+	for _, l := range strings.Split(string(data), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+			break
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return k, errors.New("not a minisign public key")
+	}
+	copy(k.id[:], raw[2:10])
+	k.key = ed25519.PublicKey(raw[10:])
+	return k, nil
+}
+
+// minisignSig is a parsed minisign signature file.
+type minisignSig struct {
+	prehashed      bool // "ED": the signature is over the BLAKE2b-512 hash of the file
+	keyID          [8]byte
+	sig            []byte
+	trustedComment string
+	globalSig      []byte
+}
+
+func parseMinisignSig(data []byte) (minisignSig, error) {
+	var s minisignSig
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return s, errors.New("not a minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return s, errors.New("invalid minisign signature")
+	}
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		s.prehashed = true
+	default:
+		return s, fmt.Errorf("unsupported minisign signature algorithm '%s'", raw[:2])
+	}
+	copy(s.keyID[:], raw[2:10])
+	s.sig = raw[10:]
+	s.trustedComment = strings.TrimPrefix(lines[2], "trusted comment: ")
+	if s.globalSig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3])); err != nil || len(s.globalSig) != ed25519.SignatureSize {
+		return s, errors.New("invalid minisign global signature")
+	}
+	return s, nil
+}
+
+// verifyMinisign verifies the minisign signature of a file with the key that made it.
+func verifyMinisign(path string, s minisignSig, keys []minisignKey) error {
+	var key *minisignKey
+	for i := range keys {
+		if keys[i].id == s.keyID {
+			key = &keys[i]
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("signed by the untrusted minisign key %X", reverse(s.keyID))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var msg []byte
+	if s.prehashed {
+		h, _ := blake2b.New512(nil)
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		msg = h.Sum(nil)
+	} else if msg, err = io.ReadAll(f); err != nil {
+		return err
+	}
+	if !ed25519.Verify(key.key, msg, s.sig) {
+		return errors.New("minisign signature doesn't match the file")
+	}
+	// the trusted comment is signed too, so it can't be swapped
+	if !ed25519.Verify(key.key, append(bytes.Clone(s.sig), s.trustedComment...), s.globalSig) {
+		return errors.New("minisign trusted comment signature is invalid")
+	}
+	return nil
+}
+
+// reverse returns the key ID in the byte order minisign prints it.
+func reverse(id [8]byte) []byte {
+	r := make([]byte, len(id))
+	for i, b := range id {
+		r[len(id)-1-i] = b
+	}
+	return r
+}
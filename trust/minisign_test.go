@@ -0,0 +1,140 @@
+package trust
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testMinisignKey is a minisign key pair for tests.
+type testMinisignKey struct {
+	id   [8]byte
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestMinisignKey(t *testing.T) testMinisignKey {
+	t.Helper()
+	var k testMinisignKey
+	var err error
+	if k.pub, k.priv, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	rand.Read(k.id[:])
+	return k
+}
+
+// writePublicKey writes the key as minisign -G does and returns the path.
+func (k testMinisignKey) writePublicKey(t *testing.T, dir string) string {
+	t.Helper()
+	raw := append(append([]byte("Ed"), k.id[:]...), k.pub...)
+	path := filepath.Join(dir, "minisign.pub")
+	data := "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sign returns a minisign signature file of data, with the "Ed" algorithm or the prehashed "ED".
+func (k testMinisignKey) sign(data []byte, alg, trustedComment string) []byte {
+	msg := data
+	if alg == "ED" {
+		h := blake2b.Sum512(data)
+		msg = h[:]
+	}
+	sig := ed25519.Sign(k.priv, msg)
+	globalSig := ed25519.Sign(k.priv, append(bytes.Clone(sig), trustedComment...))
+	raw := append(append([]byte(alg), k.id[:]...), sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n")
+}
+
+func TestMinisign(t *testing.T) {
+	key := newTestMinisignKey(t)
+	other := newTestMinisignKey(t)
+	font := []byte("font data")
+
+	tests := []struct {
+		name    string
+		sig     func() []byte
+		content []byte
+		wantErr bool
+	}{
+		{"Ed", func() []byte { return key.sign(font, "Ed", "timestamp:1") }, font, false},
+		{"ED prehashed", func() []byte { return key.sign(font, "ED", "timestamp:1") }, font, false},
+		{"changed file", func() []byte { return key.sign(font, "ED", "timestamp:1") }, []byte("font data!"), true},
+		{"swapped trusted comment", func() []byte {
+			return bytes.Replace(key.sign(font, "Ed", "timestamp:1"), []byte("timestamp:1"), []byte("timestamp:2"), 1)
+		}, font, true},
+		{"untrusted key", func() []byte { return other.sign(font, "Ed", "timestamp:1") }, font, true},
+		{"trusted key id, other key", func() []byte {
+			forged := other
+			forged.id = key.id
+			return forged.sign(font, "Ed", "timestamp:1")
+		}, font, true},
+		{"unknown algorithm", func() []byte { return key.sign(font, "Ex", "timestamp:1") }, font, true},
+		{"not a signature", func() []byte { return []byte("hello\n") }, font, true},
+	}
+	dir := t.TempDir()
+	keys, err := LoadKeys(key.writePublicKey(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &Verifier{Keys: keys}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Foo.ttf")
+			writeFile(t, path, tt.content)
+			writeFile(t, path+MinisignSuffix, tt.sig())
+			err := v.Verify(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBadSignature) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrBadSignature)
+			}
+		})
+	}
+}
+
+func TestParseMinisignKey(t *testing.T) {
+	key := newTestMinisignKey(t)
+	data, err := os.ReadFile(key.writePublicKey(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := parseMinisignKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.id != key.id || !k.key.Equal(key.pub) {
+		t.Errorf("parseMinisignKey() = %X %X, want %X %X", k.id, k.key, key.id, key.pub)
+	}
+	// the key line alone, as passed on the command line
+	if _, err := parseMinisignKey(bytes.Split(data, []byte("\n"))[1]); err != nil {
+		t.Errorf("parseMinisignKey() of the key line error = %v", err)
+	}
+	if _, err := parseMinisignKey([]byte("ssh-ed25519 AAAA")); err == nil {
+		t.Error("parseMinisignKey() accepted an SSH key")
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
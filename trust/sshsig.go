@@ -0,0 +1,154 @@
+package trust
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHSignatureSuffix is the file name suffix of SSH signatures, as written by ssh-keygen -Y sign.
+const SSHSignatureSuffix = ".sig"
+
+// SSHNamespace is the namespace font files must be signed with (ssh-keygen -Y sign -n file).
+const SSHNamespace = "file"
+
+const (
+	sshSigMagic     = "SSHSIG"
+	sshSigVersion   = 1
+	sshArmorBegin   = "-----BEGIN SSH SIGNATURE-----"
+	sshArmorEnd     = "-----END SSH SIGNATURE-----"
+	maxSignatureLen = 64 << 10
+)
+
+// parseSSHKeys parses SSH public keys in authorized_keys format, one per line.
+func parseSSHKeys(data []byte) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no SSH public key")
+	}
+	return keys, nil
+}
+
+// sshSig is a parsed SSH signature.
+type sshSig struct {
+	key       ssh.PublicKey
+	namespace string
+	reserved  []byte
+	hashAlg   string
+	sig       *ssh.Signature
+}
+
+// parseSSHSig parses an armored SSH signature (PROTOCOL.sshsig).
+func parseSSHSig(data []byte) (sshSig, error) {
+	var s sshSig
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, sshArmorBegin) || !strings.HasSuffix(text, sshArmorEnd) {
+		return s, errors.New("not an SSH signature")
+	}
+	text = strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimPrefix(text, sshArmorBegin), sshArmorEnd)), "")
+	blob, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return s, errors.New("invalid SSH signature encoding")
+	}
+	if !bytes.HasPrefix(blob, []byte(sshSigMagic)) || len(blob) < len(sshSigMagic)+4 {
+		return s, errors.New("invalid SSH signature")
+	}
+	r := blob[len(sshSigMagic):]
+	if binary.BigEndian.Uint32(r) != sshSigVersion {
+		return s, fmt.Errorf("unsupported SSH signature version %d", binary.BigEndian.Uint32(r))
+	}
+	r = r[4:]
+	var fields [5][]byte
+	for i := range fields {
+		if fields[i], r, err = sshString(r); err != nil {
+			return s, errors.New("invalid SSH signature")
+		}
+	}
+	if s.key, err = ssh.ParsePublicKey(fields[0]); err != nil {
+		return s, fmt.Errorf("invalid SSH signature key (%w)", err)
+	}
+	s.namespace, s.reserved, s.hashAlg = string(fields[1]), fields[2], string(fields[3])
+	s.sig = new(ssh.Signature)
+	if err := ssh.Unmarshal(fields[4], s.sig); err != nil {
+		return s, fmt.Errorf("invalid SSH signature (%w)", err)
+	}
+	return s, nil
+}
+
+// sshString reads a length prefixed string of the SSH wire format.
+func sshString(b []byte) (value, rest []byte, err error) {
+	if len(b) < 4 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	n := binary.BigEndian.Uint32(b)
+	if n > maxSignatureLen || int(n) > len(b)-4 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
+
+func appendSSHString(b []byte, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// verifySSHSig verifies the SSH signature of a file, made by one of the trusted keys.
+func verifySSHSig(path string, s sshSig, keys []ssh.PublicKey) error {
+	trusted := false
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), s.key.Marshal()) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return fmt.Errorf("signed by the untrusted SSH key %s", ssh.FingerprintSHA256(s.key))
+	}
+	if s.namespace != SSHNamespace {
+		return fmt.Errorf("SSH signature has namespace '%s', expected '%s'", s.namespace, SSHNamespace)
+	}
+	var h hash.Hash
+	switch s.hashAlg {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported SSH signature hash '%s'", s.hashAlg)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	signed := []byte(sshSigMagic)
+	signed = appendSSHString(signed, []byte(s.namespace))
+	signed = appendSSHString(signed, s.reserved)
+	signed = appendSSHString(signed, []byte(s.hashAlg))
+	signed = appendSSHString(signed, h.Sum(nil))
+	if err := s.key.Verify(signed, s.sig); err != nil {
+		return errors.New("SSH signature doesn't match the file")
+	}
+	return nil
+}
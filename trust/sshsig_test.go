@@ -0,0 +1,132 @@
+package trust
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSSHSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// sshSignBlob returns the SSHSIG blob of data, as ssh-keygen -Y sign -n <namespace> makes it.
+func sshSignBlob(t *testing.T, signer ssh.Signer, data []byte, namespace string) []byte {
+	t.Helper()
+	h := sha512.Sum512(data)
+	signed := []byte(sshSigMagic)
+	signed = appendSSHString(signed, []byte(namespace))
+	signed = appendSSHString(signed, nil)
+	signed = appendSSHString(signed, []byte("sha512"))
+	signed = appendSSHString(signed, h[:])
+	sig, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := binary.BigEndian.AppendUint32([]byte(sshSigMagic), sshSigVersion)
+	blob = appendSSHString(blob, signer.PublicKey().Marshal())
+	blob = appendSSHString(blob, []byte(namespace))
+	blob = appendSSHString(blob, nil)
+	blob = appendSSHString(blob, []byte("sha512"))
+	return appendSSHString(blob, ssh.Marshal(sig))
+}
+
+// armorSSHSig wraps an SSHSIG blob in the armor of ssh-keygen, with lines of 70 characters.
+func armorSSHSig(blob []byte) []byte {
+	text := base64.StdEncoding.EncodeToString(blob)
+	var b strings.Builder
+	b.WriteString(sshArmorBegin + "\n")
+	for len(text) > 70 {
+		b.WriteString(text[:70] + "\n")
+		text = text[70:]
+	}
+	b.WriteString(text + "\n" + sshArmorEnd + "\n")
+	return []byte(b.String())
+}
+
+func TestSSHSig(t *testing.T) {
+	signer := newTestSSHSigner(t)
+	other := newTestSSHSigner(t)
+	font := []byte("font data")
+
+	tests := []struct {
+		name    string
+		sig     func() []byte
+		content []byte
+		wantErr bool
+	}{
+		{"valid", func() []byte { return armorSSHSig(sshSignBlob(t, signer, font, SSHNamespace)) }, font, false},
+		{"changed file", func() []byte { return armorSSHSig(sshSignBlob(t, signer, font, SSHNamespace)) }, []byte("font data!"), true},
+		{"wrong namespace", func() []byte { return armorSSHSig(sshSignBlob(t, signer, font, "git")) }, font, true},
+		{"untrusted key", func() []byte { return armorSSHSig(sshSignBlob(t, other, font, SSHNamespace)) }, font, true},
+		{"truncated blob", func() []byte {
+			blob := sshSignBlob(t, signer, font, SSHNamespace)
+			return armorSSHSig(blob[:len(blob)-10])
+		}, font, true},
+		{"header only", func() []byte { return armorSSHSig([]byte(sshSigMagic)) }, font, true},
+		{"no armor", func() []byte { return sshSignBlob(t, signer, font, SSHNamespace) }, font, true},
+	}
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "allowed_keys")
+	writeFile(t, keyPath, ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	keys, err := LoadKeys(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &Verifier{Keys: keys}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Foo.ttf")
+			writeFile(t, path, tt.content)
+			writeFile(t, path+SSHSignatureSuffix, tt.sig())
+			err := v.Verify(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBadSignature) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrBadSignature)
+			}
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	a, b := newTestSSHSigner(t), newTestSSHSigner(t)
+	sshKeys := filepath.Join(dir, "allowed_keys")
+	writeFile(t, sshKeys, append(ssh.MarshalAuthorizedKey(a.PublicKey()), ssh.MarshalAuthorizedKey(b.PublicKey())...))
+	minisignKey := newTestMinisignKey(t).writePublicKey(t, dir)
+	keys, err := LoadKeys(sshKeys, minisignKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Len() != 3 || len(keys.ssh) != 2 || len(keys.minisign) != 1 {
+		t.Errorf("LoadKeys() = %d SSH and %d minisign keys, want 2 and 1", len(keys.ssh), len(keys.minisign))
+	}
+
+	garbage := filepath.Join(dir, "garbage")
+	writeFile(t, garbage, []byte("not a key\n"))
+	if _, err := LoadKeys(garbage); err == nil {
+		t.Error("LoadKeys() accepted a file without keys")
+	}
+	if _, err := LoadKeys(filepath.Join(dir, "missing")); err == nil {
+		t.Error("LoadKeys() accepted a missing file")
+	}
+}
@@ -0,0 +1,175 @@
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"fontctl/fonts"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	// ErrNotAllowed is returned for files whose hash is not in the allowlist.
+	ErrNotAllowed = errors.New("font file is not in the allowlist")
+	// ErrBadSignature is returned for files without a valid signature by a trusted key.
+	ErrBadSignature = errors.New("font file has no valid signature")
+)
+
+// Keys are the trusted public keys.
+type Keys struct {
+	minisign []minisignKey
+	ssh      []ssh.PublicKey
+}
+
+// Len returns the number of keys.
+func (k Keys) Len() int {
+	return len(k.minisign) + len(k.ssh)
+}
+
+// LoadKeys reads trusted public keys from files. A file has a minisign public key (as written by
+// minisign -G), or SSH public keys in authorized_keys format.
+func LoadKeys(paths ...string) (Keys, error) {
+	var keys Keys
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return keys, fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, path, fonts.WithAccessDenied(err))
+		}
+		if k, err := parseMinisignKey(data); err == nil {
+			keys.minisign = append(keys.minisign, k)
+			continue
+		}
+		k, err := parseSSHKeys(data)
+		if err != nil {
+			return keys, fmt.Errorf("'%s' is neither a minisign nor an SSH public key (%w)", path, err)
+		}
+		keys.ssh = append(keys.ssh, k...)
+	}
+	return keys, nil
+}
+
+// Verifier checks font files before they are installed or loaded. The zero value allows everything.
+type Verifier struct {
+	// Allowlist, if not nil, has the hashes of the files that are allowed.
+	Allowlist Allowlist
+	// Keys, if not empty, are the keys of which a file needs a valid signature.
+	Keys Keys
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+}
+
+func (v *Verifier) log() *slog.Logger {
+	if v.Logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return v.Logger
+}
+
+// Enabled reports whether the verifier checks anything.
+func (v *Verifier) Enabled() bool {
+	return v != nil && (v.Allowlist != nil || v.Keys.Len() > 0)
+}
+
+// Verify checks the hash and the signature of a file. Type 1 fonts are checked by both of their files.
+func (v *Verifier) Verify(path string) error {
+	return v.VerifyCopy(path, path)
+}
+
+// VerifyCopy is Verify for a copy of a file with the same name: the hash and the signature are checked
+// for the content of copyPath, the signature files are the ones next to path. Installers verify the
+// copy they install, so a file that is replaced after the check can't get installed unchecked.
+func (v *Verifier) VerifyCopy(path, copyPath string) error {
+	if !v.Enabled() {
+		return nil
+	}
+	paths := [][2]string{{path, copyPath}}
+	if fonts.IsType1Path(path) {
+		pfm, pfb, err := fonts.ResolveType1Files(path)
+		if err != nil {
+			return err
+		}
+		copyPFM, copyPFB, err := fonts.ResolveType1Files(copyPath)
+		if err != nil {
+			return err
+		}
+		paths = [][2]string{{pfm, copyPFM}, {pfb, copyPFB}}
+	}
+	for _, p := range paths {
+		if err := v.verifyFile(p[0], p[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyFile checks the content of contentPath, with the signature files of path.
+func (v *Verifier) verifyFile(path, contentPath string) error {
+	log := v.log()
+	if v.Allowlist != nil {
+		hash, err := fonts.HashFile(contentPath)
+		if err != nil {
+			return fmt.Errorf("%w '%s' (%w)", fonts.ErrFileNotFound, path, fonts.WithAccessDenied(err))
+		}
+		if !v.Allowlist.Contains(hash) {
+			return fmt.Errorf("%w: '%s' (%s)", ErrNotAllowed, path, fonts.FormatHash(hash))
+		}
+		log.Debug("font file is in the allowlist", "path", path)
+	}
+	return v.verifySignature(path, contentPath)
+}
+
+// VerifySignature checks only the signature of a file, i.e. of a font bundle. It returns nil if the
+// verifier has no keys.
+func (v *Verifier) VerifySignature(path string) error {
+	if v == nil {
+		return nil
+	}
+	return v.verifySignature(path, path)
+}
+
+// verifySignature checks the content of contentPath with the minisign or the SSH signature next to
+// path. It returns nil if the verifier has no keys.
+func (v *Verifier) verifySignature(path, contentPath string) error {
+	if v.Keys.Len() == 0 {
+		return nil
+	}
+	if err := v.signatureOf(path, contentPath); err != nil {
+		return fmt.Errorf("%w: '%s' (%w)", ErrBadSignature, path, err)
+	}
+	v.log().Debug("file has a valid signature", "path", path)
+	return nil
+}
+
+// signatureOf verifies the content of contentPath with the minisign or the SSH signature next to path.
+func (v *Verifier) signatureOf(path, contentPath string) error {
+	if data, err := os.ReadFile(path + MinisignSuffix); err == nil {
+		s, err := parseMinisignSig(data)
+		if err != nil {
+			return err
+		}
+		return verifyMinisign(contentPath, s, v.Keys.minisign)
+	}
+	if data, err := os.ReadFile(path + SSHSignatureSuffix); err == nil {
+		s, err := parseSSHSig(data)
+		if err != nil {
+			return err
+		}
+		return verifySSHSig(contentPath, s, v.Keys.ssh)
+	}
+	return fmt.Errorf("no signature file '%s' or '%s'", path+MinisignSuffix, path+SSHSignatureSuffix)
+}
+
+// WithoutSignatures returns a copy of the verifier that only checks the allowlist, for files whose
+// signature was checked another way, i.e. the fonts of a signed bundle.
+func (v *Verifier) WithoutSignatures() *Verifier {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	c.Keys = Keys{}
+	return &c
+}
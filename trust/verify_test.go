@@ -0,0 +1,75 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fontctl/fonts"
+)
+
+func TestVerifyCopyType1(t *testing.T) {
+	pfmData, pfbData := []byte("pfm data"), []byte("pfb data")
+	key := newTestMinisignKey(t)
+	keys, err := LoadKeys(key.writePublicKey(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pfmHash, pfbHash := sha256.Sum256(pfmData), sha256.Sum256(pfbData)
+
+	// the originals with their signatures, and a staged copy without
+	src, staged := t.TempDir(), t.TempDir()
+	pfm, pfb := filepath.Join(src, "Foo.pfm"), filepath.Join(src, "Foo.pfb")
+	writeFile(t, pfm, pfmData)
+	writeFile(t, pfb, pfbData)
+	writeFile(t, pfm+MinisignSuffix, key.sign(pfmData, "ED", "Foo.pfm"))
+	writeFile(t, pfb+MinisignSuffix, key.sign(pfbData, "ED", "Foo.pfb"))
+	copyPFM, copyPFB := filepath.Join(staged, "Foo.pfm"), filepath.Join(staged, "Foo.pfb")
+	writeFile(t, copyPFM, pfmData)
+	writeFile(t, copyPFB, pfbData)
+
+	both := Allowlist{fonts.FormatHash(pfmHash[:]): true, fonts.FormatHash(pfbHash[:]): true}
+	onlyPFM := Allowlist{fonts.FormatHash(pfmHash[:]): true}
+
+	tests := []struct {
+		name     string
+		verifier *Verifier
+		path     string
+		copyPath string
+		wantErr  error
+	}{
+		{"pair", &Verifier{Allowlist: both, Keys: keys}, pfm + "|" + pfb, copyPFM + "|" + copyPFB, nil},
+		{"pfm resolves the pfb", &Verifier{Allowlist: both, Keys: keys}, pfm, copyPFM, nil},
+		{"pfb resolves the pfm", &Verifier{Allowlist: both, Keys: keys}, pfb, copyPFB, nil},
+		{"pfb not allowed", &Verifier{Allowlist: onlyPFM}, pfm, copyPFM, ErrNotAllowed},
+		{"disabled", &Verifier{}, pfm, filepath.Join(staged, "Missing.pfm"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.verifier.VerifyCopy(tt.path, tt.copyPath); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyCopy() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// the copy is checked, not the original next to the signature
+	writeFile(t, copyPFB, []byte("swapped"))
+	v := &Verifier{Keys: keys}
+	if err := v.VerifyCopy(pfm, copyPFM); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifyCopy() of a changed copy error = %v, want %v", err, ErrBadSignature)
+	}
+	if err := os.Remove(pfb + MinisignSuffix); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(pfm); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Verify() without a signature of the pfb error = %v, want %v", err, ErrBadSignature)
+	}
+	if err := os.Remove(copyPFB); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyCopy(pfm, copyPFM); err == nil {
+		t.Error("VerifyCopy() without the pfb of the copy succeeded")
+	}
+}
@@ -0,0 +1,8 @@
+// Package watch keeps a font directory in sync with the system: fonts added to the directory get loaded
+// or installed, removed fonts get unloaded or uninstalled. Changes are debounced, so a batch of files
+// copied at once is applied together with a single font change notification, and files are only picked
+// up after they stopped changing.
+//
+// The engine (snapshots, diffing, debouncing) is operating system independent. What happens to the
+// fonts is up to the Backend, which the fontctl CLI implements with the winfont package.
+package watch
@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	"fontctl/fonts"
+)
+
+// FileInfo is the state of a font file in a Snapshot.
+type FileInfo struct {
+	Size    int64
+	ModTime time.Time
+	Hash    string // sha256:<hex>
+}
+
+// Snapshot is the content of a font directory: font file path to FileInfo.
+type Snapshot map[string]FileInfo
+
+// Changes are the differences between two snapshots, each ordered by path.
+type Changes struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// Empty reports whether there are no changes.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Scan returns a snapshot of the font files in dir (see fonts.FindFontFiles). Files are only hashed if
+// their size or modification time differs from prev, which can be nil. Files that can't be read (i.e.
+// still being copied) are left out.
+func Scan(dir string, prev Snapshot) (Snapshot, error) {
+	paths, err := fonts.FindFontFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	snap := make(Snapshot, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		fi := FileInfo{Size: info.Size(), ModTime: info.ModTime()}
+		if old, ok := prev[p]; ok && old.Size == fi.Size && old.ModTime.Equal(fi.ModTime) {
+			fi.Hash = old.Hash
+		} else {
+			hash, err := fonts.HashFile(p)
+			if err != nil {
+				continue
+			}
+			fi.Hash = fonts.FormatHash(hash)
+		}
+		snap[p] = fi
+	}
+	return snap, nil
+}
+
+// Diff returns the changes from old to new. A file is modified if its content changed.
+func Diff(old, new Snapshot) Changes {
+	var c Changes
+	for p, fi := range new {
+		prev, ok := old[p]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, p)
+		case prev.Hash != fi.Hash:
+			c.Modified = append(c.Modified, p)
+		}
+	}
+	for p := range old {
+		if _, ok := new[p]; !ok {
+			c.Removed = append(c.Removed, p)
+		}
+	}
+	slices.Sort(c.Added)
+	slices.Sort(c.Removed)
+	slices.Sort(c.Modified)
+	return c
+}
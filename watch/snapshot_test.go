@@ -0,0 +1,92 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeFont writes a file DetectFormat takes for a TrueType font, with content after the magic number.
+func writeFont(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("\x00\x01\x00\x00"+content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := Snapshot{
+		"a.ttf": {Size: 1, Hash: "sha256:a"},
+		"b.ttf": {Size: 1, Hash: "sha256:b"},
+		"c.ttf": {Size: 1, Hash: "sha256:c"},
+	}
+	new := Snapshot{
+		// a.ttf was touched, but has the same content
+		"a.ttf": {Size: 1, ModTime: time.Unix(1, 0), Hash: "sha256:a"},
+		"c.ttf": {Size: 2, Hash: "sha256:c2"},
+		"e.ttf": {Size: 1, Hash: "sha256:e"},
+		"d.ttf": {Size: 1, Hash: "sha256:d"},
+	}
+	got := Diff(old, new)
+	want := Changes{Added: []string{"d.ttf", "e.ttf"}, Removed: []string{"b.ttf"}, Modified: []string{"c.ttf"}}
+	if !slices.Equal(got.Added, want.Added) || !slices.Equal(got.Removed, want.Removed) || !slices.Equal(got.Modified, want.Modified) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if !Diff(old, old).Empty() {
+		t.Errorf("Diff() of a snapshot with itself = %+v, want no changes", Diff(old, old))
+	}
+	if got := Diff(nil, Snapshot{"a.ttf": {}}); !slices.Equal(got.Added, []string{"a.ttf"}) {
+		t.Errorf("Diff() from nil = %+v, want a.ttf added", got)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a", modTime)
+	writeFont(t, filepath.Join(dir, "sub", "b.otf"), "b", modTime)
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("no font"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.ttf"), []byte("no font"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := Scan(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := filepath.Join(dir, "a.ttf"), filepath.Join(dir, "sub", "b.otf")
+	if len(snap) != 2 || snap[a].Hash == "" || snap[b].Hash == "" || snap[a].Hash == snap[b].Hash {
+		t.Fatalf("Scan() = %v, want a.ttf and sub/b.otf with their hashes", snap)
+	}
+	if snap[a].Size != 5 || !snap[a].ModTime.Equal(modTime) {
+		t.Errorf("Scan() info of a.ttf = %+v, want size 5 and mod time %s", snap[a], modTime)
+	}
+
+	// same size and mod time, so the previous hash is taken without reading the file
+	writeFont(t, a, "x", modTime)
+	again, err := Scan(dir, snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[a].Hash != snap[a].Hash {
+		t.Errorf("Scan() hashed a.ttf again although its size and mod time didn't change")
+	}
+	writeFont(t, a, "x", modTime.Add(time.Second))
+	again, err = Scan(dir, snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again[a].Hash == snap[a].Hash {
+		t.Errorf("Scan() didn't hash a.ttf again after its mod time changed")
+	}
+}
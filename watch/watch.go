@@ -0,0 +1,266 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Backend applies the changes in the watched directory to the system, i.e. loads or installs fonts.
+// Add and Remove shouldn't notify applications about the font changes, Notify gets called once after
+// a batch of changes.
+type Backend interface {
+	Add(ctx context.Context, fontPath string) error
+	// Remove gets the info of the file as it was added, the file itself might be gone already.
+	Remove(ctx context.Context, fontPath string, added FileInfo) error
+	Notify(ctx context.Context) error
+}
+
+// Options are the settings of a Watcher.
+type Options struct {
+	// Logger receives structured debug messages. Can be nil.
+	Logger *slog.Logger
+	// Debounce is how long to wait for more changes before applying them, and how long a file must not
+	// have changed before it gets picked up. Defaults to two seconds.
+	Debounce time.Duration
+	// RemoveOnExit removes all added fonts when Run returns, i.e. to unload fonts that were only loaded.
+	RemoveOnExit bool
+	// OnSync is called after every Sync of Run with the applied changes and the error, if any. Can be nil.
+	OnSync func(applied Changes, err error)
+	// Now returns the current time. Defaults to time.Now, tests can use a fake clock.
+	Now func() time.Time
+}
+
+// Watcher keeps a font directory in sync with a Backend.
+type Watcher struct {
+	dir     string
+	backend Backend
+	opts    Options
+	log     *slog.Logger
+
+	scanned Snapshot          // last scan, to avoid hashing unchanged files again
+	applied Snapshot          // files the backend has added
+	failed  map[string]string // path to hash of files the backend failed to add, retried when they change
+}
+
+// New returns a Watcher for dir. Nothing happens until Sync or Run are called.
+func New(dir string, backend Backend, opts Options) *Watcher {
+	if opts.Debounce <= 0 {
+		opts.Debounce = 2 * time.Second
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	log := opts.Logger
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return &Watcher{
+		dir:     dir,
+		backend: backend,
+		opts:    opts,
+		log:     log,
+		applied: Snapshot{},
+		failed:  map[string]string{},
+	}
+}
+
+// Sync scans the directory once and applies the changes since the last Sync to the backend. It returns
+// the applied changes, and pending is true if some files are still changing and need another Sync later.
+// Errors of the backend are logged and the first one is returned, the other changes are still applied.
+func (w *Watcher) Sync(ctx context.Context) (applied Changes, pending bool, err error) {
+	prev := w.scanned
+	snap, err := Scan(w.dir, prev)
+	if err != nil {
+		return applied, false, err
+	}
+	w.scanned = snap
+
+	// files that changed recently might still be copied, keep their previous state until they settle.
+	// A file is settled if it is older than Debounce or didn't change since the last scan (file times on
+	// network shares can be ahead of the local clock).
+	now := w.opts.Now()
+	settled := make(Snapshot, len(snap))
+	for p, fi := range snap {
+		old, seen := prev[p]
+		unchanged := seen && old.Size == fi.Size && old.ModTime.Equal(fi.ModTime)
+		if !unchanged && now.Sub(fi.ModTime) < w.opts.Debounce {
+			pending = true
+			if was, ok := w.applied[p]; ok {
+				settled[p] = was
+			}
+			continue
+		}
+		settled[p] = fi
+	}
+
+	var firstErr error
+	fail := func(msg, path string, err error) {
+		w.log.Error(msg, "path", path, "error", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	changes := Diff(w.applied, settled)
+	for _, p := range changes.Removed {
+		if err := w.backend.Remove(ctx, p, w.applied[p]); err != nil {
+			// not retried, the font is gone from the directory either way
+			fail("failed to remove font", p, err)
+		}
+		delete(w.applied, p)
+		applied.Removed = append(applied.Removed, p)
+	}
+	for _, p := range changes.Modified {
+		if err := w.backend.Remove(ctx, p, w.applied[p]); err != nil {
+			fail("failed to remove previous version of font", p, err)
+		}
+		delete(w.applied, p)
+		if w.add(ctx, p, settled[p], fail) {
+			applied.Modified = append(applied.Modified, p)
+		}
+	}
+	for _, p := range changes.Added {
+		if w.failed[p] == settled[p].Hash {
+			continue
+		}
+		if w.add(ctx, p, settled[p], fail) {
+			applied.Added = append(applied.Added, p)
+		}
+	}
+	for p := range w.failed {
+		if _, ok := settled[p]; !ok {
+			delete(w.failed, p)
+		}
+	}
+
+	if !applied.Empty() {
+		w.log.Info("fonts changed", "added", len(applied.Added), "removed", len(applied.Removed), "modified", len(applied.Modified))
+		if err := w.backend.Notify(ctx); err != nil {
+			fail("failed to notify applications about font changes", w.dir, err)
+		}
+	}
+	return applied, pending, firstErr
+}
+
+// add adds a file to the backend and records the result.
+func (w *Watcher) add(ctx context.Context, path string, fi FileInfo, fail func(string, string, error)) bool {
+	if err := w.backend.Add(ctx, path); err != nil {
+		fail("failed to add font", path, err)
+		w.failed[path] = fi.Hash
+		return false
+	}
+	delete(w.failed, path)
+	w.applied[path] = fi
+	return true
+}
+
+// RemoveAll removes all added fonts from the backend.
+func (w *Watcher) RemoveAll(ctx context.Context) error {
+	var firstErr error
+	for p, fi := range w.applied {
+		if err := w.backend.Remove(ctx, p, fi); err != nil {
+			w.log.Error("failed to remove font", "path", p, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		delete(w.applied, p)
+	}
+	if err := w.backend.Notify(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// Run syncs the directory right away and then after every burst of events, until ctx is done or events
+// is closed. An event is any change in the directory, its details don't matter.
+func (w *Watcher) Run(ctx context.Context, events <-chan struct{}) error {
+	if w.opts.RemoveOnExit {
+		defer w.RemoveAll(context.WithoutCancel(ctx))
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			timer.Reset(w.opts.Debounce)
+		case <-timer.C:
+			applied, pending, err := w.Sync(ctx)
+			if err != nil {
+				w.log.Error("sync failed", "dir", w.dir, "error", err)
+			}
+			if w.opts.OnSync != nil && (err != nil || !applied.Empty()) {
+				w.opts.OnSync(applied, err)
+			}
+			if pending {
+				timer.Reset(w.opts.Debounce)
+			}
+		}
+	}
+}
+
+// WatchDir runs a Watcher for dir and its subdirs with filesystem notifications until ctx is done.
+func WatchDir(ctx context.Context, dir string, backend Backend, opts Options) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsw.Close()
+
+	w := New(dir, backend, opts)
+	addDirs := func(root string) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return err
+			}
+			w.log.Debug("watching dir", "dir", path)
+			return fsw.Add(path)
+		})
+	}
+	if err := addDirs(dir); err != nil {
+		return err
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				w.log.Debug("filesystem event", "path", ev.Name, "op", ev.Op.String())
+				if ev.Has(fsnotify.Create) {
+					// new subdirs need their own watch, fsnotify isn't recursive
+					if err := addDirs(ev.Name); err != nil {
+						w.log.Debug("can't watch new path", "path", ev.Name, "error", err)
+					}
+				}
+				select {
+				case events <- struct{}{}:
+				default: // a sync is already due
+				}
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+				w.log.Error("filesystem watch error", "dir", dir, "error", err)
+			}
+		}
+	}()
+
+	return w.Run(ctx, events)
+}
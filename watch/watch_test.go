@@ -0,0 +1,199 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend records the calls of a Watcher and fails to add the files in fail.
+type fakeBackend struct {
+	mu      sync.Mutex
+	calls   []string // "add <name>", "remove <name>" and "notify"
+	fail    map[string]bool
+	removed map[string]FileInfo
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{fail: map[string]bool{}, removed: map[string]FileInfo{}}
+}
+
+func (b *fakeBackend) Add(ctx context.Context, fontPath string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, "add "+filepath.Base(fontPath))
+	if b.fail[filepath.Base(fontPath)] {
+		return errors.New("can't add font")
+	}
+	return nil
+}
+
+func (b *fakeBackend) Remove(ctx context.Context, fontPath string, added FileInfo) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, "remove "+filepath.Base(fontPath))
+	b.removed[filepath.Base(fontPath)] = added
+	return nil
+}
+
+func (b *fakeBackend) Notify(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, "notify")
+	return nil
+}
+
+// takeCalls returns the calls since the last takeCalls.
+func (b *fakeBackend) takeCalls() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	calls := b.calls
+	b.calls = nil
+	return calls
+}
+
+// syncCalls runs a Sync and checks the backend calls it made.
+func syncCalls(t *testing.T, w *Watcher, b *fakeBackend, wantPending bool, want ...string) {
+	t.Helper()
+	_, pending, _ := w.Sync(context.Background())
+	if pending != wantPending {
+		t.Errorf("Sync() pending = %t, want %t", pending, wantPending)
+	}
+	if got := b.takeCalls(); !slices.Equal(got, want) {
+		t.Errorf("Sync() called %q, want %q", got, want)
+	}
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	old := now.Add(-time.Hour)
+	b := newFakeBackend()
+	w := New(dir, b, Options{Debounce: 10 * time.Second, Now: func() time.Time { return now }})
+
+	syncCalls(t, w, b, false)
+
+	// a batch of changes is applied with a single notification
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a", old)
+	writeFont(t, filepath.Join(dir, "b.ttf"), "b", old)
+	syncCalls(t, w, b, false, "add a.ttf", "add b.ttf", "notify")
+	syncCalls(t, w, b, false)
+
+	// a modified file is removed and added again, a removed one gets the info of when it was added
+	added := w.applied[filepath.Join(dir, "b.ttf")]
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a2", old.Add(time.Second))
+	if err := os.Remove(filepath.Join(dir, "b.ttf")); err != nil {
+		t.Fatal(err)
+	}
+	syncCalls(t, w, b, false, "remove b.ttf", "remove a.ttf", "add a.ttf", "notify")
+	if b.removed["b.ttf"] != added {
+		t.Errorf("Remove() got %+v, want the info of the added file %+v", b.removed["b.ttf"], added)
+	}
+}
+
+func TestSyncDebounce(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().Truncate(time.Second)
+	b := newFakeBackend()
+	w := New(dir, b, Options{Debounce: 10 * time.Second, Now: func() time.Time { return now }})
+
+	// a file that was just written might still be copied
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a", now)
+	syncCalls(t, w, b, true)
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a, more of it", now)
+	syncCalls(t, w, b, true)
+	// unchanged since the last scan, so it is complete
+	syncCalls(t, w, b, false, "add a.ttf", "notify")
+
+	// while a new version is written, the added one stays
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a2", now)
+	syncCalls(t, w, b, true)
+	now = now.Add(10 * time.Second)
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a3", now.Add(-11*time.Second))
+	// older than Debounce, so it is complete, even though it changed since the last scan
+	syncCalls(t, w, b, false, "remove a.ttf", "add a.ttf", "notify")
+}
+
+func TestSyncFailedAdd(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	b := newFakeBackend()
+	b.fail["a.ttf"] = true
+	w := New(dir, b, Options{})
+
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a", old)
+	if _, _, err := w.Sync(context.Background()); err == nil {
+		t.Error("Sync() didn't return the error of the backend")
+	}
+	if got := b.takeCalls(); !slices.Equal(got, []string{"add a.ttf"}) {
+		t.Errorf("Sync() called %q, want no notification without applied changes", got)
+	}
+	// not retried until the file changes
+	syncCalls(t, w, b, false)
+	b.fail["a.ttf"] = false
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a2", old)
+	syncCalls(t, w, b, false, "add a.ttf", "notify")
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeFont(t, filepath.Join(dir, "a.ttf"), "a", old)
+
+	b := newFakeBackend()
+	synced := make(chan Changes, 10)
+	w := New(dir, b, Options{
+		Debounce:     50 * time.Millisecond,
+		RemoveOnExit: true,
+		OnSync:       func(applied Changes, err error) { synced <- applied },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- w.Run(ctx, events) }()
+
+	waitSync := func() Changes {
+		t.Helper()
+		select {
+		case c := <-synced:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("no sync")
+			return Changes{}
+		}
+	}
+	if c := waitSync(); !slices.Equal(c.Added, []string{filepath.Join(dir, "a.ttf")}) {
+		t.Errorf("first sync applied %+v, want a.ttf added", c)
+	}
+
+	// a burst of events results in a single sync
+	writeFont(t, filepath.Join(dir, "b.ttf"), "b", old)
+	writeFont(t, filepath.Join(dir, "c.ttf"), "c", old)
+	for range 5 {
+		events <- struct{}{}
+	}
+	if c := waitSync(); len(c.Added) != 2 {
+		t.Errorf("sync after events applied %+v, want b.ttf and c.ttf added", c)
+	}
+	select {
+	case c := <-synced:
+		t.Errorf("another sync applied %+v", c)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	calls := b.takeCalls()
+	slices.Sort(calls)
+	want := []string{"add a.ttf", "add b.ttf", "add c.ttf", "notify", "notify", "notify", "remove a.ttf", "remove b.ttf", "remove c.ttf"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("backend calls = %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build windows
+
+package winfont
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"fontctl/fonts"
+
+	"golang.org/x/sys/windows"
+)
+
+// maxRemoveFontResource limits how often a font resource gets removed when replacing it. Every
+// AddFontResourceW call increments a reference count, so a font can be loaded more than once.
+const maxRemoveFontResource = 32
+
+// resolveConflict decides, according to opts.OnConflict, whether the installed file dst gets replaced
+// by src. It returns false without error if the installed file should be kept.
+func resolveConflict(src, dst string, opts InstallOptions) (bool, error) {
+	return fonts.ResolveConflict(opts.OnConflict, src, dst, opts.log())
+}
+
+// unloadAll removes a font resource until GDI reports it isn't loaded anymore.
+func unloadAll(gdiPath string, opts Options) {
+	for i := 0; i < maxRemoveFontResource; i++ {
+		if err := RemoveFont(gdiPath, opts); err != nil {
+			return
+		}
+	}
+}
+
+// replaceFile replaces dst with a copy of src. The copy is staged next to dst first, so dst is never left
+// half written. If dst is still in use (i.e. another process has the font open), the replacement is
+// scheduled for the next reboot, the staged file is returned and delayed is true.
+func replaceFile(src, dst string, opts Options) (staged string, delayed bool, err error) {
+	log := opts.log()
+	staged = stagingPath(dst)
+	if err := stageFile(src, staged); err != nil {
+		return "", false, err
+	}
+
+	err = os.Rename(staged, dst)
+	if err == nil {
+		log.Debug("file replaced", "source", src, "dest", dst)
+		return staged, false, nil
+	}
+	if !isInUse(err) {
+		os.Remove(staged)
+		return "", false, fmt.Errorf("failed to replace file '%s' (%w)", dst, fonts.WithAccessDenied(err))
+	}
+
+	log.Warn("installed file is in use, scheduling replacement on next reboot", "dest", dst, "staged", staged, "error", err)
+	stagedPtr, _ := windows.UTF16PtrFromString(staged)
+	dstPtr, _ := windows.UTF16PtrFromString(dst)
+	if err := windows.MoveFileEx(stagedPtr, dstPtr, windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_DELAY_UNTIL_REBOOT); err != nil {
+		os.Remove(staged)
+		return "", false, fmt.Errorf("file '%s' is in use and scheduling its replacement failed (%w)", dst, fonts.WithAccessDenied(err))
+	}
+	return staged, true, nil
+}
+
+// stagingPath returns the path replaceFile stages the new file at.
+func stagingPath(dst string) string {
+	return filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".fontctl-new")
+}
+
+// stageFile copies src to the staging path, replacing a leftover from an earlier attempt.
+func stageFile(src, staged string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file '%s' (%w)", src, fonts.WithAccessDenied(err))
+	}
+	defer srcFile.Close()
+	dstFile, err := os.OpenFile(staged, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create staging file '%s' (%w)", staged, fonts.WithAccessDenied(err))
+	}
+	_, err = io.Copy(dstFile, srcFile)
+	if err == nil {
+		err = dstFile.Sync()
+	}
+	if cerr := dstFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(staged)
+		return fmt.Errorf("failed to copy file '%s' to '%s' (%w)", src, staged, err)
+	}
+	return nil
+}
+
+// isInUse reports whether a file operation failed because another process has the file open.
+// We could write the staging file into the same dir, so access denied means the same here.
+func isInUse(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION) ||
+		errors.Is(err, windows.ERROR_USER_MAPPED_FILE) ||
+		errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
+
+// rebootRequired returns the error for a file replacement that got scheduled for the next reboot.
+func rebootRequired(dst string) error {
+	return fmt.Errorf("%w: '%s'", fonts.ErrRebootRequired, dst)
+}
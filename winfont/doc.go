@@ -0,0 +1,9 @@
+// Package winfont installs, uninstalls, loads and previews fonts on MS Windows. It implements the
+// win32 GDI font installation procedure (copy the font file, register it and add the font resource)
+// with cgo-free syscalls.
+//
+// All functions that touch the system take an options struct, whose zero value is a sensible default
+// (user scope, no logging). The high-level operations (install, uninstall, load, unload and the font
+// change broadcast) also take a context.Context; the single GDI and registry calls they are built from,
+// like AddFont or CreateWindowsFontRegistryKey, don't.
+package winfont
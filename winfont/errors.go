@@ -0,0 +1,64 @@
+//go:build windows
+
+package winfont
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+
+	"fontctl/fonts"
+)
+
+// GDIError is returned when a Windows font API call fails. It matches fonts.ErrGDI and unwraps to the
+// underlying syscall.Errno.
+type GDIError struct {
+	Op   string // name of the API call, i.e. "AddFontResourceW"
+	Path string // font file, empty for calls that don't take one
+	Err  error
+}
+
+func (e *GDIError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s failed (%v)", e.Op, e.Err)
+	}
+	return fmt.Sprintf("%s failed for '%s' (%v)", e.Op, e.Path, e.Err)
+}
+
+func (e *GDIError) Unwrap() error { return e.Err }
+
+func (e *GDIError) Is(target error) bool {
+	return target == fonts.ErrGDI || (target == fonts.ErrAccessDenied && errors.Is(e.Err, fs.ErrPermission))
+}
+
+// RegistryError is returned when reading or writing the font registry keys fails. It matches
+// fonts.ErrRegistry, and fonts.ErrAccessDenied when the underlying error is a permission error.
+type RegistryError struct {
+	Op    string // i.e. "open key", "set value"
+	Key   string // full key path including the hive, i.e. `HKLM\SOFTWARE\...`
+	Value string // registry value name, empty for key operations
+	Err   error
+}
+
+func (e *RegistryError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("failed to %s '%s' (%v)", e.Op, e.Key, e.Err)
+	}
+	return fmt.Sprintf("failed to %s '%s' in '%s' (%v)", e.Op, e.Value, e.Key, e.Err)
+}
+
+func (e *RegistryError) Unwrap() error { return e.Err }
+
+func (e *RegistryError) Is(target error) bool {
+	return target == fonts.ErrRegistry || (target == fonts.ErrAccessDenied && errors.Is(e.Err, fs.ErrPermission))
+}
+
+// winerrno returns the Windows error number of a failed syscall for logging, or 0 if err isn't one.
+func winerrno(err error) uint32 {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		return uint32(errno)
+	}
+	return 0
+}
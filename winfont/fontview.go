@@ -0,0 +1,37 @@
+//go:build windows
+
+package winfont
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"fontctl/fonts"
+)
+
+var (
+	fontviewExe = filepath.Join(os.Getenv("SYSTEMROOT"), "System32", "fontview.exe")
+)
+
+// PreviewFontWithFontview opens a font file in the Windows Font Viewer.
+func PreviewFontWithFontview(ctx context.Context, fontFile string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	if info, err := os.Stat(fontFile); err != nil || info.IsDir() {
+		log.Error("can't find or open file", "path", fontFile, "error", err)
+		return fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontFile)
+	}
+
+	// fontview.exe keeps running after we return, so it's not bound to ctx
+	cmd := exec.Command(fontviewExe, fontFile)
+	err := cmd.Start()
+	if err != nil {
+		return (err)
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+//go:build windows
+
+package winfont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"fontctl/fonts"
+)
+
+// AddFont adds a font resource to the system font table (AddFontResourceW).
+func AddFont(fontPath string, opts Options) error {
+	log := opts.log()
+	pathPtr, _ := syscall.UTF16PtrFromString(fontPath)
+	ret, err := addFontResource(pathPtr)
+	if err != nil {
+		log.Error("AddFontResourceW failed", "path", fontPath, "ret", ret, "error", err, "winerrno", winerrno(err))
+		return &GDIError{Op: "AddFontResourceW", Path: fontPath, Err: err}
+	}
+	log.Debug("font loaded", "path", fontPath, "ret", ret)
+	return nil
+}
+
+// RemoveFont removes a font resource from the system font table (RemoveFontResourceW).
+func RemoveFont(fontPath string, opts Options) error {
+	log := opts.log()
+	pathPtr, _ := syscall.UTF16PtrFromString(fontPath)
+	ret, err := removeFontResource(pathPtr)
+	if err != nil {
+		log.Error("RemoveFontResourceW failed", "path", fontPath, "ret", ret, "error", err, "winerrno", winerrno(err))
+		return &GDIError{Op: "RemoveFontResourceW", Path: fontPath, Err: err}
+	}
+	log.Debug("font unloaded", "path", fontPath, "ret", ret)
+	return nil
+}
+
+// NotifyFontChange sends a WM_FONTCHANGE broadcast so running applications become aware of font changes.
+// It does nothing if opts.DeferNotify is set.
+func NotifyFontChange(ctx context.Context, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	if opts.DeferNotify {
+		log.Debug("WM_FONTCHANGE broadcast deferred")
+		return nil
+	}
+	var result uintptr
+	ret, err := sendMessageTimeout(HWND_BROADCAST, WM_FONTCHANGE, 0, 0, SMTO_ABORTIFHUNG, 1, &result) // 1 ms timeout per window
+	if err != nil {
+		log.Error("SendMessageTimeoutW(WM_FONTCHANGE) failed", "ret", ret, "error", err, "winerrno", winerrno(err))
+		return &GDIError{Op: "SendMessageTimeoutW(WM_FONTCHANGE)", Err: err}
+	}
+	log.Debug("WM_FONTCHANGE broadcast sent", "ret", ret, "result", result)
+	return nil
+}
+
+// GetFontName returns the name of a loaded font resource as reported by GDI.
+func GetFontName(fontPath string, opts Options) (string, error) {
+	log := opts.log()
+	pathPtr, _ := syscall.UTF16PtrFromString(fontPath)
+	var bufferSize uint32
+
+	// First call: Get required buffer size
+	ret, err := getFontResourceInfo(pathPtr, &bufferSize, uintptr(0), DWINFO_FONT_DESCRIPTION)
+	if err != nil {
+		log.Debug("GetFontResourceInfoW (first call) failed, font either not loaded or other problem", "path", fontPath, "ret", ret, "error", err, "winerrno", winerrno(err))
+		// we return error here as either the font is not loaded (caller can load it and try again) or other error
+		return "", &GDIError{Op: "GetFontResourceInfoW", Path: fontPath, Err: err}
+	} else {
+		log.Debug("GetFontResourceInfoW (first call) successful", "path", fontPath, "ret", ret, "buffersize", bufferSize)
+	}
+
+	if bufferSize == 0 {
+		log.Error("GetFontResourceInfoW failed: API returned bufferSize = 0, meaning no data is available", "path", fontPath)
+		return "", &GDIError{Op: "GetFontResourceInfoW", Path: fontPath, Err: errors.New("API returned bufferSize = 0, meaning no data is available")}
+	}
+
+	fontName := make([]uint16, bufferSize/2)
+
+	// Second call: Retrieve actual font name
+	ret, err = getFontResourceInfo(pathPtr, &bufferSize, uintptr(unsafe.Pointer(&fontName[0])), DWINFO_FONT_DESCRIPTION)
+	if err != nil {
+		log.Error("GetFontResourceInfoW (second call) failed", "path", fontPath, "ret", ret, "error", err, "winerrno", winerrno(err))
+		return "", &GDIError{Op: "GetFontResourceInfoW", Path: fontPath, Err: err}
+	}
+	fontNameStr := syscall.UTF16ToString(fontName)
+	log.Debug("GetFontResourceInfoW (second call) successful", "path", fontPath, "ret", ret, "fontname", fontNameStr)
+	return fontNameStr, nil
+}
+
+// GetFontNameWithType returns the name under which the font gets registered, including the type suffix
+// (i.e. "Arial (TrueType)"). TrueType and OpenType fonts need to be loaded, names of legacy fonts are
+// read from the file.
+func GetFontNameWithType(fontPath string, opts Options) (string, error) {
+	log := opts.log()
+	format, err := fonts.DetectFormat(fontPath)
+	if err != nil {
+		return "", err
+	}
+	log.Debug("detected font format", "path", fontPath, "format", format.String())
+
+	// GDI can't tell us the registry names of legacy fonts, so we take them from the file
+	switch format {
+	case fonts.FormatType1:
+		pfm, pfb, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return "", err
+		}
+		font, err := fonts.ParseType1(pfm, pfb)
+		if err != nil {
+			return "", err
+		}
+		return font.RegistryName(), nil
+	case fonts.FormatBitmap:
+		info, err := fonts.ParseFon(fontPath)
+		if err != nil {
+			return "", err
+		}
+		return info.RegistryName(), nil
+	case fonts.FormatRawBitmap:
+		info, err := fonts.ParseFnt(fontPath)
+		if err != nil {
+			return "", err
+		}
+		return fonts.BitmapFontInfo{Resources: []fonts.FntInfo{info}}.RegistryName(), nil
+	}
+
+	fontName, err := GetFontName(fontPath, opts)
+	if err != nil {
+		return "", err
+	}
+	pathPtr, _ := syscall.UTF16PtrFromString(fontPath)
+	var fontType uint32
+	bufferSize := uint32(4) // DWORD is always 4 bytes. Right? Right?!
+	ret, err := getFontResourceInfo(pathPtr, &bufferSize, uintptr(unsafe.Pointer(&fontType)), DWINFO_FONT_TYPE)
+	if err == nil && fontType >= 1 {
+		fontName += registryTypeSuffix(format)
+	}
+	if err != nil {
+		log.Error("GetFontResourceInfoW(DWINFO_FONT_TYPE) failed", "path", fontPath, "ret", ret, "error", err, "winerrno", winerrno(err))
+	} else {
+		log.Debug("GetFontResourceInfoW(DWINFO_FONT_TYPE) successful", "path", fontPath, "ret", ret, "fonttype", fontType)
+	}
+	return fontName, nil
+}
+
+// LoadFontFromFile makes a font temporarily available to applications, until it gets unloaded or the next reboot.
+func LoadFontFromFile(ctx context.Context, fontPath string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	log.Debug("using font file", "path", fontPath)
+	fontPath, err := resolveGDIPath(fontPath, opts)
+	if err == nil {
+		err = opts.verify(fontPath, fontPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Load the font
+	if err := AddFont(fontPath, opts); err != nil {
+		return err
+	}
+	opts.trackLoad(fontPath)
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnloadFontFromFile removes a font that was loaded with LoadFontFromFile.
+func UnloadFontFromFile(ctx context.Context, fontPath string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	log.Debug("using font file", "path", fontPath)
+	fontPath, err := resolveGDIPath(fontPath, opts)
+	if err != nil {
+		return err
+	}
+
+	// Unload the font
+	if err := RemoveFont(fontPath, opts); err != nil {
+		return err
+	}
+	opts.trackUnload(fontPath)
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LoadFonts loads several fonts with a single WM_FONTCHANGE broadcast. If one of them fails to load,
+// the fonts loaded before are unloaded again.
+func LoadFonts(ctx context.Context, fontPaths []string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var loaded []string
+	rollback := func() {
+		for _, gdiPath := range loaded {
+			RemoveFont(gdiPath, opts)
+		}
+	}
+	for _, fontPath := range fontPaths {
+		gdiPath, err := resolveGDIPath(fontPath, opts)
+		if err == nil {
+			err = opts.verify(gdiPath, gdiPath)
+		}
+		if err == nil {
+			err = AddFont(gdiPath, opts)
+		}
+		if err != nil {
+			rollback()
+			return err
+		}
+		loaded = append(loaded, gdiPath)
+	}
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts); err != nil {
+		rollback()
+		return err
+	}
+	opts.trackLoad(loaded...)
+	return nil
+}
+
+// UnloadFonts unloads several fonts with a single WM_FONTCHANGE broadcast. It tries all fonts, even if
+// some fail, and returns the first error. Font files don't need to exist anymore.
+func UnloadFonts(ctx context.Context, fontPaths []string, opts Options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var firstErr error
+	var unloaded []string
+	for _, fontPath := range fontPaths {
+		gdiPath, err := resolveGDIPath(fontPath, opts)
+		if errors.Is(err, fonts.ErrFileNotFound) {
+			// GDI still knows a deleted (or moved) font by its path
+			gdiPath, err = fontPath, nil
+		}
+		if err == nil {
+			err = RemoveFont(gdiPath, opts)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		unloaded = append(unloaded, gdiPath)
+	}
+	opts.trackUnload(unloaded...)
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// GetFontNameFromFile returns the registry name of a font file. If needed, the font gets loaded temporarily.
+func GetFontNameFromFile(ctx context.Context, fontPath string, opts Options) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	log := opts.log()
+	log.Debug("using font file", "path", fontPath)
+	if fonts.IsType1Path(fontPath) {
+		// Type 1 names are read from the .pfm file, no need to load the font
+		return GetFontNameWithType(fontPath, opts)
+	}
+	if info, err := os.Stat(fontPath); err != nil || info.IsDir() {
+		log.Error("can't find or open file", "path", fontPath, "error", err)
+		return "", fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	}
+	fontIsLoadedBefore := true
+	var fontName string
+	var err error
+
+	// Retrieve the font name
+	fontName, err = GetFontNameWithType(fontPath, opts)
+	if err != nil {
+		if errors.Is(err, syscall.EINVAL) { // font either invalid or not loaded yet
+			fontIsLoadedBefore = false
+
+			// Load the font
+			if err := AddFont(fontPath, opts); err != nil {
+				return "", err
+			}
+
+			// Retrieve the font name
+			fontName, err = GetFontNameWithType(fontPath, opts)
+			if err != nil {
+				return "", err
+			}
+		} else {
+			return "", err
+		}
+	}
+	log.Debug("checked if font was loaded before", "path", fontPath, "loaded", fontIsLoadedBefore)
+	if !fontIsLoadedBefore {
+		// Unload the font, as it hadn't been loaded before we did
+		if err := RemoveFont(fontPath, opts); err != nil {
+			return "", err
+		}
+	}
+	return fontName, nil
+
+}
+
+// resolveGDIPath checks that the font file exists and returns the path in the notation that the GDI font
+// resource functions expect. For Type 1 fonts that's "<pfm>|<pfb>".
+func resolveGDIPath(fontPath string, opts Options) (string, error) {
+	log := opts.log()
+	if fonts.IsType1Path(fontPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return "", err
+		}
+		return pfm + "|" + pfb, nil
+	}
+	if info, err := os.Stat(fontPath); err != nil || info.IsDir() {
+		log.Error("can't find or open file", "path", fontPath, "error", err)
+		return "", fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	}
+	return fontPath, nil
+}
+
+// registryTypeSuffix returns the suffix Windows appends to the registry value name of a font with the given format.
+func registryTypeSuffix(format fonts.Format) string {
+	switch format {
+	case fonts.FormatTrueType, fonts.FormatTrueTypeCollection:
+		return " (TrueType)"
+	case fonts.FormatOpenType:
+		return " (OpenType)"
+	}
+	return ""
+}
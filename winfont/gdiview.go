@@ -1,4 +1,6 @@
-package main
+//go:build windows
+
+package winfont
 
 import (
 	"fmt"
@@ -16,6 +18,7 @@ const (
 	fontStyleStrikeOut
 )
 
+// PreviewFontWithGDI renders a preview of a loaded font in a GDI window and blocks until the window is closed.
 func PreviewFontWithGDI(fontName string, fontStyle string) {
 
 	var fontStyleB byte
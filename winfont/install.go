@@ -0,0 +1,391 @@
+//go:build windows
+
+package winfont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fontctl/fonts"
+
+	"golang.org/x/sys/windows"
+)
+
+// InstallFontFromFile copies a font into the user's or the system font dir, registers it and loads it.
+// The font files are copied to a staging dir first, which is verified and installed, so the installed
+// files are the verified ones.
+func InstallFontFromFile(ctx context.Context, fontPath string, opts InstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	installSystemWide := opts.SystemWide
+
+	log.Debug("using font file", "path", fontPath)
+
+	var type1 *fonts.Type1Font
+	if fonts.IsType1Path(fontPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return err
+		}
+		font, err := fonts.ParseType1(pfm, pfb)
+		if err != nil {
+			return err
+		}
+		type1 = &font
+	} else if info, err := os.Stat(fontPath); err != nil || info.IsDir() {
+		log.Error("can't find or open file", "path", fontPath, "error", err)
+		return fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	}
+	srcFiles := []string{fontPath}
+	if type1 != nil {
+		srcFiles = []string{type1.PFM, type1.PFB}
+	}
+	stageDir, staged, err := fonts.StageFiles(srcFiles...)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+	if err := opts.verify(srcFiles[0], staged[0]); err != nil {
+		return err
+	}
+
+	destPath, err := fontDir(installSystemWide)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("using destination font dir", "dir", destPath)
+
+	if fi, err := os.Stat(destPath); err != nil {
+		if os.IsNotExist(err) {
+			if !installSystemWide {
+				// if user font dir does not exist, make it
+				err = os.MkdirAll(destPath, 0755)
+				if err != nil {
+					return fmt.Errorf("user Font dir '%s' does not exist and trying to create it failed (%w)", destPath, fonts.WithAccessDenied(err))
+				}
+			} else {
+				// abort if system font dir does not exist
+				return fmt.Errorf("system font dir '%s' does not exist", destPath)
+			}
+		}
+	} else if !fi.IsDir() {
+		return fmt.Errorf("windows font dir path '%s' exists but is not a directory", destPath)
+	}
+
+	log.Debug("destination font dir exists and can be used", "dir", destPath)
+
+	if type1 != nil {
+		font := *type1
+		font.PFM, font.PFB = staged[0], staged[1]
+		return installType1Font(ctx, font, destPath, opts)
+	}
+
+	src := staged[0]
+	fontDestPath := filepath.Join(destPath, filepath.Base(src))
+
+	err = fonts.InstallFile(src, destPath, opts.log())
+	if errors.Is(err, fonts.ErrFileExistsAndIsDifferent) {
+		var replace bool
+		if replace, err = resolveConflict(src, fontDestPath, opts); err != nil || !replace {
+			return err
+		}
+		err = replaceInstalledFont(ctx, src, fontDestPath, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fi, err := os.Stat(fontDestPath); err != nil || fi.IsDir() {
+		log.Error("can't find or open destination font file after copy", "path", fontDestPath, "error", err)
+		return fmt.Errorf("%w '%s' at destination path after copy", fonts.ErrFileNotFound, fontDestPath)
+	}
+
+	// Load the font
+	if err := AddFont(fontDestPath, opts.Options); err != nil {
+		return err
+	}
+
+	// Retrieve the font name
+	fontName, err := GetFontNameWithType(fontDestPath, opts.Options)
+	if err != nil {
+		return err
+
+	}
+
+	err = CreateWindowsFontRegistryKey(fontName, fontDestPath, !installSystemWide, opts.Options)
+	if err != nil {
+		return err
+	}
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts.Options); err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// UninstallFontFromFile unloads, unregisters and deletes the installed copy of a font file.
+func UninstallFontFromFile(ctx context.Context, fontPath string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	removeSystemWide := opts.SystemWide
+
+	log.Debug("using font file", "path", fontPath)
+
+	var type1 *fonts.Type1Font
+	if fonts.IsType1Path(fontPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return err
+		}
+		font, err := fonts.ParseType1(pfm, pfb)
+		if err != nil {
+			return err
+		}
+		type1 = &font
+	} else if info, err := os.Stat(fontPath); err != nil || info.IsDir() {
+		log.Error("can't find or open file", "path", fontPath, "error", err)
+		return fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	}
+
+	destPath, err := fontDir(removeSystemWide)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("using destination font dir", "dir", destPath)
+
+	if fi, err := os.Stat(destPath); err != nil {
+		if os.IsNotExist(err) {
+			if !removeSystemWide {
+				return fmt.Errorf("user Font dir '%s' does not exist and trying to create it failed (%s)", destPath, err)
+			}
+		} else {
+			// abort if system font dir does not exist
+			return fmt.Errorf("system font dir '%s' does not exist", destPath)
+		}
+	} else if !fi.IsDir() {
+		return fmt.Errorf("windows font dir path '%s' exists but is not a directory", destPath)
+	}
+
+	log.Debug("destination font dir exists and can be used", "dir", destPath)
+
+	if type1 != nil {
+		installed := *type1
+		installed.PFM = filepath.Join(destPath, filepath.Base(type1.PFM))
+		installed.PFB = filepath.Join(destPath, filepath.Base(type1.PFB))
+		for _, f := range [][2]string{{type1.PFM, installed.PFM}, {type1.PFB, installed.PFB}} {
+			if err := fonts.VerifyInstalledCopy(f[0], f[1]); err != nil {
+				return err
+			}
+		}
+		return uninstallType1Font(ctx, *type1, destPath, opts)
+	}
+
+	fontDestPath := filepath.Join(destPath, filepath.Base(fontPath))
+
+	// never delete a different font that just happens to have the same file name
+	if err := fonts.VerifyInstalledCopy(fontPath, fontDestPath); err != nil {
+		return err
+	}
+
+	return uninstallFile(ctx, fontDestPath, opts)
+}
+
+// uninstallFile unloads, unregisters and deletes an installed font file.
+func uninstallFile(ctx context.Context, fontDestPath string, opts UninstallOptions) error {
+	log := opts.log()
+
+	err := UnloadFontFromFile(ctx, fontDestPath, opts.Options)
+	if err != nil {
+		log.Warn("failed to unload font from file during uninstall, this can be okay", "path", fontDestPath, "error", err)
+	}
+
+	err = RemoveWindowsFontRegistryKeys(fontDestPath, !opts.SystemWide, opts.Options)
+	if err != nil {
+		log.Warn("failed finding and removing font registry key during uninstall, this can be okay", "path", fontDestPath, "error", err)
+	}
+
+	err = os.Remove(fontDestPath)
+	if err != nil {
+		return fmt.Errorf("failed to remove file '%s' (%w), uninstall incomplete", fontDestPath, fonts.WithAccessDenied(err))
+	}
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts.Options); err != nil {
+		log.Warn("failed to send WM_FONTCHANGE broadcast during uninstall, this can be okay", "path", fontDestPath, "error", err)
+	}
+
+	return nil
+}
+
+func installType1Font(ctx context.Context, font fonts.Type1Font, destPath string, opts InstallOptions) error {
+	installed := font
+	installed.PFM = filepath.Join(destPath, filepath.Base(font.PFM))
+	installed.PFB = filepath.Join(destPath, filepath.Base(font.PFB))
+
+	var err error
+	for _, src := range []string{font.PFM, font.PFB} {
+		if err = fonts.InstallFile(src, destPath, opts.log()); err != nil {
+			break
+		}
+	}
+	if errors.Is(err, fonts.ErrFileExistsAndIsDifferent) {
+		var replace bool
+		if replace, err = resolveConflict(font.PFM, installed.PFM, opts); err != nil || !replace {
+			return err
+		}
+		err = replaceInstalledType1Font(ctx, font, installed, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Load the font
+	if err := AddFont(installed.GDIPath(), opts.Options); err != nil {
+		return err
+	}
+
+	err = CreateWindowsType1FontRegistryKey(installed.RegistryName(), installed.PFM, installed.PFB, !opts.SystemWide, opts.Options)
+	if err != nil {
+		return err
+	}
+
+	// Send WM_FONTCHANGE broadcast
+	if err := NotifyFontChange(ctx, opts.Options); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func uninstallType1Font(ctx context.Context, font fonts.Type1Font, destPath string, opts UninstallOptions) error {
+	log := opts.log()
+	installed := font
+	installed.PFM = filepath.Join(destPath, filepath.Base(font.PFM))
+	installed.PFB = filepath.Join(destPath, filepath.Base(font.PFB))
+
+	err := UnloadFontFromFile(ctx, installed.GDIPath(), opts.Options)
+	if err != nil {
+		log.Warn("failed to unload font from file during uninstall, this can be okay", "path", installed.GDIPath(), "error", err)
+	}
+
+	err = RemoveWindowsType1FontRegistryKeys(installed.PFM, !opts.SystemWide, opts.Options)
+	if err != nil {
+		log.Warn("failed finding and removing Type 1 font registry key during uninstall, this can be okay", "path", installed.PFM, "error", err)
+	}
+
+	for _, f := range []string{installed.PFM, installed.PFB} {
+		if err := os.Remove(f); err != nil {
+			return fmt.Errorf("failed to remove file '%s' (%w), uninstall incomplete", f, fonts.WithAccessDenied(err))
+		}
+	}
+
+	if err := NotifyFontChange(ctx, opts.Options); err != nil {
+		log.Warn("failed to send WM_FONTCHANGE broadcast during uninstall, this can be okay", "path", installed.GDIPath(), "error", err)
+	}
+
+	return nil
+}
+
+// replaceInstalledFont unloads and unregisters the installed font file dst and replaces it with src. The
+// caller loads and registers the new file afterwards. If dst is in use and can only be replaced on the next
+// reboot, the new font gets registered right away and an error wrapping fonts.ErrRebootRequired is returned.
+func replaceInstalledFont(ctx context.Context, src, dst string, opts InstallOptions) error {
+	log := opts.log()
+	unloadAll(dst, opts.Options)
+	if err := RemoveWindowsFontRegistryKeys(dst, !opts.SystemWide, opts.Options); err != nil {
+		log.Warn("failed finding and removing font registry key of the replaced font, this can be okay", "path", dst, "error", err)
+	}
+
+	staged, delayed, err := replaceFile(src, dst, opts.Options)
+	if err != nil || !delayed {
+		return err
+	}
+
+	// the registry entry already points to dst, which is the new font after the reboot
+	fontName, err := GetFontNameFromFile(ctx, staged, opts.Options)
+	if err != nil {
+		return err
+	}
+	if err := CreateWindowsFontRegistryKey(fontName, dst, !opts.SystemWide, opts.Options); err != nil {
+		return err
+	}
+	if err := NotifyFontChange(ctx, opts.Options); err != nil {
+		log.Warn("failed to send WM_FONTCHANGE broadcast after replacing font, this can be okay", "path", dst, "error", err)
+	}
+	return rebootRequired(dst)
+}
+
+// replaceInstalledType1Font is replaceInstalledFont for the .pfm/.pfb pair of a Type 1 font.
+func replaceInstalledType1Font(ctx context.Context, font, installed fonts.Type1Font, opts InstallOptions) error {
+	log := opts.log()
+	unloadAll(installed.GDIPath(), opts.Options)
+	if err := RemoveWindowsType1FontRegistryKeys(installed.PFM, !opts.SystemWide, opts.Options); err != nil {
+		log.Warn("failed finding and removing Type 1 font registry key of the replaced font, this can be okay", "path", installed.PFM, "error", err)
+	}
+
+	var rebootErr error
+	for _, f := range [][2]string{{font.PFM, installed.PFM}, {font.PFB, installed.PFB}} {
+		_, delayed, err := replaceFile(f[0], f[1], opts.Options)
+		if err != nil {
+			return err
+		}
+		if delayed {
+			rebootErr = rebootRequired(f[1])
+		}
+	}
+	if rebootErr == nil {
+		return nil
+	}
+
+	err := CreateWindowsType1FontRegistryKey(installed.RegistryName(), installed.PFM, installed.PFB, !opts.SystemWide, opts.Options)
+	if err != nil {
+		return err
+	}
+	if err := NotifyFontChange(ctx, opts.Options); err != nil {
+		log.Warn("failed to send WM_FONTCHANGE broadcast after replacing font, this can be okay", "path", installed.GDIPath(), "error", err)
+	}
+	return rebootErr
+}
+
+// fontDir returns the Windows font dir, or the current user's font dir if systemWide is false.
+func fontDir(systemWide bool) (string, error) {
+	if systemWide {
+		winDir, err := windows.GetSystemWindowsDirectory()
+		if err != nil {
+			return "", fmt.Errorf("can't find Windows system dir")
+		}
+		return filepath.Join(winDir, "Fonts"), nil
+	}
+	localAppData, err := windows.KnownFolderPath(windows.FOLDERID_LocalAppData, 0)
+	if err != nil {
+		return "", fmt.Errorf("can't find User localappdata dir")
+	}
+	return filepath.Join(localAppData, "Microsoft", "Windows", "Fonts"), nil
+}
+
+// FontDirs returns the system and the user font dir, as far as they exist.
+func FontDirs() []string {
+	var dirs []string
+	for _, systemWide := range []bool{true, false} {
+		dir, err := fontDir(systemWide)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
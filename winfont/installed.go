@@ -0,0 +1,92 @@
+//go:build windows
+
+package winfont
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// InstalledFont is a font registered in the font registry keys of HKLM or HKCU.
+type InstalledFont struct {
+	RegisteredFont
+	Path    string // absolute path of the font file, the .pfm file for Type 1 fonts
+	PFBPath string // absolute path of the .pfb file of Type 1 fonts
+	User    bool   // registered in HKCU
+}
+
+// InstalledFonts returns the fonts registered for all users (HKLM) and the current user (HKCU), in
+// that order. Relative paths are resolved against the Windows font dir, like Windows does. A missing
+// HKCU Fonts key is not an error, it only exists once a user font was installed.
+func InstalledFonts(opts Options) ([]InstalledFont, error) {
+	log := opts.log()
+	systemDir, err := fontDir(true)
+	if err != nil {
+		return nil, err
+	}
+	var list []InstalledFont
+	for _, user := range []bool{false, true} {
+		registered, err := ListWindowsFontRegistryKeys(user, opts)
+		if err != nil {
+			if user {
+				log.Debug("can't list user fonts", "error", err)
+				continue
+			}
+			return nil, err
+		}
+		for _, r := range registered {
+			f := InstalledFont{RegisteredFont: r, Path: absFontPath(systemDir, r.File), User: user}
+			if r.PFB != "" {
+				f.PFBPath = absFontPath(systemDir, r.PFB)
+			}
+			list = append(list, f)
+		}
+	}
+	return list, nil
+}
+
+func absFontPath(dir, file string) string {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return filepath.Clean(file)
+}
+
+// IsInstalled reports whether a font file is installed: a file with the same name and content is in
+// the user's or the system font dir and registered. Type 1 fonts are checked by their .pfm file.
+func IsInstalled(fontPath string, systemWide bool, opts Options) (bool, error) {
+	if fonts.IsType1Path(fontPath) {
+		pfm, _, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return false, err
+		}
+		fontPath = pfm
+	}
+	dir, err := fontDir(systemWide)
+	if err != nil {
+		return false, err
+	}
+	installedPath := filepath.Join(dir, filepath.Base(fontPath))
+	hash, err := fonts.HashFile(fontPath)
+	if err != nil {
+		return false, err
+	}
+	installedHash, err := fonts.HashFile(installedPath)
+	if err != nil || !bytes.Equal(hash, installedHash) {
+		return false, nil
+	}
+	registered, err := ListWindowsFontRegistryKeys(!systemWide, opts)
+	if err != nil {
+		if !systemWide {
+			return false, nil // no user fonts registered yet
+		}
+		return false, err
+	}
+	return slices.ContainsFunc(registered, func(r RegisteredFont) bool {
+		return strings.EqualFold(absFontPath(dir, r.File), installedPath)
+	}), nil
+}
@@ -0,0 +1,312 @@
+//go:build windows
+
+package winfont
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const fontsKeyPath = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Fonts`
+
+// hive is where fonts are registered: HKLM, HKCU, or the hive of another user under HKU.
+type hive struct {
+	root   registry.Key
+	prefix string // subkey of root the hive is mounted at, only for HKU
+}
+
+// scopeHive returns HKCU (user) or HKLM.
+func scopeHive(user bool) hive {
+	if user {
+		return hive{root: registry.CURRENT_USER}
+	}
+	return hive{root: registry.LOCAL_MACHINE}
+}
+
+// path returns the path of a key of the hive relative to its root.
+func (h hive) path(keyPath string) string {
+	if h.prefix == "" {
+		return keyPath
+	}
+	return h.prefix + `\` + keyPath
+}
+
+// name returns the full name of a key of the hive for messages.
+func (h hive) name(keyPath string) string {
+	return registryKeyName(h.root, h.path(keyPath))
+}
+
+// CreateWindowsFontRegistryKey registers a font file under the given name in the Fonts key of HKCU (user) or HKLM.
+// If the name is already taken by a different file, a " (n)" suffix is added.
+func CreateWindowsFontRegistryKey(fontName, fontFile string, user bool, opts Options) error {
+	return createFontRegistryValue(scopeHive(user), fontName, fontFile, opts)
+}
+
+// createFontRegistryValue is CreateWindowsFontRegistryKey for any hive. Only HKLM gets the filename,
+// user hives get the absolute path.
+func createFontRegistryValue(h hive, fontName, fontFile string, opts Options) error {
+	log := opts.log()
+	if h.root == registry.LOCAL_MACHINE { // For HLKM use only the filename
+		fontFile = filepath.Base(fontFile)
+	}
+
+	// the Fonts key of a user only exists once a user font was installed
+	k, _, err := registry.CreateKey(h.root, h.path(fontsKeyPath), registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return &RegistryError{Op: "open key", Key: h.name(fontsKeyPath), Err: err}
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return &RegistryError{Op: "read value names of", Key: h.name(fontsKeyPath), Err: err}
+	}
+
+	for _, name := range names {
+		val, _, err := k.GetStringValue(name)
+		if err == nil && strings.EqualFold(val, fontFile) {
+			log.Warn("font file is already registered", "key", h.name(fontsKeyPath), "value", name, "path", fontFile)
+			return nil
+		}
+	}
+
+	// the file isn't registered, so a value with the same name points to a different file
+	newFontName := uniqueValueName(names, fontName)
+	if newFontName != fontName {
+		log.Warn("font name already exists with a different file, using new value name", "key", h.name(fontsKeyPath), "value", fontName, "newvalue", newFontName)
+	}
+
+	if err := k.SetStringValue(newFontName, fontFile); err != nil {
+		return &RegistryError{Op: "set value", Key: h.name(fontsKeyPath), Value: newFontName, Err: err}
+	}
+
+	return nil
+}
+
+// RemoveWindowsFontRegistryKeys deletes all values in the Fonts key of HKCU (user) or HKLM that point to the font file.
+func RemoveWindowsFontRegistryKeys(fontFile string, user bool, opts Options) error {
+	return removeFontRegistryValues(scopeHive(user), fontFile, opts)
+}
+
+// removeFontRegistryValues is RemoveWindowsFontRegistryKeys for any hive.
+func removeFontRegistryValues(h hive, fontFile string, opts Options) error {
+	log := opts.log()
+	if h.root == registry.LOCAL_MACHINE { // for HKLM use only the filename
+		fontFile = filepath.Base(fontFile)
+	}
+
+	k, err := registry.OpenKey(h.root, h.path(fontsKeyPath), registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return &RegistryError{Op: "open key", Key: h.name(fontsKeyPath), Err: err}
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return &RegistryError{Op: "read value names of", Key: h.name(fontsKeyPath), Err: err}
+	}
+
+	found := false
+	for _, name := range names {
+		val, _, err := k.GetStringValue(name)
+		if err == nil && strings.EqualFold(val, fontFile) {
+			if err := k.DeleteValue(name); err != nil {
+				return &RegistryError{Op: "delete value", Key: h.name(fontsKeyPath), Value: name, Err: err}
+			}
+			log.Info("deleted registry value", "key", h.name(fontsKeyPath), "value", name, "path", fontFile)
+			found = true
+		}
+	}
+
+	if !found {
+		log.Warn("no registry values found for font file", "key", h.name(fontsKeyPath), "path", fontFile)
+
+	}
+
+	return nil
+}
+
+// uniqueValueName returns fontName, or fontName with the first free " (n)" suffix if a value of that name
+// already exists.
+func uniqueValueName(names []string, fontName string) string {
+	taken := func(name string) bool {
+		return slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) })
+	}
+	if !taken(fontName) {
+		return fontName
+	}
+	for index := 1; ; index++ {
+		if candidate := fmt.Sprintf("%s (%d)", fontName, index); !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// registryKeyName returns the full name of a registry key for messages, i.e. `HKLM\SOFTWARE\...`.
+func registryKeyName(baseKey registry.Key, path string) string {
+	switch baseKey {
+	case registry.LOCAL_MACHINE:
+		return `HKLM\` + path
+	case registry.CURRENT_USER:
+		return `HKCU\` + path
+	case registry.USERS:
+		return `HKU\` + path
+	}
+	return path
+}
+
+// Type 1 fonts are not registered in the Fonts key but in their own key, with a REG_MULTI_SZ value of
+// "T", "<pfm>", "<pfb>" per font.
+const type1FontsKeyPath = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Type 1 Installer\Type 1 Fonts`
+
+// CreateWindowsType1FontRegistryKey registers a Type 1 font in the Type 1 Fonts key of HKCU (user) or HKLM.
+func CreateWindowsType1FontRegistryKey(fontName, pfmFile, pfbFile string, user bool, opts Options) error {
+	return createType1FontRegistryValue(scopeHive(user), fontName, pfmFile, pfbFile, opts)
+}
+
+// createType1FontRegistryValue is CreateWindowsType1FontRegistryKey for any hive.
+func createType1FontRegistryValue(h hive, fontName, pfmFile, pfbFile string, opts Options) error {
+	log := opts.log()
+	if h.root == registry.LOCAL_MACHINE { // For HLKM use only the filename
+		pfmFile = filepath.Base(pfmFile)
+		pfbFile = filepath.Base(pfbFile)
+	}
+
+	k, _, err := registry.CreateKey(h.root, h.path(type1FontsKeyPath), registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return &RegistryError{Op: "open key", Key: h.name(type1FontsKeyPath), Err: err}
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return &RegistryError{Op: "read value names of", Key: h.name(type1FontsKeyPath), Err: err}
+	}
+
+	for _, name := range names {
+		val, _, err := k.GetStringsValue(name)
+		if err == nil && slices.ContainsFunc(val, func(v string) bool { return strings.EqualFold(v, pfmFile) }) {
+			log.Warn("font file is already registered", "key", h.name(type1FontsKeyPath), "value", name, "path", pfmFile)
+			return nil
+		}
+	}
+
+	newFontName := uniqueValueName(names, fontName)
+	if newFontName != fontName {
+		log.Warn("font name already exists with a different file, using new value name", "key", h.name(type1FontsKeyPath), "value", fontName, "newvalue", newFontName)
+	}
+
+	if err := k.SetStringsValue(newFontName, []string{"T", pfmFile, pfbFile}); err != nil {
+		return &RegistryError{Op: "set value", Key: h.name(type1FontsKeyPath), Value: newFontName, Err: err}
+	}
+
+	return nil
+}
+
+// RemoveWindowsType1FontRegistryKeys deletes all values in the Type 1 Fonts key of HKCU (user) or HKLM that reference the .pfm file.
+func RemoveWindowsType1FontRegistryKeys(pfmFile string, user bool, opts Options) error {
+	return removeType1FontRegistryValues(scopeHive(user), pfmFile, opts)
+}
+
+// removeType1FontRegistryValues is RemoveWindowsType1FontRegistryKeys for any hive.
+func removeType1FontRegistryValues(h hive, pfmFile string, opts Options) error {
+	log := opts.log()
+	if h.root == registry.LOCAL_MACHINE { // for HKLM use only the filename
+		pfmFile = filepath.Base(pfmFile)
+	}
+
+	k, err := registry.OpenKey(h.root, h.path(type1FontsKeyPath), registry.QUERY_VALUE|registry.SET_VALUE)
+	if err != nil {
+		return &RegistryError{Op: "open key", Key: h.name(type1FontsKeyPath), Err: err}
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return &RegistryError{Op: "read value names of", Key: h.name(type1FontsKeyPath), Err: err}
+	}
+
+	found := false
+	for _, name := range names {
+		val, _, err := k.GetStringsValue(name)
+		if err == nil && slices.ContainsFunc(val, func(v string) bool { return strings.EqualFold(v, pfmFile) }) {
+			if err := k.DeleteValue(name); err != nil {
+				return &RegistryError{Op: "delete value", Key: h.name(type1FontsKeyPath), Value: name, Err: err}
+			}
+			log.Info("deleted registry value", "key", h.name(type1FontsKeyPath), "value", name, "path", pfmFile)
+			found = true
+		}
+	}
+
+	if !found {
+		log.Warn("no registry values found for font file", "key", h.name(type1FontsKeyPath), "path", pfmFile)
+	}
+
+	return nil
+}
+
+// RegisteredFont is a font registered in the Fonts or the Type 1 Fonts key.
+type RegisteredFont struct {
+	Name string // registry value name, i.e. "Arial (TrueType)"
+	File string // font file as registered, only the filename in HKLM. The .pfm file for Type 1 fonts.
+	PFB  string // .pfb file of Type 1 fonts, empty for other fonts
+}
+
+// ListWindowsFontRegistryKeys returns all fonts registered in the Fonts and Type 1 Fonts keys of HKCU (user) or HKLM.
+func ListWindowsFontRegistryKeys(user bool, opts Options) ([]RegisteredFont, error) {
+	log := opts.log()
+	var baseKey registry.Key
+	if user {
+		baseKey = registry.CURRENT_USER
+	} else {
+		baseKey = registry.LOCAL_MACHINE
+	}
+
+	k, err := registry.OpenKey(baseKey, fontsKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, &RegistryError{Op: "open key", Key: registryKeyName(baseKey, fontsKeyPath), Err: err}
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, &RegistryError{Op: "read value names of", Key: registryKeyName(baseKey, fontsKeyPath), Err: err}
+	}
+
+	var list []RegisteredFont
+	for _, name := range names {
+		val, _, err := k.GetStringValue(name)
+		if err != nil {
+			log.Debug("skipping registry value that is not a string", "key", registryKeyName(baseKey, fontsKeyPath), "value", name, "error", err)
+			continue
+		}
+		list = append(list, RegisteredFont{Name: name, File: val})
+	}
+
+	t1, err := registry.OpenKey(baseKey, type1FontsKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		// the Type 1 Fonts key only exists if Type 1 fonts were ever installed
+		log.Debug("can't open Type 1 fonts key", "key", registryKeyName(baseKey, type1FontsKeyPath), "error", err)
+		return list, nil
+	}
+	defer t1.Close()
+
+	names, err = t1.ReadValueNames(0)
+	if err != nil {
+		return nil, &RegistryError{Op: "read value names of", Key: registryKeyName(baseKey, type1FontsKeyPath), Err: err}
+	}
+	for _, name := range names {
+		val, _, err := t1.GetStringsValue(name)
+		if err != nil || len(val) < 3 {
+			log.Debug("skipping invalid Type 1 font registry value", "key", registryKeyName(baseKey, type1FontsKeyPath), "value", name, "error", err)
+			continue
+		}
+		list = append(list, RegisteredFont{Name: name, File: val[1], PFB: val[2]})
+	}
+
+	return list, nil
+}
@@ -0,0 +1,145 @@
+//go:build windows
+
+package winfont
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fontctl/fonts"
+	"fontctl/state"
+
+	"golang.org/x/sys/windows"
+)
+
+// BootTime returns when Windows was started.
+func BootTime() time.Time {
+	return time.Now().Add(-time.Duration(getTickCount64()) * time.Millisecond)
+}
+
+// DefaultStateStore returns the state file for the fonts loaded in the current Windows session,
+// %LocalAppData%\fontctl\loaded-<session id>.json. Fonts loaded with AddFontResourceW belong to the
+// session, so each session has its own file.
+func DefaultStateStore() (*state.Store, error) {
+	localAppData, err := windows.KnownFolderPath(windows.FOLDERID_LocalAppData, 0)
+	if err != nil {
+		return nil, fmt.Errorf("can't find User localappdata dir")
+	}
+	var session uint32
+	if err := windows.ProcessIdToSessionId(windows.GetCurrentProcessId(), &session); err != nil {
+		return nil, fmt.Errorf("can't get Windows session id (%w)", err)
+	}
+	return &state.Store{
+		Path:     filepath.Join(localAppData, "fontctl", fmt.Sprintf("loaded-%d.json", session)),
+		BootTime: BootTime(),
+	}, nil
+}
+
+// LoadedFonts returns the fonts loaded by fontctl that are still loaded. Requires opts.State.
+func LoadedFonts(opts Options) ([]state.Entry, error) {
+	if opts.State == nil {
+		return nil, fmt.Errorf("no state file configured")
+	}
+	st, err := opts.State.Load()
+	if err != nil {
+		return nil, err
+	}
+	if st.Fonts == nil {
+		return []state.Entry{}, nil
+	}
+	return st.Fonts, nil
+}
+
+// UnloadAllFonts unloads all fonts loaded by fontctl, as often as they were loaded, and returns them.
+// Requires opts.State.
+func UnloadAllFonts(ctx context.Context, opts Options) ([]state.Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.State == nil {
+		return nil, fmt.Errorf("no state file configured")
+	}
+	log := opts.log()
+	var unloaded []state.Entry
+	err := opts.State.Update(func(st *state.State) error {
+		for _, e := range st.Fonts {
+			for i := 0; i < e.RefCount; i++ {
+				if err := RemoveFont(e.Path, opts); err != nil {
+					// i.e. unloaded by another tool, there is nothing left to do
+					log.Warn("failed to unload tracked font, dropping it", "path", e.Path, "error", err)
+					break
+				}
+			}
+			unloaded = append(unloaded, e)
+		}
+		st.Fonts = st.Fonts[:0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(unloaded) > 0 {
+		if err := NotifyFontChange(ctx, opts); err != nil {
+			return unloaded, err
+		}
+	}
+	return unloaded, nil
+}
+
+// trackLoad records fonts loaded by fontctl in the state file, if there is one. Failing to track
+// a font shouldn't fail the load, so errors are only logged.
+func (o Options) trackLoad(gdiPaths ...string) {
+	if o.State == nil {
+		return
+	}
+	now := time.Now()
+	err := o.State.Update(func(st *state.State) error {
+		for _, p := range gdiPaths {
+			st.Add(absGDIPath(p), fileHash(p), now)
+		}
+		return nil
+	})
+	if err != nil {
+		o.log().Warn("failed to record loaded fonts in state file", "path", o.State.Path, "error", err)
+	}
+}
+
+// trackUnload records fonts unloaded by fontctl in the state file, if there is one.
+func (o Options) trackUnload(gdiPaths ...string) {
+	if o.State == nil {
+		return
+	}
+	err := o.State.Update(func(st *state.State) error {
+		for _, p := range gdiPaths {
+			st.Remove(absGDIPath(p))
+		}
+		return nil
+	})
+	if err != nil {
+		o.log().Warn("failed to record unloaded fonts in state file", "path", o.State.Path, "error", err)
+	}
+}
+
+// fileHash returns the hash of a font file for the state file, the .pfm file for Type 1 fonts.
+func fileHash(gdiPath string) string {
+	path, _, _ := strings.Cut(gdiPath, "|")
+	hash, err := fonts.HashFile(path)
+	if err != nil {
+		return ""
+	}
+	return fonts.FormatHash(hash)
+}
+
+// absGDIPath makes a GDI font path absolute, so the state file works independent of the working dir.
+func absGDIPath(gdiPath string) string {
+	parts := strings.Split(gdiPath, "|")
+	for i, p := range parts {
+		if abs, err := filepath.Abs(p); err == nil {
+			parts[i] = abs
+		}
+	}
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,151 @@
+//go:build windows
+
+package winfont
+
+import (
+	"errors"
+
+	"fontctl/substitutes"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// registryKey is an open registry key for the substitutes rules. Its errors are RegistryErrors.
+type registryKey struct {
+	registry.Key
+	name string // full key path for messages
+}
+
+func (k registryKey) ReadValueNames(n int) ([]string, error) {
+	names, err := k.Key.ReadValueNames(n)
+	if err != nil {
+		return nil, &RegistryError{Op: "read value names of", Key: k.name, Err: err}
+	}
+	return names, nil
+}
+
+func (k registryKey) GetStringValue(name string) (string, uint32, error) {
+	val, valtype, err := k.Key.GetStringValue(name)
+	if err != nil {
+		return "", valtype, &RegistryError{Op: "read value", Key: k.name, Value: name, Err: err}
+	}
+	return val, valtype, nil
+}
+
+func (k registryKey) GetStringsValue(name string) ([]string, uint32, error) {
+	val, valtype, err := k.Key.GetStringsValue(name)
+	if err != nil {
+		return nil, valtype, &RegistryError{Op: "read value", Key: k.name, Value: name, Err: err}
+	}
+	return val, valtype, nil
+}
+
+func (k registryKey) SetStringValue(name, value string) error {
+	if err := k.Key.SetStringValue(name, value); err != nil {
+		return &RegistryError{Op: "set value", Key: k.name, Value: name, Err: err}
+	}
+	return nil
+}
+
+func (k registryKey) SetStringsValue(name string, value []string) error {
+	if err := k.Key.SetStringsValue(name, value); err != nil {
+		return &RegistryError{Op: "set value", Key: k.name, Value: name, Err: err}
+	}
+	return nil
+}
+
+func (k registryKey) DeleteValue(name string) error {
+	if err := k.Key.DeleteValue(name); err != nil {
+		return &RegistryError{Op: "delete value", Key: k.name, Value: name, Err: err}
+	}
+	return nil
+}
+
+// ListFontSubstitutes returns the FontSubstitutes rules of HKLM.
+func ListFontSubstitutes(opts Options) ([]substitutes.Substitute, error) {
+	var list []substitutes.Substitute
+	err := readRules(substitutes.FontSubstitutesKeyPath, func(k substitutes.Key) (err error) {
+		list, err = substitutes.ListSubstitutes(k)
+		return err
+	})
+	return list, err
+}
+
+// AddFontSubstitute makes applications asking for font get substitute, see substitutes.AddSubstitute.
+// Requires Admin privileges.
+func AddFontSubstitute(font, substitute string, replace bool, opts Options) error {
+	opts.log().Info("adding font substitute", "font", font, "substitute", substitute)
+	return writeRules(substitutes.FontSubstitutesKeyPath, opts, func(k substitutes.Key) error {
+		return substitutes.AddSubstitute(k, font, substitute, replace)
+	})
+}
+
+// RemoveFontSubstitute removes the FontSubstitutes rule of a font. Requires Admin privileges.
+func RemoveFontSubstitute(font string, opts Options) error {
+	opts.log().Info("removing font substitute", "font", font)
+	return writeRules(substitutes.FontSubstitutesKeyPath, opts, func(k substitutes.Key) error {
+		return substitutes.RemoveSubstitute(k, font)
+	})
+}
+
+// ListFontFallbacks returns the SystemLink rules of HKLM.
+func ListFontFallbacks(opts Options) ([]substitutes.Fallback, error) {
+	var list []substitutes.Fallback
+	err := readRules(substitutes.SystemLinkKeyPath, func(k substitutes.Key) (err error) {
+		list, err = substitutes.ListFallbacks(k)
+		return err
+	})
+	return list, err
+}
+
+// AddFontFallback adds a fallback font to the SystemLink rule of a font, see substitutes.AddFallback.
+// Requires Admin privileges.
+func AddFontFallback(font, link string, first bool, opts Options) error {
+	opts.log().Info("adding fallback font", "font", font, "link", link, "first", first)
+	return writeRules(substitutes.SystemLinkKeyPath, opts, func(k substitutes.Key) error {
+		return substitutes.AddFallback(k, font, link, first)
+	})
+}
+
+// RemoveFontFallback removes a fallback font, or all of them if link is empty, from the SystemLink rule
+// of a font. Requires Admin privileges.
+func RemoveFontFallback(font, link string, opts Options) error {
+	opts.log().Info("removing fallback font", "font", font, "link", link)
+	return writeRules(substitutes.SystemLinkKeyPath, opts, func(k substitutes.Key) error {
+		return substitutes.RemoveFallback(k, font, link)
+	})
+}
+
+// readRules opens a rules key of HKLM for reading.
+func readRules(keyPath string, fn func(k substitutes.Key) error) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return &RegistryError{Op: "open key", Key: registryKeyName(registry.LOCAL_MACHINE, keyPath), Err: err}
+	}
+	defer k.Close()
+	return fn(registryKey{Key: k, name: registryKeyName(registry.LOCAL_MACHINE, keyPath)})
+}
+
+// writeRules changes a rules key of HKLM. 32-bit applications read their own copy of the key under
+// WOW6432Node, so the change is applied to the 32-bit view too, if there is one. A rule that was already
+// removed from the 32-bit view is fine.
+func writeRules(keyPath string, opts Options, fn func(k substitutes.Key) error) error {
+	log := opts.log()
+	name := registryKeyName(registry.LOCAL_MACHINE, keyPath)
+	for _, view := range []uint32{registry.WOW64_64KEY, registry.WOW64_32KEY} {
+		k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE|registry.SET_VALUE|view)
+		if err != nil {
+			if view == registry.WOW64_32KEY && errors.Is(err, registry.ErrNotExist) {
+				log.Debug("no 32-bit view of key", "key", name)
+				continue
+			}
+			return &RegistryError{Op: "open key", Key: name, Err: err}
+		}
+		err = fn(registryKey{Key: k, name: name})
+		k.Close()
+		if err != nil && !(view == registry.WOW64_32KEY && errors.Is(err, substitutes.ErrNotFound)) {
+			return err
+		}
+	}
+	return nil
+}
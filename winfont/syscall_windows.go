@@ -0,0 +1,21 @@
+//go:generate go run golang.org/x/sys/windows/mkwinsyscall -output zsyscall_windows.go syscall_windows.go
+
+package winfont
+
+//sys addFontResource(fontPath *uint16) (ret int32, err error) = gdi32.AddFontResourceW
+//sys removeFontResource(fontPath *uint16) (ret int32, err error) = gdi32.RemoveFontResourceW
+//sys sendMessage(hWnd uintptr, msg uint32, wParam uintptr, lParam uintptr) (ret int32, err error) = user32.SendMessageW
+//sys getFontResourceInfo(fontPath *uint16, bufferSize *uint32, buffer uintptr, queryType uint32) (ret int32, err error) = gdi32.GetFontResourceInfoW
+//sys sendMessageTimeout(hWnd uintptr, msg uint32, wParam uintptr, lParam uintptr, fuFlags uintptr, uTimeout uintptr, lpdwResult *uintptr) (ret uintptr, err error) = user32.SendMessageTimeoutW
+//sys getTickCount64() (ms uint64) = kernel32.GetTickCount64
+//sys regLoadKey(key syscall.Handle, subkey *uint16, file *uint16) (regerrno error) = advapi32.RegLoadKeyW
+//sys regUnLoadKey(key syscall.Handle, subkey *uint16) (regerrno error) = advapi32.RegUnLoadKeyW
+
+const (
+	DWINFO_FONT_DESCRIPTION = 1
+	DWINFO_FONT_TYPE        = 3
+	WM_FONTCHANGE           = 0x001D
+	HWND_BROADCAST          = 0xFFFF
+	SMTO_ABORTIFHUNG        = 0x0002
+	VOLUME_NAME_DOS         = 0x0 // GetFinalPathNameByHandle returns a path with a drive letter
+)
@@ -0,0 +1,150 @@
+//go:build windows
+
+package winfont
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fontctl/fonts"
+)
+
+// UninstallFontByName uninstalls the font registered under the given registry value name
+// (i.e. "Foo Bold (TrueType)"), without needing the original font file.
+func UninstallFontByName(ctx context.Context, fontName string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	dir, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+
+	registered, err := ListWindowsFontRegistryKeys(!opts.SystemWide, opts.Options)
+	if err != nil {
+		return err
+	}
+	for _, r := range registered {
+		if !strings.EqualFold(r.Name, fontName) {
+			continue
+		}
+		log.Debug("found registered font", "name", r.Name, "file", r.File, "pfb", r.PFB)
+		pfm, err := registeredFilePath(dir, r.File)
+		if err != nil {
+			return err
+		}
+		if r.PFB == "" {
+			return uninstallFile(ctx, pfm, opts)
+		}
+		pfb, err := registeredFilePath(dir, r.PFB)
+		if err != nil {
+			return err
+		}
+		return uninstallType1Font(ctx, fonts.Type1Font{PFM: pfm, PFB: pfb}, dir, opts)
+	}
+	return fmt.Errorf("%w: no font named '%s' is registered", fonts.ErrNotInstalled, fontName)
+}
+
+// UninstallFontByPath uninstalls an installed font file. The file has to be in the font dir.
+func UninstallFontByPath(ctx context.Context, installedPath string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+	installedPath, err = filepath.Abs(installedPath)
+	if err != nil {
+		return err
+	}
+	if !isInDir(dir, installedPath) {
+		return fmt.Errorf("'%s' is not in the font dir '%s', uninstall aborted", installedPath, dir)
+	}
+
+	if fonts.IsType1Path(installedPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(installedPath)
+		if err != nil {
+			return err
+		}
+		return uninstallType1Font(ctx, fonts.Type1Font{PFM: pfm, PFB: pfb}, dir, opts)
+	}
+	if info, err := os.Stat(installedPath); err != nil || info.IsDir() {
+		return fmt.Errorf("%w '%s'", fonts.ErrNotInstalled, installedPath)
+	}
+	return uninstallFile(ctx, installedPath, opts)
+}
+
+// UninstallFontByHash uninstalls all font files in the font dir with the given content hash
+// ("sha256:<hex>", see fonts.ParseHash).
+func UninstallFontByHash(ctx context.Context, hash string, opts UninstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+	want, err := fonts.ParseHash(hash)
+	if err != nil {
+		return err
+	}
+	dir, err := fontDir(opts.SystemWide)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("can't read font dir '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+
+	var matches []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".fontctl-new") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, e.Name())
+		h, err := fonts.HashFile(path)
+		if err != nil {
+			log.Debug("can't hash file, skipping it", "path", path, "error", err)
+			continue
+		}
+		if bytes.Equal(h, want) {
+			matches = append(matches, path)
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("%w: no file with hash %s in '%s'", fonts.ErrNotInstalled, fonts.FormatHash(want), dir)
+	}
+
+	for _, path := range matches {
+		log.Debug("found installed file with matching hash", "path", path, "hash", fonts.FormatHash(want))
+		if err := UninstallFontByPath(ctx, path, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registeredFilePath returns the absolute path of a font file from the registry. Fonts registered in HKLM
+// only have a filename relative to the Windows font dir. Files outside of the font dir are not ours to
+// delete, so they are an error.
+func registeredFilePath(dir, file string) (string, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	if !isInDir(dir, file) {
+		return "", fmt.Errorf("registered font file '%s' is not in the font dir '%s', uninstall aborted", file, dir)
+	}
+	return filepath.Clean(file), nil
+}
+
+// isInDir reports whether path is a file directly in dir. Windows paths are case-insensitive.
+func isInDir(dir, path string) bool {
+	return strings.EqualFold(filepath.Dir(filepath.Clean(path)), filepath.Clean(dir))
+}
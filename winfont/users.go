@@ -0,0 +1,472 @@
+//go:build windows
+
+package winfont
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"fontctl/fonts"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const profileListKeyPath = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\ProfileList`
+
+// Privileges needed to load the registry hive of a user that isn't signed in.
+const (
+	seBackupPrivilege  = "SeBackupPrivilege"
+	seRestorePrivilege = "SeRestorePrivilege"
+)
+
+// UserProfile is the profile of a user account on this machine.
+type UserProfile struct {
+	SID  string // i.e. "S-1-5-21-...-1001"
+	Name string // i.e. `DOMAIN\jane`, empty if the account can't be looked up anymore
+	Dir  string // profile dir, i.e. `C:\Users\jane`
+}
+
+// FontDir returns the user font dir in the profile. Windows uses the local AppData dir of the user, which is
+// always in the profile.
+func (p UserProfile) FontDir() string {
+	return filepath.Join(p.Dir, "AppData", "Local", "Microsoft", "Windows", "Fonts")
+}
+
+// String returns the account name, or the SID if there is none.
+func (p UserProfile) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.SID
+}
+
+// UserProfiles returns the profiles of the local, domain and Entra ID accounts that have signed in on this
+// machine, from the ProfileList key of HKLM. Profiles of service accounts and profiles whose dir is gone
+// are skipped.
+func UserProfiles(opts Options) ([]UserProfile, error) {
+	log := opts.log()
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, profileListKeyPath, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, &RegistryError{Op: "open key", Key: registryKeyName(registry.LOCAL_MACHINE, profileListKeyPath), Err: err}
+	}
+	defer k.Close()
+
+	sids, err := k.ReadSubKeyNames(0)
+	if err != nil {
+		return nil, &RegistryError{Op: "read subkey names of", Key: registryKeyName(registry.LOCAL_MACHINE, profileListKeyPath), Err: err}
+	}
+
+	var list []UserProfile
+	for _, sid := range sids {
+		// S-1-5-21 are local and domain accounts, S-1-12-1 Entra ID accounts. Backups of broken
+		// profiles have a ".bak" suffix, which is no valid SID.
+		if !strings.HasPrefix(sid, "S-1-5-21-") && !strings.HasPrefix(sid, "S-1-12-1-") {
+			continue
+		}
+		s, err := windows.StringToSid(sid)
+		if err != nil {
+			log.Debug("skipping profile with invalid SID", "sid", sid, "error", err)
+			continue
+		}
+		dir, err := profileDir(sid)
+		if err != nil {
+			log.Debug("skipping profile without profile dir", "sid", sid, "error", err)
+			continue
+		}
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			log.Debug("skipping profile whose dir is gone", "sid", sid, "dir", dir, "error", err)
+			continue
+		}
+		p := UserProfile{SID: sid, Dir: dir}
+		if account, domain, _, err := s.LookupAccount(""); err == nil {
+			p.Name = domain + `\` + account
+		} else {
+			log.Debug("can't look up account of profile", "sid", sid, "error", err)
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// profileDir returns the expanded ProfileImagePath of a profile.
+func profileDir(sid string) (string, error) {
+	keyPath := profileListKeyPath + `\` + sid
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, keyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return "", &RegistryError{Op: "open key", Key: registryKeyName(registry.LOCAL_MACHINE, keyPath), Err: err}
+	}
+	defer k.Close()
+	dir, _, err := k.GetStringValue("ProfileImagePath")
+	if err != nil {
+		return "", &RegistryError{Op: "read value", Key: registryKeyName(registry.LOCAL_MACHINE, keyPath), Value: "ProfileImagePath", Err: err}
+	}
+	return registry.ExpandString(dir)
+}
+
+// LookupUserProfile returns the profile of a user given by account name (`jane`, `DOMAIN\jane` or
+// `jane@example.com`) or SID. An error wrapping fonts.ErrFileNotFound is returned if the user has no
+// profile on this machine, i.e. because they never signed in.
+func LookupUserProfile(user string, opts Options) (UserProfile, error) {
+	sid := user
+	if !strings.HasPrefix(strings.ToUpper(user), "S-1-") {
+		s, _, _, err := windows.LookupSID("", user)
+		if err != nil {
+			return UserProfile{}, fmt.Errorf("unknown user '%s' (%w)", user, err)
+		}
+		sid = s.String()
+	}
+	profiles, err := UserProfiles(opts)
+	if err != nil {
+		return UserProfile{}, err
+	}
+	i := slices.IndexFunc(profiles, func(p UserProfile) bool { return strings.EqualFold(p.SID, sid) })
+	if i < 0 {
+		return UserProfile{}, fmt.Errorf("%w: user '%s' has no profile on this machine", fonts.ErrFileNotFound, user)
+	}
+	return profiles[i], nil
+}
+
+// openUserHive returns the registry hive of a user. If the user is signed in, that's their hive under
+// HKU. Otherwise the NTUSER.DAT of the profile gets loaded under HKU, until closeHive is called. Loading a
+// hive needs the backup and restore privileges, which Admins have.
+func openUserHive(p UserProfile, opts Options) (h hive, closeHive func(), err error) {
+	log := opts.log()
+	if k, err := registry.OpenKey(registry.USERS, p.SID, registry.QUERY_VALUE); err == nil {
+		k.Close()
+		log.Debug("using loaded hive of user", "user", p, "key", registryKeyName(registry.USERS, p.SID))
+		return hive{root: registry.USERS, prefix: p.SID}, func() {}, nil
+	}
+
+	if err := enablePrivileges(seBackupPrivilege, seRestorePrivilege); err != nil {
+		return hive{}, nil, fmt.Errorf("%w: can't load the registry hive of '%s' (%w)", fonts.ErrAccessDenied, p, err)
+	}
+	mount := "fontctl-" + p.SID
+	hiveFile := filepath.Join(p.Dir, "NTUSER.DAT")
+	mountPtr, _ := syscall.UTF16PtrFromString(mount)
+	filePtr, _ := syscall.UTF16PtrFromString(hiveFile)
+	if err := regLoadKey(syscall.Handle(registry.USERS), mountPtr, filePtr); err != nil {
+		regErr := &RegistryError{Op: "load hive " + hiveFile + " into", Key: registryKeyName(registry.USERS, mount), Err: err}
+		if errors.Is(err, windows.ERROR_PRIVILEGE_NOT_HELD) {
+			return hive{}, nil, fmt.Errorf("%w: %w", fonts.ErrAccessDenied, regErr)
+		}
+		return hive{}, nil, regErr
+	}
+	log.Debug("loaded hive of user", "user", p, "file", hiveFile, "key", registryKeyName(registry.USERS, mount))
+	return hive{root: registry.USERS, prefix: mount}, func() {
+		if err := regUnLoadKey(syscall.Handle(registry.USERS), mountPtr); err != nil {
+			log.Warn("failed to unload hive of user", "user", p, "key", registryKeyName(registry.USERS, mount), "error", err, "winerrno", winerrno(err))
+		}
+	}, nil
+}
+
+// enablePrivileges enables privileges in the token of the process. Privileges the token doesn't have are
+// silently left out, the privileged call fails with ERROR_PRIVILEGE_NOT_HELD then.
+func enablePrivileges(names ...string) error {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token); err != nil {
+		return err
+	}
+	defer token.Close()
+	for _, name := range names {
+		namePtr, _ := syscall.UTF16PtrFromString(name)
+		var luid windows.LUID
+		if err := windows.LookupPrivilegeValue(nil, namePtr, &luid); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		privs := windows.Tokenprivileges{PrivilegeCount: 1}
+		privs.Privileges[0] = windows.LUIDAndAttributes{Luid: luid, Attributes: windows.SE_PRIVILEGE_ENABLED}
+		if err := windows.AdjustTokenPrivileges(token, false, &privs, uint32(unsafe.Sizeof(privs)), nil, nil); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// checkInProfile checks that writing to path, a file in the profile of a user, stays in the profile: no
+// existing part of the path may be a reparse point (symlink, junction or mount point), and the resolved
+// path must be in the resolved profile dir. Parts that don't exist yet are created by the caller.
+//
+// The check is only true at the time it is made. The font dir itself is pinned with openProfileDir while
+// the files are written, but the user can still replace a file in it with a link between the check and
+// the write, which needs the privilege to create symbolic links that users don't have by default.
+func checkInProfile(profile UserProfile, path string) error {
+	rel, ok := relInDir(profile.Dir, path)
+	if !ok {
+		return fmt.Errorf("'%s' is not in the profile dir '%s' of '%s'", path, profile.Dir, profile)
+	}
+	existing := profile.Dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		next := filepath.Join(existing, part)
+		fi, err := os.Lstat(next)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("can't check '%s' (%w)", next, fonts.WithAccessDenied(err))
+		}
+		if isReparsePoint(fi) {
+			return fmt.Errorf("'%s' in the profile of '%s' is a link, refusing to follow it", next, profile)
+		}
+		existing = next
+	}
+
+	root, err := filepath.EvalSymlinks(profile.Dir)
+	if err != nil {
+		return fmt.Errorf("can't resolve profile dir '%s' of '%s' (%w)", profile.Dir, profile, fonts.WithAccessDenied(err))
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return fmt.Errorf("can't resolve '%s' (%w)", existing, fonts.WithAccessDenied(err))
+	}
+	if _, ok := relInDir(root, resolved); !ok {
+		return fmt.Errorf("'%s' resolves to '%s', which is not in the profile dir '%s' of '%s'", existing, resolved, root, profile)
+	}
+	return nil
+}
+
+// openProfileDir opens dir, a dir in the profile of a user, without following a link, and checks that
+// it is a directory and no reparse point and that its final path is in the profile dir. The handle is
+// opened without FILE_SHARE_DELETE, so while it is open neither dir nor one of its parents can be renamed
+// or deleted and replaced with a link: the checked dir stays the one the files are written to.
+func openProfileDir(profile UserProfile, dir string) (windows.Handle, error) {
+	root, err := finalPath(profile.Dir)
+	if err != nil {
+		return 0, fmt.Errorf("can't resolve profile dir '%s' of '%s' (%w)", profile.Dir, profile, fonts.WithAccessDenied(err))
+	}
+	h, err := openDir(dir, windows.FILE_FLAG_OPEN_REPARSE_POINT)
+	if err != nil {
+		return 0, fmt.Errorf("can't open '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+	var info windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &info); err != nil {
+		windows.CloseHandle(h)
+		return 0, fmt.Errorf("can't check '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+	if info.FileAttributes&windows.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+		windows.CloseHandle(h)
+		return 0, fmt.Errorf("'%s' in the profile of '%s' is a link, refusing to follow it", dir, profile)
+	}
+	if info.FileAttributes&windows.FILE_ATTRIBUTE_DIRECTORY == 0 {
+		windows.CloseHandle(h)
+		return 0, fmt.Errorf("'%s' in the profile of '%s' is not a directory", dir, profile)
+	}
+	resolved, err := handlePath(h)
+	if err != nil {
+		windows.CloseHandle(h)
+		return 0, fmt.Errorf("can't resolve '%s' (%w)", dir, fonts.WithAccessDenied(err))
+	}
+	if _, ok := relInDir(root, resolved); !ok {
+		windows.CloseHandle(h)
+		return 0, fmt.Errorf("'%s' resolves to '%s', which is not in the profile dir '%s' of '%s'", dir, resolved, root, profile)
+	}
+	return h, nil
+}
+
+// openDir opens a dir for reading its attributes, sharing it for everything but renaming and deleting.
+func openDir(dir string, flags uint32) (windows.Handle, error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	return windows.CreateFile(p, windows.FILE_READ_ATTRIBUTES, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil,
+		windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS|flags, 0)
+}
+
+// finalPath returns the path of dir with all links resolved, see handlePath.
+func finalPath(dir string) (string, error) {
+	h, err := openDir(dir, 0)
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(h)
+	return handlePath(h)
+}
+
+// handlePath returns the final path of an open file, without the \\?\ prefix.
+func handlePath(h windows.Handle) (string, error) {
+	buf := make([]uint16, windows.MAX_PATH)
+	for {
+		n, err := windows.GetFinalPathNameByHandle(h, &buf[0], uint32(len(buf)), VOLUME_NAME_DOS)
+		if err != nil {
+			return "", err
+		}
+		if int(n) < len(buf) {
+			path := windows.UTF16ToString(buf[:n])
+			if rest, ok := strings.CutPrefix(path, `\\?\UNC\`); ok {
+				return `\\` + rest, nil
+			}
+			return strings.TrimPrefix(path, `\\?\`), nil
+		}
+		buf = make([]uint16, n)
+	}
+}
+
+// relInDir returns the path of path relative to dir, and whether it is in dir.
+func relInDir(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// isReparsePoint reports whether a file from os.Lstat is a symlink, junction or another reparse point.
+func isReparsePoint(fi fs.FileInfo) bool {
+	if fi.Mode()&(fs.ModeSymlink|fs.ModeIrregular) != 0 {
+		return true
+	}
+	attrs, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	return ok && attrs.FileAttributes&windows.FILE_ATTRIBUTE_REPARSE_POINT != 0
+}
+
+// InstallFontForUser copies a font into the font dir in the profile of another user and registers it in
+// their registry hive, with the absolute path like for the current user. The font isn't loaded, the user
+// gets it with their next sign-in. opts.SystemWide is ignored. Requires Admin privileges. Like with
+// InstallFontFromFile, the staged copies of the font files are verified and installed.
+func InstallFontForUser(ctx context.Context, fontPath string, profile UserProfile, opts InstallOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	log := opts.log()
+
+	log.Debug("using font file", "path", fontPath, "user", profile)
+
+	// pairs of source file and installed file, the first one is registered
+	destPath := profile.FontDir()
+	var files [][2]string
+	var type1 *fonts.Type1Font
+	if fonts.IsType1Path(fontPath) {
+		pfm, pfb, err := fonts.ResolveType1Files(fontPath)
+		if err != nil {
+			return err
+		}
+		font, err := fonts.ParseType1(pfm, pfb)
+		if err != nil {
+			return err
+		}
+		type1 = &font
+		files = [][2]string{{pfm, filepath.Join(destPath, filepath.Base(pfm))}, {pfb, filepath.Join(destPath, filepath.Base(pfb))}}
+	} else if info, err := os.Stat(fontPath); err != nil || info.IsDir() {
+		log.Error("can't find or open file", "path", fontPath, "error", err)
+		return fmt.Errorf("%w '%s'", fonts.ErrFileNotFound, fontPath)
+	} else {
+		files = [][2]string{{fontPath, filepath.Join(destPath, filepath.Base(fontPath))}}
+	}
+	var srcFiles []string
+	for _, f := range files {
+		srcFiles = append(srcFiles, f[0])
+	}
+	stageDir, staged, err := fonts.StageFiles(srcFiles...)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(stageDir)
+	if err := opts.verify(files[0][0], staged[0]); err != nil {
+		return err
+	}
+	for i := range files {
+		files[i][0] = staged[i]
+	}
+	// the profile belongs to the user, who can replace the font dir or the files in it with links to
+	// elsewhere, i.e. the Windows font dir, so every path is checked before it is written
+	checkDest := func() error {
+		for _, f := range files {
+			for _, dst := range []string{f[1], stagingPath(f[1])} {
+				if err := checkInProfile(profile, dst); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := checkDest(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destPath, 0755); err != nil {
+		return fmt.Errorf("font dir '%s' of '%s' does not exist and trying to create it failed (%w)", destPath, profile, fonts.WithAccessDenied(err))
+	}
+	log.Debug("destination font dir exists and can be used", "dir", destPath, "user", profile)
+
+	h, closeHive, err := openUserHive(profile, opts.Options)
+	if err != nil {
+		return err
+	}
+	defer closeHive()
+
+	// pin the font dir until the files are written
+	dir, err := openProfileDir(profile, destPath)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(dir)
+	if err := checkDest(); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err = fonts.InstallFile(f[0], destPath, opts.log()); err != nil {
+			break
+		}
+	}
+	// the name is read from the file that gets registered, which is only staged if it is in use
+	nameFile := files[0][1]
+	var rebootErr error
+	if errors.Is(err, fonts.ErrFileExistsAndIsDifferent) {
+		var replace bool
+		if replace, err = resolveConflict(files[0][0], files[0][1], opts); err != nil || !replace {
+			return err
+		}
+		// the old font can only be loaded in the session of the user, so it is just unregistered
+		if type1 != nil {
+			err = removeType1FontRegistryValues(h, files[0][1], opts.Options)
+		} else {
+			err = removeFontRegistryValues(h, files[0][1], opts.Options)
+		}
+		if err != nil {
+			log.Warn("failed finding and removing font registry key of the replaced font, this can be okay", "path", files[0][1], "error", err)
+		}
+		if err := checkDest(); err != nil {
+			return err
+		}
+		for i, f := range files {
+			staged, delayed, err := replaceFile(f[0], f[1], opts.Options)
+			if err != nil {
+				return err
+			}
+			if delayed {
+				rebootErr = rebootRequired(f[1])
+				if i == 0 {
+					nameFile = staged
+				}
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if type1 != nil {
+		installed := *type1
+		installed.PFM, installed.PFB = files[0][1], files[1][1]
+		err = createType1FontRegistryValue(h, installed.RegistryName(), installed.PFM, installed.PFB, opts.Options)
+	} else {
+		var fontName string
+		if fontName, err = GetFontNameFromFile(ctx, nameFile, opts.Options); err != nil {
+			return err
+		}
+		err = createFontRegistryValue(h, fontName, files[0][1], opts.Options)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info("installed font for user", "path", files[0][1], "user", profile)
+	return rebootErr
+}
@@ -1,6 +1,6 @@
 // Code generated by 'go generate'; DO NOT EDIT.
 
-package main
+package winfont
 
 import (
 	"syscall"
@@ -38,17 +38,38 @@ func errnoErr(e syscall.Errno) error {
 }
 
 var (
-	modgdi32  = windows.NewLazySystemDLL("gdi32.dll")
-	moduser32 = windows.NewLazySystemDLL("user32.dll")
+	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
+	modgdi32    = windows.NewLazySystemDLL("gdi32.dll")
+	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	moduser32   = windows.NewLazySystemDLL("user32.dll")
 
+	procRegLoadKeyW          = modadvapi32.NewProc("RegLoadKeyW")
+	procRegUnLoadKeyW        = modadvapi32.NewProc("RegUnLoadKeyW")
 	procAddFontResourceW     = modgdi32.NewProc("AddFontResourceW")
 	procGetFontResourceInfoW = modgdi32.NewProc("GetFontResourceInfoW")
 	procRemoveFontResourceW  = modgdi32.NewProc("RemoveFontResourceW")
+	procGetTickCount64       = modkernel32.NewProc("GetTickCount64")
 	procSendMessageTimeoutW  = moduser32.NewProc("SendMessageTimeoutW")
 	procSendMessageW         = moduser32.NewProc("SendMessageW")
 )
 
-func AddFontResource(fontPath *uint16) (ret int32, err error) {
+func regLoadKey(key syscall.Handle, subkey *uint16, file *uint16) (regerrno error) {
+	r0, _, _ := syscall.Syscall(procRegLoadKeyW.Addr(), 3, uintptr(key), uintptr(unsafe.Pointer(subkey)), uintptr(unsafe.Pointer(file)))
+	if r0 != 0 {
+		regerrno = syscall.Errno(r0)
+	}
+	return
+}
+
+func regUnLoadKey(key syscall.Handle, subkey *uint16) (regerrno error) {
+	r0, _, _ := syscall.Syscall(procRegUnLoadKeyW.Addr(), 2, uintptr(key), uintptr(unsafe.Pointer(subkey)), 0)
+	if r0 != 0 {
+		regerrno = syscall.Errno(r0)
+	}
+	return
+}
+
+func addFontResource(fontPath *uint16) (ret int32, err error) {
 	r0, _, e1 := syscall.Syscall(procAddFontResourceW.Addr(), 1, uintptr(unsafe.Pointer(fontPath)), 0, 0)
 	ret = int32(r0)
 	if ret == 0 {
@@ -57,7 +78,7 @@ func AddFontResource(fontPath *uint16) (ret int32, err error) {
 	return
 }
 
-func GetFontResourceInfo(fontPath *uint16, bufferSize *uint32, buffer uintptr, queryType uint32) (ret int32, err error) {
+func getFontResourceInfo(fontPath *uint16, bufferSize *uint32, buffer uintptr, queryType uint32) (ret int32, err error) {
 	r0, _, e1 := syscall.Syscall6(procGetFontResourceInfoW.Addr(), 4, uintptr(unsafe.Pointer(fontPath)), uintptr(unsafe.Pointer(bufferSize)), uintptr(buffer), uintptr(queryType), 0, 0)
 	ret = int32(r0)
 	if ret == 0 {
@@ -66,7 +87,7 @@ func GetFontResourceInfo(fontPath *uint16, bufferSize *uint32, buffer uintptr, q
 	return
 }
 
-func RemoveFontResource(fontPath *uint16) (ret int32, err error) {
+func removeFontResource(fontPath *uint16) (ret int32, err error) {
 	r0, _, e1 := syscall.Syscall(procRemoveFontResourceW.Addr(), 1, uintptr(unsafe.Pointer(fontPath)), 0, 0)
 	ret = int32(r0)
 	if ret == 0 {
@@ -75,7 +96,13 @@ func RemoveFontResource(fontPath *uint16) (ret int32, err error) {
 	return
 }
 
-func SendMessageTimeoutW(hWnd uintptr, msg uint32, wParam uintptr, lParam uintptr, fuFlags uintptr, uTimeout uintptr, lpdwResult *uintptr) (ret uintptr, err error) {
+func getTickCount64() (ms uint64) {
+	r0, _, _ := syscall.Syscall(procGetTickCount64.Addr(), 0, 0, 0, 0)
+	ms = uint64(r0)
+	return
+}
+
+func sendMessageTimeout(hWnd uintptr, msg uint32, wParam uintptr, lParam uintptr, fuFlags uintptr, uTimeout uintptr, lpdwResult *uintptr) (ret uintptr, err error) {
 	r0, _, e1 := syscall.Syscall9(procSendMessageTimeoutW.Addr(), 7, uintptr(hWnd), uintptr(msg), uintptr(wParam), uintptr(lParam), uintptr(fuFlags), uintptr(uTimeout), uintptr(unsafe.Pointer(lpdwResult)), 0, 0)
 	ret = uintptr(r0)
 	if ret == 0 {
@@ -84,7 +111,7 @@ func SendMessageTimeoutW(hWnd uintptr, msg uint32, wParam uintptr, lParam uintpt
 	return
 }
 
-func SendMessage(hWnd uintptr, msg uint32, wParam uintptr, lParam uintptr) (ret int32, err error) {
+func sendMessage(hWnd uintptr, msg uint32, wParam uintptr, lParam uintptr) (ret int32, err error) {
 	r0, _, e1 := syscall.Syscall6(procSendMessageW.Addr(), 4, uintptr(hWnd), uintptr(msg), uintptr(wParam), uintptr(lParam), 0, 0)
 	ret = int32(r0)
 	if ret == 0 {